@@ -1,9 +1,21 @@
 package main
 
-import "github.com/tanveerprottoy/stdlib-go-template/internal/template"
+import (
+	"os"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template"
+)
 
 func main() {
-	a := template.NewApp()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "secrets" {
+		runSecrets(os.Args[2:])
+		return
+	}
+	a := template.NewApp(os.Args[1:])
 	a.Run()
 }
 
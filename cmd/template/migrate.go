@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/migrate"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/secrets"
+	"github.com/tanveerprottoy/stdlib-go-template/migrations"
+)
+
+// migrationsDir is where `migrate new` writes the files
+const migrationsDir = "./migrations"
+
+const migrateUsage = `usage: template migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down [n]      roll back the last n migrations (default 1)
+  status        list migrations and whether they are applied
+  new <name>    create an empty up & down migration pair`
+
+// runMigrate handles the migrate subcommand
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+	if args[0] == "new" {
+		if len(args) < 2 {
+			log.Fatal(migrateUsage)
+		}
+		up, down, err := migrate.Create(migrationsDir, args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("created", up)
+		fmt.Println("created", down)
+		return
+	}
+	// only the db config is needed so it isn't validated as a whole,
+	// its secret references are resolved as by the app, ex: DB_PASS
+	c, err := config.Load(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := context.Background()
+	key, err := secrets.MasterKey(c.Secrets.MasterKey, ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := c.ResolveSecrets(secrets.NewDefaultResolver(key), ctx); err != nil {
+		log.Fatal(err)
+	}
+	db, err := sqlxext.NewClient(c.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.DB.Close()
+	m, err := migrate.NewMigrator(db.DB.DB, migrations.FS)
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		printMigrations("applied", applied)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			n, err = strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatal(migrateUsage)
+			}
+		}
+		reverted, err := m.Down(ctx, n)
+		printMigrations("reverted", reverted)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		s, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, v := range s {
+			status := "pending"
+			if v.Applied {
+				status = "applied"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", v.Version, v.Name, status)
+		}
+		w.Flush()
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
+
+func printMigrations(action string, migrations []migrate.Migration) {
+	for _, v := range migrations {
+		fmt.Printf("%s %d_%s\n", action, v.Version, v.Name)
+	}
+}
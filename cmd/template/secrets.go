@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/secrets"
+)
+
+const secretsUsage = `usage: template secrets <command>
+
+commands:
+  key                  print a new base64 master key for SECRETS_MASTER_KEY
+  encrypt <in> <out>   encrypt the json object of secrets in with SECRETS_MASTER_KEY
+  decrypt <in>         print the secrets of the encrypted file in`
+
+// runSecrets handles the secrets subcommand
+func runSecrets(args []string) {
+	if len(args) == 0 {
+		log.Fatal(secretsUsage)
+	}
+	if args[0] == "key" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+		return
+	}
+	// only the master key is needed so the config isn't validated nor
+	// its secrets resolved, MasterKey resolves the reference of the key
+	c, err := config.Load(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	key, err := secrets.MasterKey(c.Secrets.MasterKey, context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch {
+	case args[0] == "encrypt" && len(args) == 3:
+		b, err := os.ReadFile(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		// the provider expects an object of strings
+		var m map[string]string
+		if err := json.Unmarshal(b, &m); err != nil {
+			log.Fatal(err)
+		}
+		b, err = secrets.Encrypt(key, b)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(args[2], b, 0o600); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("encrypted", len(m), "secrets to", args[2])
+	case args[0] == "decrypt" && len(args) == 2:
+		b, err := os.ReadFile(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		b, err = secrets.Decrypt(key, b)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(b))
+	default:
+		log.Fatal(secretsUsage)
+	}
+}
@@ -0,0 +1,80 @@
+package abac
+
+import (
+	"database/sql"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/errorext"
+)
+
+// Action is what a principal does to a resource
+type Action string
+
+const (
+	Read   Action = "read"
+	Update Action = "update"
+	Delete Action = "delete"
+)
+
+// Rule reports whether the principal may act on the resource
+type Rule[T any] func(p authn.Principal, resource T) bool
+
+// Policy is the attribute based policy of a resource type, an action
+// is allowed when any of its rules allows it, denied if it has none
+type Policy[T any] struct {
+	rules map[Action][]Rule[T]
+}
+
+func NewPolicy[T any]() *Policy[T] {
+	p := new(Policy[T])
+	p.rules = make(map[Action][]Rule[T])
+	return p
+}
+
+// Allow adds the rules of the action
+func (p *Policy[T]) Allow(a Action, rules ...Rule[T]) *Policy[T] {
+	p.rules[a] = append(p.rules[a], rules...)
+	return p
+}
+
+// Can reports whether the principal may act on the resource
+func (p *Policy[T]) Can(principal authn.Principal, a Action, resource T) bool {
+	for _, rule := range p.rules[a] {
+		if rule(principal, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize returns nil if the principal may act on the resource, a
+// resource the principal can't read is reported as sql.ErrNoRows so
+// its existence isn't leaked, errorext.ErrForbidden otherwise
+func (p *Policy[T]) Authorize(principal authn.Principal, a Action, resource T) error {
+	if p.Can(principal, a, resource) {
+		return nil
+	}
+	if a != Read && p.Can(principal, Read, resource) {
+		return errorext.ErrForbidden
+	}
+	return sql.ErrNoRows
+}
+
+// Anyone allows every principal, anonymous too
+func Anyone[T any](authn.Principal, T) bool {
+	return true
+}
+
+// Role allows the principals with one of the roles
+func Role[T any](roles ...string) Rule[T] {
+	return func(p authn.Principal, _ T) bool {
+		return p.HasRole(roles...)
+	}
+}
+
+// Owner allows the principal whose subject is the owner of the resource
+func Owner[T any](ownerOf func(T) string) Rule[T] {
+	return func(p authn.Principal, resource T) bool {
+		return p.Subject != "" && p.Subject == ownerOf(resource)
+	}
+}
@@ -0,0 +1,50 @@
+package abac
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/errorext"
+)
+
+type doc struct {
+	owner     string
+	published bool
+}
+
+func TestPolicyAuthorize(t *testing.T) {
+	owner := Owner(func(d doc) string { return d.owner })
+	admin := Role[doc]("admin")
+	published := func(_ authn.Principal, d doc) bool { return d.published }
+	policy := NewPolicy[doc]().
+		Allow(Read, published, owner, admin).
+		Allow(Update, owner, admin)
+	alice := authn.Principal{Subject: "alice", Roles: []string{"user"}}
+	bob := authn.Principal{Subject: "bob", Roles: []string{"user"}}
+	root := authn.Principal{Subject: "root", Roles: []string{"admin"}}
+	tests := []struct {
+		name      string
+		principal authn.Principal
+		action    Action
+		doc       doc
+		expected  error
+	}{
+		{name: "owner reads draft", principal: alice, action: Read, doc: doc{owner: "alice"}},
+		{name: "other reads draft", principal: bob, action: Read, doc: doc{owner: "alice"}, expected: sql.ErrNoRows},
+		{name: "anonymous reads published", action: Read, doc: doc{owner: "alice", published: true}},
+		{name: "owner updates", principal: alice, action: Update, doc: doc{owner: "alice"}},
+		{name: "other updates published", principal: bob, action: Update, doc: doc{owner: "alice", published: true}, expected: errorext.ErrForbidden},
+		{name: "other updates draft", principal: bob, action: Update, doc: doc{owner: "alice"}, expected: sql.ErrNoRows},
+		{name: "admin updates", principal: root, action: Update, doc: doc{owner: "alice"}},
+		{name: "no owner", principal: authn.Principal{}, action: Update, doc: doc{published: true}, expected: errorext.ErrForbidden},
+		{name: "no rules", principal: root, action: Delete, doc: doc{owner: "root"}, expected: errorext.ErrForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := policy.Authorize(tc.principal, tc.action, tc.doc); err != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token is expired")
+)
+
+// KindAPIKey is the kind of the principals verified from an api key
+const KindAPIKey = "apiKey"
+
+// Principal is the verified identity behind a token
+type Principal struct {
+	// Kind is empty for bearer tokens & KindAPIKey for api keys
+	Kind string
+	// Subject is the id of the user
+	Subject string
+	// TokenID is the jti, empty if the issuer doesn't set it
+	TokenID  string
+	Issuer   string
+	Audience []string
+	Scopes   []string
+	// Roles are set from the local user when there is one,
+	// from the roles or role claim otherwise
+	Roles []string
+	// Tenant is the tenant or tid claim, empty for single tenant tokens
+	Tenant    string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	// Claims are all the claims of the token as decoded from json
+	Claims map[string]any
+}
+
+// String leaves the claims out so printing a principal
+// doesn't leak the token, ex: its email or custom claims
+func (p Principal) String() string {
+	return fmt.Sprintf("{kind:%s subject:%s tokenId:%s issuer:%s roles:%v scopes:%v}", p.Kind, p.Subject, p.TokenID, p.Issuer, p.Roles, p.Scopes)
+}
+
+// LogValue leaves the claims out as String
+func (p Principal) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("kind", p.Kind),
+		slog.String("subject", p.Subject),
+		slog.String("tokenId", p.TokenID),
+		slog.String("issuer", p.Issuer),
+		slog.Any("roles", p.Roles),
+		slog.Any("scopes", p.Scopes),
+	)
+}
+
+// HasRole reports whether the principal has one of the roles
+func (p Principal) HasRole(roles ...string) bool {
+	for _, r := range roles {
+		if contains(p.Roles, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifier verifies a bearer token and returns its principal
+type Verifier interface {
+	Verify(ctx context.Context, token string) (Principal, error)
+}
+
+// Rules are the checks of the registered claims shared by the verifiers
+type Rules struct {
+	// Issuer is the expected iss, not checked when empty
+	Issuer string
+	// Audience has to be one of the aud, not checked when empty
+	Audience string
+	// Leeway is the allowed clock skew for exp & nbf
+	Leeway time.Duration
+}
+
+// Validate checks the exp & the sub which are required, the nbf, the iss & the aud
+func (r Rules) Validate(p Principal, now time.Time) error {
+	if p.ExpiresAt.IsZero() {
+		return fmt.Errorf("%w: exp is missing", ErrInvalidToken)
+	}
+	if p.Subject == "" {
+		return fmt.Errorf("%w: sub is missing", ErrInvalidToken)
+	}
+	return r.validateOptional(p, now)
+}
+
+// validateOptional checks the exp & the nbf when set, the iss & the aud,
+// for the introspection responses where exp & sub are optional (RFC 7662)
+func (r Rules) validateOptional(p Principal, now time.Time) error {
+	if !p.ExpiresAt.IsZero() && !now.Before(p.ExpiresAt.Add(r.Leeway)) {
+		return ErrTokenExpired
+	}
+	if !p.NotBefore.IsZero() && now.Add(r.Leeway).Before(p.NotBefore) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	if r.Issuer != "" && p.Issuer != r.Issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if r.Audience != "" && !contains(p.Audience, r.Audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+// PrincipalFromClaims maps the decoded json claims, the scopes are read
+// from the space separated scope or the scp array
+func PrincipalFromClaims(claims map[string]any) Principal {
+	p := Principal{Claims: claims}
+	p.Subject, _ = claims["sub"].(string)
+	if p.Subject == "" {
+		// the tokens issued before sub was set
+		if payload, ok := claims["payload"].(map[string]any); ok {
+			p.Subject, _ = payload["id"].(string)
+		}
+	}
+	p.TokenID, _ = claims["jti"].(string)
+	p.Issuer, _ = claims["iss"].(string)
+	p.Audience = stringList(claims["aud"])
+	p.ExpiresAt = numericDate(claims["exp"])
+	p.NotBefore = numericDate(claims["nbf"])
+	if s, ok := claims["scope"].(string); ok {
+		p.Scopes = strings.Fields(s)
+	} else {
+		p.Scopes = stringList(claims["scp"])
+	}
+	if p.Roles = stringList(claims["roles"]); p.Roles == nil {
+		p.Roles = stringList(claims["role"])
+	}
+	if p.Tenant, _ = claims["tenant"].(string); p.Tenant == "" {
+		p.Tenant, _ = claims["tid"].(string)
+	}
+	return p
+}
+
+// stringList reads a claim which is a string or an array of them
+func stringList(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []any:
+		d := make([]string, 0, len(t))
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				d = append(d, s)
+			}
+		}
+		return d
+	}
+	return nil
+}
+
+// numericDate reads a claim of seconds since the epoch
+func numericDate(v any) time.Time {
+	var f float64
+	switch t := v.(type) {
+	case float64:
+		f = t
+	case int64:
+		f = float64(t)
+	case json.Number:
+		f, _ = t.Float64()
+	default:
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(f * 1000))
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package authn
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext"
+)
+
+func TestRulesValidate(t *testing.T) {
+	now := time.Now()
+	valid := Principal{Subject: "1", Issuer: "iss", Audience: []string{"api"}, ExpiresAt: now.Add(time.Minute)}
+	rules := Rules{Issuer: "iss", Audience: "api", Leeway: 30 * time.Second}
+	tests := []struct {
+		name     string
+		edit     func(p *Principal)
+		expected error
+	}{
+		{name: "valid", edit: func(p *Principal) {}},
+		{name: "expired", edit: func(p *Principal) { p.ExpiresAt = now.Add(-time.Minute) }, expected: ErrTokenExpired},
+		{name: "expired in leeway", edit: func(p *Principal) { p.ExpiresAt = now.Add(-10 * time.Second) }},
+		{name: "missing exp", edit: func(p *Principal) { p.ExpiresAt = time.Time{} }, expected: ErrInvalidToken},
+		{name: "not before", edit: func(p *Principal) { p.NotBefore = now.Add(time.Minute) }, expected: ErrInvalidToken},
+		{name: "not before in leeway", edit: func(p *Principal) { p.NotBefore = now.Add(10 * time.Second) }},
+		{name: "issuer", edit: func(p *Principal) { p.Issuer = "other" }, expected: ErrInvalidToken},
+		{name: "audience", edit: func(p *Principal) { p.Audience = []string{"other"} }, expected: ErrInvalidToken},
+		{name: "missing sub", edit: func(p *Principal) { p.Subject = "" }, expected: ErrInvalidToken},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := valid
+			tc.edit(&p)
+			err := rules.Validate(p, now)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestLocalVerifier(t *testing.T) {
+	s := jwtext.NewSigner("iss", "")
+	s.UseSecret("secret")
+	token, _, err := s.NewToken(jwtext.Payload{Id: "1"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewLocalVerifier(s, Rules{Issuer: "iss"}).Verify(context.Background(), token)
+	if err != nil || p.Subject != "1" || p.TokenID == "" {
+		t.Errorf("Expected '%v', but got '%v' '%v'", "1", p.Subject, err)
+	}
+	_, err = NewLocalVerifier(s, Rules{Issuer: "other"}).Verify(context.Background(), token)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected '%v', but got '%v'", ErrInvalidToken, err)
+	}
+	// the signer of another app doesn't share the secret
+	other := jwtext.NewSigner("iss", "")
+	other.UseSecret("other")
+	_, err = NewLocalVerifier(other, Rules{Issuer: "iss"}).Verify(context.Background(), token)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected '%v', but got '%v'", ErrInvalidToken, err)
+	}
+}
+
+// newKeySigner returns a signer of the iss with an rsa key signing right away
+func newKeySigner(t *testing.T) *jwtext.Signer {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := jwtext.NewKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.ActiveFrom = time.Now().Add(-time.Minute)
+	ks := jwtext.NewKeySet(time.Hour, 0)
+	ks.Add(k)
+	s := jwtext.NewSigner("iss", "")
+	s.UseKeySet(ks)
+	return s
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	s := newKeySigner(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(s.JWKS())
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v, err := NewJWKSVerifier(ctx, srv.URL, 0, Rules{Issuer: "iss"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newToken := func(s *jwtext.Signer) string {
+		token, _, err := s.NewToken(jwtext.Payload{Id: "1"}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	// the same key with another issuer
+	other := jwtext.NewSigner("other", "")
+	other.UseKeySet(s.KeySet())
+	hmac := jwtext.NewSigner("iss", "")
+	hmac.UseSecret("secret")
+	tests := []struct {
+		name     string
+		token    string
+		expected error
+	}{
+		{name: "valid", token: newToken(s)},
+		{name: "unknown key", token: newToken(newKeySigner(t)), expected: ErrInvalidToken},
+		{name: "issuer", token: newToken(other), expected: ErrInvalidToken},
+		{name: "hs256", token: newToken(hmac), expected: ErrInvalidToken},
+		{name: "garbage", token: "garbage", expected: ErrInvalidToken},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := v.Verify(context.Background(), tc.token)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, err)
+			}
+			if err == nil && p.Subject != "1" {
+				t.Errorf("Expected '%v', but got '%v'", "1", p.Subject)
+			}
+		})
+	}
+}
+
+func TestIntrospectionVerifier(t *testing.T) {
+	exp := time.Now().Add(time.Minute).Unix()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		res := map[string]any{"active": false}
+		switch r.PostFormValue("token") {
+		case "active":
+			res = map[string]any{"active": true, "sub": "1", "scope": "read write", "exp": exp}
+		case "minimal":
+			// exp & sub are optional (RFC 7662)
+			res = map[string]any{"active": true, "scope": "read write"}
+		case "expired":
+			res = map[string]any{"active": true, "sub": "1", "scope": "read write", "exp": time.Now().Add(-time.Minute).Unix()}
+		}
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+	v := NewIntrospectionVerifier(srv.URL, "client", "secret", srv.Client(), Rules{})
+	tests := []struct {
+		name     string
+		token    string
+		subject  string
+		expected error
+	}{
+		{name: "active", token: "active", subject: "1"},
+		{name: "minimal", token: "minimal"},
+		{name: "expired", token: "expired", expected: ErrTokenExpired},
+		{name: "inactive", token: "inactive", expected: ErrInvalidToken},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := v.Verify(context.Background(), tc.token)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, err)
+			}
+			if err == nil && (p.Subject != tc.subject || len(p.Scopes) != 2) {
+				t.Errorf("Expected '%v', but got '%v'", tc.subject, p)
+			}
+		})
+	}
+	// a rotated client secret is used by the next requests
+	v.SetClientSecret("rotated")
+	if _, err := v.Verify(context.Background(), "active"); err == nil {
+		t.Errorf("Expected an error, but got '%v'", err)
+	}
+}
+
+func TestPrincipalRedacted(t *testing.T) {
+	p := Principal{Subject: "1", Claims: map[string]any{"email": "a@b.c"}}
+	var b strings.Builder
+	slog.New(slog.NewTextHandler(&b, nil)).Info("principal", "principal", p)
+	for _, s := range []string{fmt.Sprint(p), fmt.Sprintf("%v", p), b.String()} {
+		if strings.Contains(s, "a@b.c") || !strings.Contains(s, "1") {
+			t.Errorf("Expected the subject without the claims, but got '%v'", s)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package authn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext/jwk"
+)
+
+// asymmetricAlgs are the algs accepted from a remote jwks
+var asymmetricAlgs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// verifyJWT checks the signature with the keyfunc and the claims with
+// the rules, the claims are validated by the rules only so every
+// verifier applies them the same way
+func verifyJWT(token string, kf jwt.Keyfunc, algs []string, rules Rules, now time.Time) (Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, kf, jwt.WithValidMethods(algs), jwt.WithoutClaimsValidation())
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	p := PrincipalFromClaims(claims)
+	if err := rules.Validate(p, now); err != nil {
+		return Principal{}, err
+	}
+	return p, nil
+}
+
+// LocalVerifier verifies the tokens issued by this app
+// with the keys of its signer, HS256 or the key set in use
+type LocalVerifier struct {
+	signer *jwtext.Signer
+	rules  Rules
+	now    func() time.Time
+}
+
+func NewLocalVerifier(signer *jwtext.Signer, rules Rules) *LocalVerifier {
+	v := new(LocalVerifier)
+	v.signer = signer
+	v.rules = rules
+	v.now = time.Now
+	return v
+}
+
+func (v *LocalVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	kf, algs := v.signer.VerificationKeys()
+	return verifyJWT(token, kf, algs, v.rules, v.now())
+}
+
+// JWKSVerifier verifies the tokens of another issuer with the keys of
+// its jwks, they are cached & refreshed in the background by keyfunc
+// and an unknown kid triggers a rate limited refresh
+type JWKSVerifier struct {
+	jwks  *keyfunc.JWKS
+	rules Rules
+	now   func() time.Time
+}
+
+// NewJWKSVerifier fetches the jwks, the background refresh
+// runs every refreshInterval until ctx is done
+func NewJWKSVerifier(ctx context.Context, url string, refreshInterval time.Duration, rules Rules) (*JWKSVerifier, error) {
+	jwks, err := jwk.CreateJWKS(ctx, url, refreshInterval, 10*time.Second, nil)
+	if err != nil {
+		return nil, err
+	}
+	v := new(JWKSVerifier)
+	v.jwks = jwks
+	v.rules = rules
+	v.now = time.Now
+	return v, nil
+}
+
+func (v *JWKSVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	return verifyJWT(token, v.jwks.Keyfunc, asymmetricAlgs, v.rules, v.now())
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Config is the configuration of the app, every field is set by, in
+// increasing precedence, its default, the profile file under its json
+// path, ex: {"db": {"host": "..."}}, its env & its flag, ex: -db.host,
+// the secret fields are redacted when logged & may be secret references,
+// ex: file:///run/secrets/db_pass, see ResolveSecrets
+type Config struct {
+	Profile string  `json:"-"`
+	Server  Server  `json:"server"`
+	DB      DB      `json:"db"`
+	S3      S3      `json:"s3"`
+	Auth    Auth    `json:"auth"`
+	Log     Log     `json:"log"`
+	Secrets Secrets `json:"secrets"`
+	Health  Health  `json:"health"`
+	Purge   Purge   `json:"purge"`
+	Tracing Tracing `json:"tracing"`
+	RBAC    RBAC    `json:"rbac"`
+	// Services are the urls of the other services
+	Services Services `json:"services"`
+	// refs are the secret references by path, ex: db.pass
+	refs map[string]string
+}
+
+type Server struct {
+	Host            string        `json:"host" env:"APP_HOST"`
+	Port            int           `json:"port" env:"APP_PORT" validate:"min=1,max=65535"`
+	ShutdownTimeout time.Duration `json:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" validate:"gt=0"`
+	// DrainDelay is taken out of ShutdownTimeout so it has to be shorter
+	DrainDelay time.Duration `json:"drainDelay" env:"SHUTDOWN_DRAIN_DELAY" validate:"gte=0,ltfield=ShutdownTimeout"`
+}
+
+type DB struct {
+	Host        string `json:"host" env:"DB_HOST" validate:"required"`
+	Port        int    `json:"port" env:"DB_PORT" validate:"min=1,max=65535"`
+	User        string `json:"user" env:"DB_USER" validate:"required"`
+	Pass        string `json:"pass" env:"DB_PASS" secret:"true"`
+	Name        string `json:"name" env:"DB_NAME" validate:"required"`
+	SSLMode     string `json:"sslMode" env:"DB_SSL_MODE" validate:"oneof=disable allow prefer require verify-ca verify-full"`
+	RootCert    string `json:"rootCert" env:"DB_ROOT_CERT"`
+	Cert        string `json:"cert" env:"DB_CERT"`
+	Key         string `json:"key" env:"DB_KEY"`
+	AutoMigrate bool   `json:"autoMigrate" env:"DB_AUTO_MIGRATE"`
+}
+
+// DSN is the keyword/value connection string of the db
+func (d DB) DSN() string {
+	dsn := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quote(d.Host), d.Port, quote(d.User), quote(d.Pass), quote(d.Name), quote(d.SSLMode),
+	)
+	if d.RootCert != "" {
+		dsn += fmt.Sprintf(" sslrootcert=%s sslcert=%s sslkey=%s", quote(d.RootCert), quote(d.Cert), quote(d.Key))
+	}
+	return dsn
+}
+
+// quote quotes the value so an empty one or one with spaces is kept
+func quote(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+type S3 struct {
+	Region   string `json:"region" env:"S3_REGION" validate:"required"`
+	Endpoint string `json:"endpoint" env:"S3_ENDPOINT" validate:"omitempty,url"`
+	Bucket   string `json:"bucket" env:"BUCKET_NAME" validate:"required"`
+	// the default credential chain is used when not set
+	AccessKey string `json:"accessKey" env:"S3_ACCESS_KEY" secret:"true" validate:"required_with=SecretKey"`
+	SecretKey string `json:"secretKey" env:"S3_SECRET_KEY" secret:"true" validate:"required_with=AccessKey"`
+}
+
+type Auth struct {
+	// JWTSecret signs the tokens with HS256 unless Keys is set
+	JWTSecret           string        `json:"jwtSecret" env:"JWT_SECRET" secret:"true" validate:"required_without=Keys"`
+	Issuer              string        `json:"issuer" env:"JWT_ISSUER" validate:"required"`
+	Audience            string        `json:"audience" env:"JWT_AUDIENCE"`
+	AccessTTL           time.Duration `json:"accessTTL" env:"JWT_ACCESS_TTL" validate:"gt=0"`
+	RefreshTTL          time.Duration `json:"refreshTTL" env:"JWT_REFRESH_TTL" validate:"gt=0"`
+	Keys                string        `json:"keys" env:"JWT_KEYS"`
+	KeyGrace            time.Duration `json:"keyGrace" env:"JWT_KEY_GRACE" validate:"gt=0"`
+	KeyActivationDelay  time.Duration `json:"keyActivationDelay" env:"JWT_KEY_ACTIVATION_DELAY" validate:"gt=0"`
+	KeyReloadInterval   time.Duration `json:"keyReloadInterval" env:"JWT_KEY_RELOAD_INTERVAL" validate:"gt=0"`
+	Verifier            string        `json:"verifier" env:"AUTH_VERIFIER" validate:"oneof=local jwks introspection"`
+	ExpectedIssuer      string        `json:"expectedIssuer" env:"JWT_EXPECTED_ISSUER"`
+	Leeway              time.Duration `json:"leeway" env:"JWT_LEEWAY" validate:"gte=0"`
+	JWKSURL             string        `json:"jwksURL" env:"JWT_JWKS_URL" validate:"required_if=Verifier jwks,omitempty,url"`
+	JWKSRefreshInterval time.Duration `json:"jwksRefreshInterval" env:"JWT_JWKS_REFRESH_INTERVAL" validate:"gt=0"`
+	IntrospectionURL    string        `json:"introspectionURL" env:"INTROSPECTION_URL" validate:"required_if=Verifier introspection,omitempty,url"`
+	IntrospectionID     string        `json:"introspectionClientId" env:"INTROSPECTION_CLIENT_ID"`
+	IntrospectionSecret string        `json:"introspectionClientSecret" env:"INTROSPECTION_CLIENT_SECRET" secret:"true"`
+	// CursorSecret signs the pagination cursors, random when empty
+	CursorSecret string `json:"cursorSecret" env:"CURSOR_SECRET" secret:"true"`
+}
+
+type Log struct {
+	Format string `json:"format" env:"LOG_FORMAT" validate:"oneof=json text"`
+	Level  string `json:"level" env:"LOG_LEVEL" validate:"oneof=debug info warn error"`
+}
+
+type Secrets struct {
+	// MasterKey decrypts the encfile references, the base64 of a 32 bytes
+	// key, it may be an env or a file reference but not an encfile one
+	MasterKey string `json:"masterKey" env:"SECRETS_MASTER_KEY" secret:"true" validate:"omitempty,base64"`
+	// RefreshInterval is how often the references are resolved
+	// again so the rotated secrets are picked up
+	RefreshInterval time.Duration `json:"refreshInterval" env:"SECRETS_REFRESH_INTERVAL" validate:"gt=0"`
+}
+
+type Health struct {
+	CheckTimeout time.Duration `json:"checkTimeout" env:"HEALTH_CHECK_TIMEOUT" validate:"gt=0"`
+	// CacheTTL is how long the results of the checks are reused
+	CacheTTL time.Duration `json:"cacheTTL" env:"HEALTH_CACHE_TTL" validate:"gt=0"`
+}
+
+type Purge struct {
+	// Retention is how long the soft deleted rows are kept
+	Retention time.Duration `json:"retention" env:"SOFT_DELETE_RETENTION" validate:"gt=0"`
+	Interval  time.Duration `json:"interval" env:"PURGE_INTERVAL" validate:"gt=0"`
+}
+
+type Tracing struct {
+	Exporter string `json:"exporter" env:"OTEL_TRACES_EXPORTER" validate:"oneof=none otlp stdout"`
+}
+
+type RBAC struct {
+	// Config seeds the rbac tables when empty, config/rbac.json
+	// of the working dir when not set
+	Config string `json:"config" env:"RBAC_CONFIG"`
+}
+
+type Services struct {
+	// UserBaseURL is checked by the health checks when set
+	UserBaseURL string `json:"userBaseURL" env:"USER_SERVICE_BASE_URL" validate:"omitempty,url"`
+}
+
+// Default returns the config with the default values
+func Default() *Config {
+	c := new(Config)
+	c.Server = Server{Port: 8080, ShutdownTimeout: 30 * time.Second, DrainDelay: 5 * time.Second}
+	c.DB = DB{Host: "localhost", Port: 5432, SSLMode: "disable"}
+	c.Auth = Auth{
+		Issuer:              "stdlib-go-template",
+		AccessTTL:           15 * time.Minute,
+		RefreshTTL:          30 * 24 * time.Hour,
+		KeyGrace:            24 * time.Hour,
+		KeyActivationDelay:  10 * time.Minute,
+		KeyReloadInterval:   time.Minute,
+		Verifier:            "local",
+		Leeway:              30 * time.Second,
+		JWKSRefreshInterval: time.Hour,
+	}
+	c.Log = Log{Format: "json", Level: "info"}
+	c.Secrets = Secrets{RefreshInterval: time.Minute}
+	c.Health = Health{CheckTimeout: 2 * time.Second, CacheTTL: 5 * time.Second}
+	c.Purge = Purge{Retention: 30 * 24 * time.Hour, Interval: time.Hour}
+	c.Tracing = Tracing{Exporter: "none"}
+	return c
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/secrets"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/validatorext"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.json")
+	err := os.WriteFile(path, []byte(`{"server": {"port": 9000}, "db": {"host": "file", "name": "file"}, "auth": {"accessTTL": "5m"}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DB_HOST", "env")
+	t.Setenv("DB_NAME", "")
+	t.Setenv("PURGE_INTERVAL", "2h")
+	c, err := Load([]string{"-config", path, "-db.host", "flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		value    any
+		expected any
+	}{
+		{name: "default", value: c.DB.Port, expected: 5432},
+		{name: "file", value: c.Server.Port, expected: 9000},
+		{name: "file duration", value: c.Auth.AccessTTL, expected: 5 * time.Minute},
+		{name: "empty env", value: c.DB.Name, expected: "file"},
+		{name: "flag", value: c.DB.Host, expected: "flag"},
+		{name: "env duration", value: c.Purge.Interval, expected: 2 * time.Hour},
+		{name: "default section", value: c.Tracing.Exporter, expected: "none"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.value != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, tc.value)
+			}
+		})
+	}
+	if _, err := Load([]string{"-config", path, "-server.port", "x"}); err == nil {
+		t.Errorf("Expected '%v', but got '%v'", "error", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	validate := validator.New()
+	validatorext.RegisterTagNameFunc(validate)
+	c := Default()
+	c.DB.User, c.DB.Name, c.S3.Region, c.S3.Bucket = "u", "n", "r", "b"
+	c.Auth.Verifier = "jwks"
+	c.Tracing.Exporter = "zipkin"
+	c.Services.UserBaseURL = "users"
+	c.Server.DrainDelay = c.Server.ShutdownTimeout
+	err := c.Validate(validate)
+	expected := "invalid config: auth.jwksURL required_if, auth.jwtSecret required_without, server.drainDelay ltfield, services.userBaseURL url, tracing.exporter oneof"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected '%v', but got '%v'", expected, err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	c := Default()
+	c.DB.Pass = "hunter2"
+	var b strings.Builder
+	slog.New(slog.NewTextHandler(&b, nil)).Info("loaded config", "config", c)
+	for _, s := range []string{b.String(), c.String()} {
+		if strings.Contains(s, "hunter2") || !strings.Contains(s, "pass="+redacted) {
+			t.Errorf("Expected '%v', but got '%v'", redacted, s)
+		}
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("TEST_DB_PASS", "secret")
+	r := secrets.NewResolver()
+	r.Register(secrets.SchemeEnv, secrets.Env{})
+	c := Default()
+	c.DB.Pass = "env://TEST_DB_PASS"
+	c.DB.Name = "env://TEST_DB_PASS"
+	if err := c.ResolveSecrets(r, context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// only the secret fields are resolved
+	ref, ok := c.SecretRef("db.pass")
+	if c.DB.Pass != "secret" || c.DB.Name != "env://TEST_DB_PASS" || !ok || ref != "env://TEST_DB_PASS" {
+		t.Errorf("Expected '%v', but got '%v' '%v'", "secret", c.DB.Pass, c.DB.Name)
+	}
+}
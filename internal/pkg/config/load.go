@@ -0,0 +1,258 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/secrets"
+	"github.com/tanveerprottoy/stdlib-go-template/pkg/file"
+)
+
+const redacted = "[REDACTED]"
+
+// field is a settable leaf of the config, ex: db.host
+type field struct {
+	path   string
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+// fields lists the leaves of the sections of c in declaration order
+func fields(c reflect.Value) []field {
+	var fs []field
+	for i := 0; i < c.NumField(); i++ {
+		section := c.Field(i)
+		name := jsonName(c.Type().Field(i))
+		if name == "" || section.Kind() != reflect.Struct {
+			continue
+		}
+		for j := 0; j < section.NumField(); j++ {
+			sf := section.Type().Field(j)
+			fs = append(fs, field{
+				path:   name + "." + jsonName(sf),
+				env:    sf.Tag.Get("env"),
+				secret: sf.Tag.Get("secret") == "true",
+				value:  section.Field(j),
+			})
+		}
+	}
+	return fs
+}
+
+func jsonName(f reflect.StructField) string {
+	n := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+	if n == "-" {
+		return ""
+	}
+	return n
+}
+
+// Load builds the config from the defaults, the file, the env & the flags
+// of args, the file is -config or CONFIG_FILE, else config/<profile>.json
+// of the -profile or APP_PROFILE, none when both are empty
+func Load(args []string) (*Config, error) {
+	c := Default()
+	fs := fields(reflect.ValueOf(c).Elem())
+	set := flag.NewFlagSet("template", flag.ContinueOnError)
+	profile := set.String("profile", os.Getenv("APP_PROFILE"), "the profile whose config/<profile>.json is loaded")
+	path := set.String("config", os.Getenv("CONFIG_FILE"), "the config file, overrides the profile")
+	byPath := make(map[string]field, len(fs))
+	for _, f := range fs {
+		byPath[f.path] = f
+		set.String(f.path, "", "overrides env "+f.env)
+	}
+	if err := set.Parse(args); err != nil {
+		return nil, err
+	}
+	c.Profile = *profile
+	if *path == "" && *profile != "" {
+		pwd, _ := file.GetPWD()
+		*path = filepath.Join(pwd, "config", *profile+".json")
+	}
+	if *path != "" {
+		values, err := readFile(*path)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range values {
+			f, ok := byPath[k]
+			if !ok {
+				return nil, fmt.Errorf("unknown config key %q in %s", k, *path)
+			}
+			if err := setValue(f.value, v); err != nil {
+				return nil, fmt.Errorf("config key %s: %w", k, err)
+			}
+		}
+	}
+	// an empty env is unset, as in the env files
+	for _, f := range fs {
+		if v := os.Getenv(f.env); f.env != "" && v != "" {
+			if err := setValue(f.value, v); err != nil {
+				return nil, fmt.Errorf("env %s: %w", f.env, err)
+			}
+		}
+	}
+	var err error
+	set.Visit(func(fl *flag.Flag) {
+		if f, ok := byPath[fl.Name]; ok && err == nil {
+			if e := setValue(f.value, fl.Value.String()); e != nil {
+				err = fmt.Errorf("flag -%s: %w", fl.Name, e)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// readFile reads the json file as the flat values by path
+func readFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	values := make(map[string]string)
+	flatten("", m, values)
+	return values, nil
+}
+
+func flatten(prefix string, m map[string]any, values map[string]string) {
+	for k, v := range m {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(k, v, values)
+		case float64:
+			values[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			values[k] = fmt.Sprint(v)
+		}
+	}
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// ResolveSecrets replaces the secret references of the secret
+// fields with their secrets, the references are kept for SecretRef
+func (c *Config) ResolveSecrets(r *secrets.Resolver, ctx context.Context) error {
+	c.refs = make(map[string]string)
+	for _, f := range fields(reflect.ValueOf(c).Elem()) {
+		if !f.secret || !r.IsRef(f.value.String()) {
+			continue
+		}
+		ref := f.value.String()
+		v, err := r.Resolve(ref, ctx)
+		if err != nil {
+			return err
+		}
+		c.refs[f.path] = ref
+		f.value.SetString(v)
+	}
+	return nil
+}
+
+// SecretRef returns the reference the secret of the path was resolved
+// from, ex: db.pass, to be notified of its rotations by the resolver
+func (c *Config) SecretRef(path string) (string, bool) {
+	ref, ok := c.refs[path]
+	return ref, ok
+}
+
+// Validate checks c by the validate tags, validate must
+// name the fields by their json tag, see validatorext
+func (c *Config) Validate(validate *validator.Validate) error {
+	err := validate.Struct(c)
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		// Config.db.host -> db.host
+		_, ns, _ := strings.Cut(e.Namespace(), ".")
+		msgs = append(msgs, ns+" "+e.Tag())
+	}
+	sort.Strings(msgs)
+	return fmt.Errorf("invalid config: %s", strings.Join(msgs, ", "))
+}
+
+// LogValue groups the values by section with the secrets redacted
+func (c Config) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("profile", c.Profile)}
+	var section string
+	var group []any
+	for _, f := range fields(reflect.ValueOf(c)) {
+		s, name, _ := strings.Cut(f.path, ".")
+		if s != section {
+			if group != nil {
+				attrs = append(attrs, slog.Group(section, group...))
+			}
+			section, group = s, nil
+		}
+		var v any = f.value.Interface()
+		if d, ok := v.(time.Duration); ok {
+			v = d.String()
+		}
+		if f.secret && !f.value.IsZero() {
+			v = redacted
+		}
+		group = append(group, slog.Any(name, v))
+	}
+	if group != nil {
+		attrs = append(attrs, slog.Group(section, group...))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// String is redacted as well so printing c doesn't leak the secrets
+func (c Config) String() string {
+	return c.LogValue().String()
+}
@@ -8,6 +8,14 @@ const V1 = "/v1"
 const UsersPattern = "/users"
 const ContentsPattern = "/contents"
 const FilesPattern = "/files"
+const AuthPattern = "/auth"
+const JWKSPattern = "/.well-known/jwks.json"
+const RBACPattern = "/admin/rbac"
+const APIKeysPattern = "/admin/api-keys"
+const MetricsPattern = "/metrics"
+const HealthzPattern = "/healthz"
+const ReadyzPattern = "/readyz"
+const HealthPattern = "/health"
 
 // db
 const RowsAffected = "rowsAffected"
@@ -16,6 +24,7 @@ const RowsAffected = "rowsAffected"
 const InternalServerError = "internal server error"
 const BadRequest = "bad request"
 const Unauthorized = "unauthorized"
+const Forbidden = "forbidden"
 const OperationNotSuccess = "operation was not successful"
 const InvalidRequestBody = "invalid request body"
 
@@ -26,6 +35,14 @@ const Errors = "errors"
 const KeyId = "id"
 const KeyPage = "page"
 const KeyLimit = "limit"
+const KeyCursor = "cursor"
+const KeyTotal = "total"
+const KeyHard = "hard"
+
+// headers
+const HeaderAPIKey = "X-API-Key"
+const HeaderRequestID = "X-Request-ID"
+const HeaderTraceID = "X-Trace-ID"
 
 // context keys
 const KeyAuthData types.KeyContext = "AuthData"
@@ -36,6 +53,14 @@ const KeyNowMilli types.KeyContext = "nowMilli"
 // remote userservice auth endpoint
 const UserServiceAuthEndpoint = "/api/v2/auth/get-user"
 
+// roles
+const RoleAdmin = "admin"
+const RoleUser = "user"
+
+// user statuses
+const UserStatusActive = "active"
+const UserStatusSuspended = "suspended"
+
 // auth data keys
 const AuthUser = "authUser"
 const AuthRole = "authRole"
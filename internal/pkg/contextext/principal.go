@@ -0,0 +1,24 @@
+package contextext
+
+import (
+	"context"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+)
+
+// principalKey is unexported so only WithPrincipal can set the value
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal, the
+// middlewares have to pass the request of the new ctx down the chain
+// ex: next.ServeHTTP(w, r.WithContext(contextext.WithPrincipal(r.Context(), p)))
+func WithPrincipal(ctx context.Context, p authn.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal of ctx, false if
+// the request wasn't authenticated
+func PrincipalFrom(ctx context.Context) (authn.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(authn.Principal)
+	return p, ok
+}
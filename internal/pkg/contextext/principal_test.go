@@ -0,0 +1,20 @@
+package contextext
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+)
+
+func TestPrincipal(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := PrincipalFrom(ctx); ok {
+		t.Errorf("Expected '%v', but got '%v'", false, ok)
+	}
+	ctx = WithPrincipal(ctx, authn.Principal{Subject: "1", Roles: []string{"admin"}, Tenant: "t"})
+	p, ok := PrincipalFrom(ctx)
+	if !ok || p.Subject != "1" || !p.HasRole("admin") || p.Tenant != "t" {
+		t.Errorf("Expected '%v', but got '%v'", "1", p)
+	}
+}
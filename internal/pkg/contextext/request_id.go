@@ -0,0 +1,16 @@
+package contextext
+
+import "context"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request id of ctx, false if there is none
+func RequestIDFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
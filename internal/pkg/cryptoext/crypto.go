@@ -1,18 +1,26 @@
 package cryptoext
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-// GenerateHashFromPassword generates password hash
-func GenerateHashFromPassword(p string) string {
+// MaxPasswordBytes is the longest password bcrypt hashes, in bytes
+const MaxPasswordBytes = 72
+
+// GenerateHashFromPassword generates password hash, it fails
+// with bcrypt.ErrPasswordTooLong past MaxPasswordBytes
+func GenerateHashFromPassword(p string) (string, error) {
 	h, err := bcrypt.GenerateFromPassword([]byte(p), bcrypt.DefaultCost)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return string(h)
+	return string(h), nil
 }
 
 // CompareHashAndPassword compares pass with hash
@@ -21,6 +29,22 @@ func CompareHashAndPassword(h string, p string) bool {
 	return err == nil
 }
 
+// RandomToken generates an url safe token of n random bytes
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken hashes a high entropy token for storage, unlike
+// passwords these don't need a slow hash
+func HashToken(t string) string {
+	h := sha256.Sum256([]byte(t))
+	return hex.EncodeToString(h[:])
+}
+
 func AppendCertsFromPEM(pemCerts []byte) (*x509.CertPool, bool) {
 	cp := x509.NewCertPool()
 	b := cp.AppendCertsFromPEM(pemCerts)
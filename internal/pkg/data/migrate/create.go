@@ -0,0 +1,40 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// Create writes an empty up & down file pair in dir
+// the version is the next one after the highest existing version
+func Create(dir, name string) (string, string, error) {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "_")
+	name = strings.Trim(name, "_")
+	if name == "" {
+		return "", "", errors.New("migrate: migration name is required")
+	}
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	var v int64 = 1
+	if l := len(migrations); l > 0 {
+		v = migrations[l-1].Version + 1
+	}
+	base := filepath.Join(dir, fmt.Sprintf("%06d_%s", v, name))
+	up := base + ".up.sql"
+	down := base + ".down.sql"
+	if err := os.WriteFile(up, []byte("-- "+name+" up\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- "+name+" down\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// file name format: {version}_{name}.{up|down}.sql
+var fileNameRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration holds a pair of up & down sql scripts
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Load reads the migration files from the root of fsys,
+// pairs the up & down files and sorts them by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		matches := fileNameRegex.FindStringSubmatch(e.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("migrate: invalid migration file name %q", e.Name())
+		}
+		v, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: invalid version in %q: %w", e.Name(), err)
+		}
+		b, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := m[v]
+		if !ok {
+			mig = &Migration{Version: v, Name: matches[2]}
+			m[v] = mig
+		} else if mig.Name != matches[2] {
+			return nil, fmt.Errorf("migrate: version %d is used by %q and %q", v, mig.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			mig.Up = string(b)
+		} else {
+			mig.Down = string(b)
+		}
+	}
+	migrations := make([]Migration, 0, len(m))
+	for _, mig := range m {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migrate: missing up file for version %d", mig.Version)
+		}
+		if mig.Down == "" {
+			return nil, fmt.Errorf("migrate: missing down file for version %d", mig.Version)
+		}
+		mig.Checksum = checksum(mig.Up)
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// checksum returns the hex encoded sha256 of the up script
+func checksum(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
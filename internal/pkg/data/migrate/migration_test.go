@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_create_contents.up.sql":   {Data: []byte("CREATE TABLE contents ();")},
+		"000002_create_contents.down.sql": {Data: []byte("DROP TABLE contents;")},
+		"000001_create_users.up.sql":      {Data: []byte("CREATE TABLE users ();")},
+		"000001_create_users.down.sql":    {Data: []byte("DROP TABLE users;")},
+		"migrations.go":                   {Data: []byte("package migrations")},
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Expected no error, but got '%v'", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("Expected '%d' migrations, but got '%d'", 2, len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "create_users" {
+		t.Errorf("Expected '%s', but got '%d_%s'", "1_create_users", migrations[0].Version, migrations[0].Name)
+	}
+	if migrations[1].Down != "DROP TABLE contents;" {
+		t.Errorf("Expected '%s', but got '%s'", "DROP TABLE contents;", migrations[1].Down)
+	}
+	if migrations[0].Checksum == "" || migrations[0].Checksum == migrations[1].Checksum {
+		t.Errorf("Expected distinct checksums, but got '%s' and '%s'", migrations[0].Checksum, migrations[1].Checksum)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"missing down": {
+			"000001_create_users.up.sql": {Data: []byte("CREATE TABLE users ();")},
+		},
+		"invalid name": {
+			"create_users.up.sql": {Data: []byte("CREATE TABLE users ();")},
+		},
+		"duplicate version": {
+			"000001_create_users.up.sql":      {Data: []byte("CREATE TABLE users ();")},
+			"000001_create_users.down.sql":    {Data: []byte("DROP TABLE users;")},
+			"000001_create_contents.up.sql":   {Data: []byte("CREATE TABLE contents ();")},
+			"000001_create_contents.down.sql": {Data: []byte("DROP TABLE contents;")},
+		},
+	}
+	for name, fsys := range cases {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: Expected an error, but got nil", name)
+		}
+	}
+}
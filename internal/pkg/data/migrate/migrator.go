@@ -0,0 +1,232 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
+)
+
+const (
+	// bookkeeping table
+	tableName = "schema_migrations"
+	// key for pg_advisory_lock, keeps concurrent
+	// app instances from migrating at the same time
+	lockKey int64 = 4385137602
+)
+
+var ErrChecksumMismatch = errors.New("migrate: checksum mismatch")
+
+// Status represents the state of a migration in the db
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt int64
+}
+
+type appliedRow struct {
+	checksum  string
+	appliedAt int64
+}
+
+// Migrator applies & rolls back migrations
+// on a postgres db
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	m := new(Migrator)
+	m.db = db
+	m.migrations = migrations
+	return m, nil
+}
+
+// Migrations returns the loaded migrations sorted by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// withLock runs fn on a single connection which holds the advisory lock
+// the lock is session scoped so every statement must use the same conn
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return err
+	}
+	defer func() {
+		// use a fresh context so the lock is released
+		// even if ctx is already cancelled
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+	}()
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(
+		ctx,
+		"CREATE TABLE IF NOT EXISTS "+tableName+" (version BIGINT PRIMARY KEY, name VARCHAR NOT NULL, checksum VARCHAR NOT NULL, applied_at BIGINT NOT NULL)",
+	)
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedRow, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM "+tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	d := make(map[int64]appliedRow)
+	for rows.Next() {
+		var v int64
+		var r appliedRow
+		if err := rows.Scan(&v, &r.checksum, &r.appliedAt); err != nil {
+			return nil, err
+		}
+		d[v] = r
+	}
+	return d, rows.Err()
+}
+
+// verify checks that every applied migration still exists
+// and that its up script has not been edited afterwards
+func (m *Migrator) verify(applied map[int64]appliedRow) error {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+	for v, r := range applied {
+		mig, ok := known[v]
+		if !ok {
+			return fmt.Errorf("migrate: applied migration %d has no matching file", v)
+		}
+		if mig.Checksum != r.checksum {
+			return fmt.Errorf("%w: version %d (%s)", ErrChecksumMismatch, v, mig.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script string, bookkeeping string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up applies all pending migrations in version order
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			err := m.run(
+				ctx,
+				conn,
+				mig.Up,
+				"INSERT INTO "+tableName+" (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)",
+				mig.Version, mig.Name, mig.Checksum, timeext.NowUnixMilli(),
+			)
+			if err != nil {
+				return fmt.Errorf("migrate: up %d (%s): %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last n applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < n; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			err := m.run(ctx, conn, mig.Down, "DELETE FROM "+tableName+" WHERE version = $1", mig.Version)
+			if err != nil {
+				return fmt.Errorf("migrate: down %d (%s): %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status reports every known migration and whether it is applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var d []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			r, ok := applied[mig.Version]
+			d = append(d, Status{Migration: mig, Applied: ok, AppliedAt: r.appliedAt})
+		}
+		return nil
+	})
+	return d, err
+}
+
+// Pending returns the migrations which are not applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	s, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var d []Migration
+	for _, v := range s {
+		if !v.Applied {
+			d = append(d, v.Migration)
+		}
+	}
+	return d, nil
+}
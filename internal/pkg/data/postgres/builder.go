@@ -0,0 +1,518 @@
+package postgres
+
+// Builder renders a statement to its sql & args
+// the placeholders are numbered automatically,
+// subqueries & ctes share the numbering of the outer statement
+type Builder interface {
+	Build() (string, []any)
+	render(w *writer)
+}
+
+func build(b Builder) (string, []any) {
+	w := new(writer)
+	b.render(w)
+	return w.sb.String(), w.args
+}
+
+type cte struct {
+	name string
+	b    Builder
+}
+
+// ctes are shared by all the statements
+type ctes []cte
+
+func (c ctes) render(w *writer) {
+	if len(c) == 0 {
+		return
+	}
+	w.write("WITH ")
+	for i, v := range c {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(QuoteIdent(v.name), " AS (")
+		v.b.render(w)
+		w.write(")")
+	}
+	w.write(" ")
+}
+
+func renderWhere(w *writer, where []Expr) {
+	if len(where) == 0 {
+		return
+	}
+	w.write(" WHERE ")
+	w.join(where, " AND ")
+}
+
+func renderReturning(w *writer, cols []string) {
+	if len(cols) == 0 {
+		return
+	}
+	w.write(" RETURNING ", quoteIdents(cols))
+}
+
+func renderSuffix(w *writer, suffix string) {
+	if suffix != "" {
+		w.write(" ", suffix)
+	}
+}
+
+type join struct {
+	kind  string
+	table string
+	on    Expr
+}
+
+// SelectBuilder builds a SELECT statement
+type SelectBuilder struct {
+	ctes     ctes
+	distinct bool
+	columns  []Expr
+	table    string
+	sub      Builder
+	alias    string
+	joins    []join
+	where    []Expr
+	groupBy  []string
+	having   []Expr
+	orderBy  []Order
+	limit    *int
+	offset   *int
+	lock     string
+	suffix   string
+}
+
+// Select starts a SELECT of the columns, no columns selects *
+func Select(cols ...string) *SelectBuilder {
+	b := new(SelectBuilder)
+	for _, c := range cols {
+		b.columns = append(b.columns, identExpr(c))
+	}
+	return b
+}
+
+// With adds a common table expression
+func (b *SelectBuilder) With(name string, q Builder) *SelectBuilder {
+	b.ctes = append(b.ctes, cte{name, q})
+	return b
+}
+
+func (b *SelectBuilder) Distinct() *SelectBuilder {
+	b.distinct = true
+	return b
+}
+
+// Column adds an expression to the projection
+// ex: Column(As(Raw("COUNT(*)"), "total"))
+func (b *SelectBuilder) Column(e Expr) *SelectBuilder {
+	b.columns = append(b.columns, e)
+	return b
+}
+
+// From sets the table, an alias can follow the name, ex: "users u"
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.table = table
+	return b
+}
+
+// FromSub selects from a subquery
+func (b *SelectBuilder) FromSub(q Builder, alias string) *SelectBuilder {
+	b.sub = q
+	b.alias = alias
+	return b
+}
+
+func (b *SelectBuilder) Join(table string, on Expr) *SelectBuilder {
+	b.joins = append(b.joins, join{"JOIN", table, on})
+	return b
+}
+
+func (b *SelectBuilder) LeftJoin(table string, on Expr) *SelectBuilder {
+	b.joins = append(b.joins, join{"LEFT JOIN", table, on})
+	return b
+}
+
+// Where adds predicates, all of them are joined with AND
+func (b *SelectBuilder) Where(exprs ...Expr) *SelectBuilder {
+	b.where = append(b.where, exprs...)
+	return b
+}
+
+func (b *SelectBuilder) GroupBy(cols ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, cols...)
+	return b
+}
+
+func (b *SelectBuilder) Having(exprs ...Expr) *SelectBuilder {
+	b.having = append(b.having, exprs...)
+	return b
+}
+
+func (b *SelectBuilder) OrderBy(orders ...Order) *SelectBuilder {
+	b.orderBy = append(b.orderBy, orders...)
+	return b
+}
+
+func (b *SelectBuilder) Limit(n int) *SelectBuilder {
+	b.limit = &n
+	return b
+}
+
+func (b *SelectBuilder) Offset(n int) *SelectBuilder {
+	b.offset = &n
+	return b
+}
+
+// ForUpdate locks the selected rows
+func (b *SelectBuilder) ForUpdate() *SelectBuilder {
+	b.lock = "FOR UPDATE"
+	return b
+}
+
+// Suffix appends a raw clause to the statement
+func (b *SelectBuilder) Suffix(s string) *SelectBuilder {
+	b.suffix = s
+	return b
+}
+
+func (b *SelectBuilder) Build() (string, []any) {
+	return build(b)
+}
+
+func (b *SelectBuilder) render(w *writer) {
+	b.ctes.render(w)
+	w.write("SELECT ")
+	if b.distinct {
+		w.write("DISTINCT ")
+	}
+	if len(b.columns) == 0 {
+		w.write("*")
+	} else {
+		w.join(b.columns, ", ")
+	}
+	if b.sub != nil {
+		w.write(" FROM (")
+		b.sub.render(w)
+		w.write(") ", QuoteIdent(b.alias))
+	} else if b.table != "" {
+		w.write(" FROM ", quoteTable(b.table))
+	}
+	for _, j := range b.joins {
+		w.write(" ", j.kind, " ", quoteTable(j.table), " ON ")
+		j.on.render(w)
+	}
+	renderWhere(w, b.where)
+	if len(b.groupBy) > 0 {
+		w.write(" GROUP BY ", quoteIdents(b.groupBy))
+	}
+	if len(b.having) > 0 {
+		w.write(" HAVING ")
+		w.join(b.having, " AND ")
+	}
+	if len(b.orderBy) > 0 {
+		w.write(" ORDER BY ")
+		for i, o := range b.orderBy {
+			if i > 0 {
+				w.write(", ")
+			}
+			o.render(w)
+		}
+	}
+	if b.limit != nil {
+		w.write(" LIMIT ")
+		w.arg(*b.limit)
+	}
+	if b.offset != nil {
+		w.write(" OFFSET ")
+		w.arg(*b.offset)
+	}
+	if b.lock != "" {
+		w.write(" ", b.lock)
+	}
+	renderSuffix(w, b.suffix)
+}
+
+type set struct {
+	col string
+	v   any
+}
+
+type onConflict struct {
+	target    []string
+	doNothing bool
+	sets      []set
+	// update all inserted columns except the target
+	updateAll bool
+}
+
+// InsertBuilder builds an INSERT statement
+type InsertBuilder struct {
+	ctes      ctes
+	table     string
+	columns   []string
+	rows      [][]any
+	sub       Builder
+	conflict  *onConflict
+	returning []string
+	suffix    string
+}
+
+// Insert starts an INSERT into table
+func Insert(table string) *InsertBuilder {
+	b := new(InsertBuilder)
+	b.table = table
+	return b
+}
+
+// Upsert starts an INSERT which updates all the inserted columns
+// except the conflict columns when a row with the same keys exists
+func Upsert(table string, conflictCols ...string) *InsertBuilder {
+	b := Insert(table)
+	b.conflict = &onConflict{target: conflictCols, updateAll: true}
+	return b
+}
+
+func (b *InsertBuilder) With(name string, q Builder) *InsertBuilder {
+	b.ctes = append(b.ctes, cte{name, q})
+	return b
+}
+
+func (b *InsertBuilder) Columns(cols ...string) *InsertBuilder {
+	b.columns = append(b.columns, cols...)
+	return b
+}
+
+// Values adds a row, call it multiple times for a multi row insert
+func (b *InsertBuilder) Values(vals ...any) *InsertBuilder {
+	b.rows = append(b.rows, vals)
+	return b
+}
+
+// FromSelect inserts the rows returned by a query
+func (b *InsertBuilder) FromSelect(q Builder) *InsertBuilder {
+	b.sub = q
+	return b
+}
+
+// OnConflict sets the conflict target, follow it with DoNothing or DoUpdate
+func (b *InsertBuilder) OnConflict(cols ...string) *InsertBuilder {
+	b.conflict = &onConflict{target: cols}
+	return b
+}
+
+func (b *InsertBuilder) DoNothing() *InsertBuilder {
+	if b.conflict == nil {
+		b.conflict = new(onConflict)
+	}
+	b.conflict.doNothing = true
+	return b
+}
+
+// DoUpdate sets the columns to the EXCLUDED values on conflict
+func (b *InsertBuilder) DoUpdate(cols ...string) *InsertBuilder {
+	for _, c := range cols {
+		b.DoUpdateSet(c, Raw("EXCLUDED."+QuoteIdent(c)))
+	}
+	return b
+}
+
+// DoUpdateSet sets col to v on conflict
+func (b *InsertBuilder) DoUpdateSet(col string, v any) *InsertBuilder {
+	if b.conflict == nil {
+		b.conflict = new(onConflict)
+	}
+	b.conflict.sets = append(b.conflict.sets, set{col, v})
+	return b
+}
+
+func (b *InsertBuilder) Returning(cols ...string) *InsertBuilder {
+	b.returning = append(b.returning, cols...)
+	return b
+}
+
+func (b *InsertBuilder) Suffix(s string) *InsertBuilder {
+	b.suffix = s
+	return b
+}
+
+func (b *InsertBuilder) Build() (string, []any) {
+	return build(b)
+}
+
+func (b *InsertBuilder) render(w *writer) {
+	b.ctes.render(w)
+	w.write("INSERT INTO ", quoteTable(b.table))
+	if len(b.columns) > 0 {
+		w.write(" (", quoteIdents(b.columns), ")")
+	}
+	if b.sub != nil {
+		w.write(" ")
+		b.sub.render(w)
+	} else {
+		w.write(" VALUES ")
+		for i, row := range b.rows {
+			if i > 0 {
+				w.write(", ")
+			}
+			w.write("(")
+			for j, v := range row {
+				if j > 0 {
+					w.write(", ")
+				}
+				w.value(v)
+			}
+			w.write(")")
+		}
+	}
+	if c := b.conflict; c != nil {
+		w.write(" ON CONFLICT")
+		if len(c.target) > 0 {
+			w.write(" (", quoteIdents(c.target), ")")
+		}
+		sets := c.sets
+		if c.updateAll {
+			target := make(map[string]bool, len(c.target))
+			for _, t := range c.target {
+				target[t] = true
+			}
+			for _, col := range b.columns {
+				if !target[col] {
+					sets = append(sets, set{col, Raw("EXCLUDED." + QuoteIdent(col))})
+				}
+			}
+		}
+		if c.doNothing || len(sets) == 0 {
+			w.write(" DO NOTHING")
+		} else {
+			w.write(" DO UPDATE SET ")
+			renderSets(w, sets)
+		}
+	}
+	renderReturning(w, b.returning)
+	renderSuffix(w, b.suffix)
+}
+
+func renderSets(w *writer, sets []set) {
+	for i, s := range sets {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(QuoteIdent(s.col), " = ")
+		w.value(s.v)
+	}
+}
+
+// UpdateBuilder builds an UPDATE statement
+type UpdateBuilder struct {
+	ctes      ctes
+	table     string
+	sets      []set
+	from      string
+	where     []Expr
+	returning []string
+	suffix    string
+}
+
+// Update starts an UPDATE of table
+func Update(table string) *UpdateBuilder {
+	b := new(UpdateBuilder)
+	b.table = table
+	return b
+}
+
+func (b *UpdateBuilder) With(name string, q Builder) *UpdateBuilder {
+	b.ctes = append(b.ctes, cte{name, q})
+	return b
+}
+
+// Set sets col to v, v can be an Expr, ex: Set("version", Raw("version + 1"))
+func (b *UpdateBuilder) Set(col string, v any) *UpdateBuilder {
+	b.sets = append(b.sets, set{col, v})
+	return b
+}
+
+func (b *UpdateBuilder) From(table string) *UpdateBuilder {
+	b.from = table
+	return b
+}
+
+func (b *UpdateBuilder) Where(exprs ...Expr) *UpdateBuilder {
+	b.where = append(b.where, exprs...)
+	return b
+}
+
+func (b *UpdateBuilder) Returning(cols ...string) *UpdateBuilder {
+	b.returning = append(b.returning, cols...)
+	return b
+}
+
+func (b *UpdateBuilder) Suffix(s string) *UpdateBuilder {
+	b.suffix = s
+	return b
+}
+
+func (b *UpdateBuilder) Build() (string, []any) {
+	return build(b)
+}
+
+func (b *UpdateBuilder) render(w *writer) {
+	b.ctes.render(w)
+	w.write("UPDATE ", quoteTable(b.table), " SET ")
+	renderSets(w, b.sets)
+	if b.from != "" {
+		w.write(" FROM ", quoteTable(b.from))
+	}
+	renderWhere(w, b.where)
+	renderReturning(w, b.returning)
+	renderSuffix(w, b.suffix)
+}
+
+// DeleteBuilder builds a DELETE statement
+type DeleteBuilder struct {
+	ctes      ctes
+	table     string
+	where     []Expr
+	returning []string
+	suffix    string
+}
+
+// Delete starts a DELETE from table
+func Delete(table string) *DeleteBuilder {
+	b := new(DeleteBuilder)
+	b.table = table
+	return b
+}
+
+func (b *DeleteBuilder) With(name string, q Builder) *DeleteBuilder {
+	b.ctes = append(b.ctes, cte{name, q})
+	return b
+}
+
+func (b *DeleteBuilder) Where(exprs ...Expr) *DeleteBuilder {
+	b.where = append(b.where, exprs...)
+	return b
+}
+
+func (b *DeleteBuilder) Returning(cols ...string) *DeleteBuilder {
+	b.returning = append(b.returning, cols...)
+	return b
+}
+
+func (b *DeleteBuilder) Suffix(s string) *DeleteBuilder {
+	b.suffix = s
+	return b
+}
+
+func (b *DeleteBuilder) Build() (string, []any) {
+	return build(b)
+}
+
+func (b *DeleteBuilder) render(w *writer) {
+	b.ctes.render(w)
+	w.write("DELETE FROM ", quoteTable(b.table))
+	renderWhere(w, b.where)
+	renderReturning(w, b.returning)
+	renderSuffix(w, b.suffix)
+}
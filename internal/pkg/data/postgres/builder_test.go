@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilders(t *testing.T) {
+	tests := []struct {
+		name     string
+		b        Builder
+		expected string
+		args     []any
+	}{
+		{
+			name:     "select all",
+			b:        Select().From("users"),
+			expected: `SELECT * FROM "users"`,
+		},
+		{
+			name: "select where order limit",
+			b: Select("id", "name").From("users").
+				Where(Eq("is_deleted", false), ILike("name", "%a%")).
+				OrderBy(Desc("created_at"), Asc("id")).
+				Limit(10).Offset(20),
+			expected: `SELECT "id", "name" FROM "users" WHERE "is_deleted" = $1 AND "name" ILIKE $2 ORDER BY "created_at" DESC, "id" ASC LIMIT $3 OFFSET $4`,
+			args:     []any{false, "%a%", 10, 20},
+		},
+		{
+			name:     "list default order",
+			b:        Select().From("users").OrderBy(ListOptions{}.Order()...),
+			expected: `SELECT * FROM "users" ORDER BY "created_at" DESC, "id" DESC`,
+		},
+		{
+			name:     "list sorted order",
+			b:        Select().From("users").OrderBy(ListOptions{OrderBy: []Order{Asc("name"), Asc("created_at")}}.Order()...),
+			expected: `SELECT * FROM "users" ORDER BY "name" ASC, "created_at" ASC, "id" DESC`,
+		},
+		{
+			name: "select join or in",
+			b: Select("c.id", "u.name").From("contents c").
+				Join("users u", EqCol("u.id", "c.user_id")).
+				Where(Or(Eq("c.name", "a"), In("c.id", "1", "2")), IsNull("c.deleted_at")),
+			expected: `SELECT "c"."id", "u"."name" FROM "contents" "c" JOIN "users" "u" ON "u"."id" = "c"."user_id" WHERE ("c"."name" = $1 OR "c"."id" IN ($2, $3)) AND "c"."deleted_at" IS NULL`,
+			args:     []any{"a", "1", "2"},
+		},
+		{
+			name:     "empty in",
+			b:        Select().From("users").Where(In("id")),
+			expected: `SELECT * FROM "users" WHERE FALSE`,
+		},
+		{
+			name: "subquery and cte numbering",
+			b: Select().
+				With("active", Select("id").From("users").Where(Eq("role", "admin"))).
+				From("contents").
+				Where(In("user_id", Select("id").From("active")), Gt("created_at", int64(5))),
+			expected: `WITH "active" AS (SELECT "id" FROM "users" WHERE "role" = $1) SELECT * FROM "contents" WHERE "user_id" IN (SELECT "id" FROM "active") AND "created_at" > $2`,
+			args:     []any{"admin", int64(5)},
+		},
+		{
+			name:     "raw",
+			b:        Select().Column(As(Raw("COUNT(*)"), "total")).From("users").Where(Raw("created_at > ? AND created_at < ?", 1, 2)),
+			expected: `SELECT COUNT(*) AS "total" FROM "users" WHERE created_at > $1 AND created_at < $2`,
+			args:     []any{1, 2},
+		},
+		{
+			name:     "keyset seek",
+			b:        Select("id").From("contents").Where(TupleLt([]string{"created_at", "id"}, int64(5), "a")).Limit(3),
+			expected: `SELECT "id" FROM "contents" WHERE ("created_at", "id") < ($1, $2) LIMIT $3`,
+			args:     []any{int64(5), "a", 3},
+		},
+		{
+			name:     "insert many rows",
+			b:        Insert("users").Columns("name", "role").Values("a", "x").Values("b", "y").Returning("id"),
+			expected: `INSERT INTO "users" ("name", "role") VALUES ($1, $2), ($3, $4) RETURNING "id"`,
+			args:     []any{"a", "x", "b", "y"},
+		},
+		{
+			name:     "upsert",
+			b:        Upsert("users", "id").Columns("id", "name").Values("1", "a"),
+			expected: `INSERT INTO "users" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`,
+			args:     []any{"1", "a"},
+		},
+		{
+			name:     "insert do nothing",
+			b:        Insert("users").Columns("name").Values("a").OnConflict("name").DoNothing(),
+			expected: `INSERT INTO "users" ("name") VALUES ($1) ON CONFLICT ("name") DO NOTHING`,
+			args:     []any{"a"},
+		},
+		{
+			name:     "update",
+			b:        Update("users").Set("name", "a").Set("version", Raw("version + 1")).Where(Eq("id", "1")).Returning("version"),
+			expected: `UPDATE "users" SET "name" = $1, "version" = version + 1 WHERE "id" = $2 RETURNING "version"`,
+			args:     []any{"a", "1"},
+		},
+		{
+			name:     "delete",
+			b:        Delete("users").Where(Eq("id", "1"), Not(Exists(Select("id").From("contents").Where(EqCol("contents.user_id", "users.id"))))),
+			expected: `DELETE FROM "users" WHERE "id" = $1 AND NOT (EXISTS (SELECT "id" FROM "contents" WHERE "contents"."user_id" = "users"."id"))`,
+			args:     []any{"1"},
+		},
+		{
+			name:     "purge where",
+			b:        Purge("users", 10, 100, IsNull("x")),
+			expected: `DELETE FROM "users" WHERE "id" IN (SELECT "id" FROM "users" WHERE "deleted_at" < $1 AND "x" IS NULL LIMIT $2)`,
+			args:     []any{int64(10), 100},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q, args := tc.b.Build()
+			if q != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, q)
+			}
+			if len(args) != len(tc.args) || (len(args) > 0 && !reflect.DeepEqual(args, tc.args)) {
+				t.Errorf("Expected '%v', but got '%v'", tc.args, args)
+			}
+		})
+	}
+}
+
+func TestLegacyBuilders(t *testing.T) {
+	tests := []struct {
+		name     string
+		q        string
+		expected string
+	}{
+		{
+			name:     "insert",
+			q:        BuildInsertQuery("users", []string{"name", "created_at"}, "RETURNING id"),
+			expected: `INSERT INTO "users" ("name", "created_at") VALUES ($1, $2) RETURNING id`,
+		},
+		{
+			name:     "select or",
+			q:        BuildSelectQuery("users", []string{}, []string{"is_deleted", "name"}, "LIMIT $3 OFFSET $4", "OR"),
+			expected: `SELECT * FROM "users" WHERE ("is_deleted" = $1 OR "name" = $2) LIMIT $3 OFFSET $4`,
+		},
+		{
+			name:     "update",
+			q:        BuildUpdateQuery("users", []string{"name", "updated_at"}, []string{"id"}, ""),
+			expected: `UPDATE "users" SET "name" = $1, "updated_at" = $2 WHERE "id" = $3`,
+		},
+		{
+			name:     "delete",
+			q:        BuildDeleteQuery("users", []string{"id"}, ""),
+			expected: `DELETE FROM "users" WHERE "id" = $1`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.q != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, tc.q)
+			}
+		})
+	}
+}
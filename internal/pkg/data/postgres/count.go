@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// RowQuerier is implemented by *sql.DB, *sql.Tx, *sqlx.DB & *sqlx.Tx
+type RowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Count returns the exact number of rows of the table matching where
+func Count(ctx context.Context, q RowQuerier, table string, where ...Expr) (int64, error) {
+	var n int64
+	s, args := Select().Column(Raw("COUNT(*)")).From(table).Where(where...).Build()
+	err := q.QueryRowContext(ctx, s, args...).Scan(&n)
+	return n, err
+}
+
+// EstimateCount returns the planner's estimate of the row count of the
+// table from pg_class, it ignores any filter and is cheap on large tables,
+// -1 is returned if the table has not been vacuumed or analyzed yet
+func EstimateCount(ctx context.Context, q RowQuerier, table string) (int64, error) {
+	var n int64
+	s, args := Select().
+		Column(Raw("reltuples::bigint")).
+		From("pg_class").
+		Where(Raw("oid = ?::regclass", table)).
+		Build()
+	err := q.QueryRowContext(ctx, s, args...).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,338 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+)
+
+// writer accumulates the sql text & args of a statement
+// and numbers the placeholders in the order they are written
+type writer struct {
+	sb   strings.Builder
+	args []any
+}
+
+func (w *writer) write(s ...string) {
+	for _, v := range s {
+		w.sb.WriteString(v)
+	}
+}
+
+// arg adds v to the args and writes its placeholder
+func (w *writer) arg(v any) {
+	w.args = append(w.args, v)
+	w.sb.WriteString("$" + strconv.Itoa(len(w.args)))
+}
+
+// value writes v as an expression, a subquery or a placeholder
+func (w *writer) value(v any) {
+	switch v := v.(type) {
+	case Builder:
+		w.write("(")
+		v.render(w)
+		w.write(")")
+	case Expr:
+		v.render(w)
+	default:
+		w.arg(v)
+	}
+}
+
+// join renders the exprs separated by sep
+func (w *writer) join(exprs []Expr, sep string) {
+	for i, e := range exprs {
+		if i > 0 {
+			w.write(sep)
+		}
+		e.render(w)
+	}
+}
+
+// QuoteIdent quotes an identifier, qualified names like
+// "c.name" are quoted per part and "*" is kept as is
+func QuoteIdent(s string) string {
+	parts := strings.Split(s, ".")
+	for i, p := range parts {
+		if p == "*" {
+			continue
+		}
+		parts[i] = `"` + strings.ReplaceAll(p, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteTable quotes a table name with an optional alias
+// ex: "users", "users u", "users AS u"
+func quoteTable(s string) string {
+	f := strings.Fields(s)
+	switch {
+	case len(f) == 2:
+		return QuoteIdent(f[0]) + " " + QuoteIdent(f[1])
+	case len(f) == 3 && strings.EqualFold(f[1], "AS"):
+		return QuoteIdent(f[0]) + " AS " + QuoteIdent(f[2])
+	}
+	return QuoteIdent(s)
+}
+
+func quoteIdents(cols []string) string {
+	q := make([]string, len(cols))
+	for i, c := range cols {
+		q[i] = QuoteIdent(c)
+	}
+	return strings.Join(q, ", ")
+}
+
+// Expr is a sql expression which can be rendered by the builders
+type Expr interface {
+	render(w *writer)
+}
+
+type rawExpr struct {
+	sql  string
+	args []any
+}
+
+// Raw is an sql fragment written as is, "?" in sql are
+// replaced by the numbered placeholders of args
+// ex: Raw("created_at > ?", t), Raw("version + 1")
+func Raw(sql string, args ...any) Expr {
+	return rawExpr{sql: sql, args: args}
+}
+
+func (e rawExpr) render(w *writer) {
+	s := e.sql
+	for _, a := range e.args {
+		i := strings.IndexByte(s, '?')
+		if i < 0 {
+			break
+		}
+		w.write(s[:i])
+		w.value(a)
+		s = s[i+1:]
+	}
+	w.write(s)
+}
+
+type identExpr string
+
+// Ident is a quoted column reference usable as a value
+// ex: Set("updated_at", Ident("created_at"))
+func Ident(col string) Expr {
+	return identExpr(col)
+}
+
+func (e identExpr) render(w *writer) {
+	w.write(QuoteIdent(string(e)))
+}
+
+type aliasExpr struct {
+	e     Expr
+	alias string
+}
+
+// As aliases an expression, ex: As(Raw("COUNT(*)"), "total")
+func As(e Expr, alias string) Expr {
+	return aliasExpr{e: e, alias: alias}
+}
+
+func (e aliasExpr) render(w *writer) {
+	e.e.render(w)
+	w.write(" AS ", QuoteIdent(e.alias))
+}
+
+type cmpExpr struct {
+	col string
+	op  string
+	v   any
+}
+
+func (e cmpExpr) render(w *writer) {
+	w.write(QuoteIdent(e.col), " ", e.op, " ")
+	w.value(e.v)
+}
+
+// Eq renders col = v
+func Eq(col string, v any) Expr { return cmpExpr{col, "=", v} }
+
+// NotEq renders col <> v
+func NotEq(col string, v any) Expr { return cmpExpr{col, "<>", v} }
+
+// Gt renders col > v
+func Gt(col string, v any) Expr { return cmpExpr{col, ">", v} }
+
+// Gte renders col >= v
+func Gte(col string, v any) Expr { return cmpExpr{col, ">=", v} }
+
+// Lt renders col < v
+func Lt(col string, v any) Expr { return cmpExpr{col, "<", v} }
+
+// Lte renders col <= v
+func Lte(col string, v any) Expr { return cmpExpr{col, "<=", v} }
+
+// Like renders col LIKE v
+func Like(col string, v any) Expr { return cmpExpr{col, "LIKE", v} }
+
+// ILike renders col ILIKE v
+func ILike(col string, v any) Expr { return cmpExpr{col, "ILIKE", v} }
+
+// EqCol compares two columns, ex: EqCol("u.id", "c.user_id")
+func EqCol(a, b string) Expr { return cmpExpr{a, "=", identExpr(b)} }
+
+type tupleExpr struct {
+	cols []string
+	op   string
+	vals []any
+}
+
+func (e tupleExpr) render(w *writer) {
+	w.write("(", quoteIdents(e.cols), ") ", e.op, " (")
+	for i, v := range e.vals {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.value(v)
+	}
+	w.write(")")
+}
+
+// TupleLt renders the row comparison (cols...) < (vals...)
+// used for keyset pagination, ex: ("created_at", "id") < ($1, $2)
+func TupleLt(cols []string, vals ...any) Expr { return tupleExpr{cols, "<", vals} }
+
+// TupleGt renders the row comparison (cols...) > (vals...)
+func TupleGt(cols []string, vals ...any) Expr { return tupleExpr{cols, ">", vals} }
+
+type nullExpr struct {
+	col string
+	not bool
+}
+
+func (e nullExpr) render(w *writer) {
+	w.write(QuoteIdent(e.col), " IS ")
+	if e.not {
+		w.write("NOT ")
+	}
+	w.write("NULL")
+}
+
+// IsNull renders col IS NULL
+func IsNull(col string) Expr { return nullExpr{col: col} }
+
+// IsNotNull renders col IS NOT NULL
+func IsNotNull(col string) Expr { return nullExpr{col: col, not: true} }
+
+type inExpr struct {
+	col  string
+	vals []any
+	not  bool
+}
+
+func (e inExpr) render(w *writer) {
+	if len(e.vals) == 0 {
+		// nothing is in an empty set
+		if e.not {
+			w.write("TRUE")
+		} else {
+			w.write("FALSE")
+		}
+		return
+	}
+	w.write(QuoteIdent(e.col))
+	if e.not {
+		w.write(" NOT")
+	}
+	w.write(" IN ")
+	if b, ok := e.vals[0].(Builder); ok && len(e.vals) == 1 {
+		w.write("(")
+		b.render(w)
+		w.write(")")
+		return
+	}
+	w.write("(")
+	for i, v := range e.vals {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.value(v)
+	}
+	w.write(")")
+}
+
+// In renders col IN (vals...), a single Builder val renders a subquery
+func In(col string, vals ...any) Expr { return inExpr{col: col, vals: vals} }
+
+// NotIn renders col NOT IN (vals...)
+func NotIn(col string, vals ...any) Expr { return inExpr{col: col, vals: vals, not: true} }
+
+type groupExpr struct {
+	exprs []Expr
+	op    string
+}
+
+func (e groupExpr) render(w *writer) {
+	if len(e.exprs) == 0 {
+		// identity of the operator
+		if e.op == " OR " {
+			w.write("FALSE")
+		} else {
+			w.write("TRUE")
+		}
+		return
+	}
+	w.write("(")
+	w.join(e.exprs, e.op)
+	w.write(")")
+}
+
+// And joins the exprs with AND
+func And(exprs ...Expr) Expr { return groupExpr{exprs, " AND "} }
+
+// Or joins the exprs with OR
+func Or(exprs ...Expr) Expr { return groupExpr{exprs, " OR "} }
+
+type notExpr struct {
+	e Expr
+}
+
+func (e notExpr) render(w *writer) {
+	w.write("NOT (")
+	e.e.render(w)
+	w.write(")")
+}
+
+// Not negates the expr
+func Not(e Expr) Expr { return notExpr{e} }
+
+type existsExpr struct {
+	b Builder
+}
+
+func (e existsExpr) render(w *writer) {
+	w.write("EXISTS (")
+	e.b.render(w)
+	w.write(")")
+}
+
+// Exists renders EXISTS (subquery)
+func Exists(b Builder) Expr { return existsExpr{b} }
+
+// Order is an ORDER BY item
+type Order struct {
+	col  string
+	desc bool
+}
+
+// Asc orders by col ascending
+func Asc(col string) Order { return Order{col: col} }
+
+// Desc orders by col descending
+func Desc(col string) Order { return Order{col: col, desc: true} }
+
+func (o Order) render(w *writer) {
+	w.write(QuoteIdent(o.col))
+	if o.desc {
+		w.write(" DESC")
+	} else {
+		w.write(" ASC")
+	}
+}
@@ -0,0 +1,41 @@
+package postgres
+
+// ListOptions are the filters, order & projection of a list query
+type ListOptions struct {
+	Where   []Expr
+	OrderBy []Order
+	// Columns are the selected columns, all mapped columns if empty
+	Columns []string
+}
+
+// Projection returns the requested columns or defaults if none,
+// id & created_at are always selected as the cursors are built from them
+func (o ListOptions) Projection(defaults []string) []string {
+	if len(o.Columns) == 0 {
+		return defaults
+	}
+	cols := []string{"id", "created_at"}
+	for _, c := range o.Columns {
+		if c != "id" && c != "created_at" {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// Order returns the requested order followed by created_at & id
+// descending, the ones not ordered by yet, so the rows sharing
+// the sorted values keep a stable order across the pages
+func (o ListOptions) Order() []Order {
+	orders := append([]Order{}, o.OrderBy...)
+	for _, col := range []string{"created_at", "id"} {
+		ordered := false
+		for _, ord := range o.OrderBy {
+			ordered = ordered || ord.col == col
+		}
+		if !ordered {
+			orders = append(orders, Desc(col))
+		}
+	}
+	return orders
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
+)
+
+// Listen calls fn with the payload of every notification on the channel
+// until ctx is done, it holds a connection of the pool, the connection is
+// reestablished after retry on failures and fn is called with an empty
+// payload once listening again as notifications may have been missed
+func Listen(ctx context.Context, db *sql.DB, channel string, retry time.Duration, fn func(payload string)) {
+	retryListen(ctx, channel, retry, func(resync bool) error {
+		return listen(ctx, db, channel, resync, fn)
+	})
+}
+
+// retryListen runs listen until ctx is done, waiting retry after a
+// failure, resync reports whether it listened before
+func retryListen(ctx context.Context, channel string, retry time.Duration, listen func(resync bool) error) {
+	resync := false
+	for {
+		err := listen(resync)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("listen failed", "channel", channel, logext.Err(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+		resync = true
+	}
+}
+
+func listen(ctx context.Context, db *sql.DB, channel string, resync bool, fn func(payload string)) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("postgres: listen needs the pgx driver, got %T", driverConn)
+		}
+		if _, err := c.Conn().Exec(ctx, "LISTEN "+QuoteIdent(channel)); err != nil {
+			return err
+		}
+		if resync {
+			fn("")
+		}
+		for {
+			n, err := c.Conn().WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			fn(n.Payload)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres/postgrestest"
+)
+
+func TestRetryListen(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var resyncs []bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		retryListen(ctx, "c", time.Millisecond, func(resync bool) error {
+			resyncs = append(resyncs, resync)
+			if len(resyncs) == 3 {
+				cancel()
+			}
+			return errors.New("connection reset")
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected retryListen to return once ctx is done")
+	}
+	// every listen after a failure resyncs as notifications may be missed
+	want := []bool{false, true, true}
+	if !reflect.DeepEqual(want, resyncs) {
+		t.Errorf("Expected '%v', but got '%v'", want, resyncs)
+	}
+}
+
+func TestListen(t *testing.T) {
+	db, _ := postgrestest.NewDB()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	called := false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// the fake driver isn't pgx so every listen fails & is retried
+		Listen(ctx, db, "c", time.Millisecond, func(string) {
+			called = true
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Listen to return once ctx is done")
+	}
+	if called {
+		t.Errorf("Expected '%v', but got '%v'", false, called)
+	}
+}
@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// fieldCache holds the column to field index mapping per struct type
+var fieldCache sync.Map
+
+// fieldsOf returns the column to field index mapping of the struct type t
+// the column name is the db tag or the lower cased field name,
+// fields tagged with db:"-" are skipped and the fields of
+// embedded structs are promoted like in Go
+func fieldsOf(t reflect.Type) map[string][]int {
+	if m, ok := fieldCache.Load(t); ok {
+		return m.(map[string][]int)
+	}
+	m := make(map[string][]int)
+	collectFields(t, nil, m)
+	v, _ := fieldCache.LoadOrStore(t, m)
+	return v.(map[string][]int)
+}
+
+func collectFields(t reflect.Type, index []int, m map[string][]int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		// copy so sibling fields don't share the backing array
+		idx := append(index[:len(index):len(index)], i)
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct && !isScanner(ft) {
+			collectFields(ft, idx, m)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		// the shallower field wins, the first one on a tie
+		if prev, ok := m[name]; !ok || len(prev) > len(idx) {
+			m[name] = idx
+		}
+	}
+}
+
+// isScanner reports whether t is scanned as a whole, ex: NullString, time.Time
+func isScanner(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(scannerType) || t == reflect.TypeOf(time.Time{})
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex but
+// allocates the nil embedded struct pointers on the way
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// mapper resolves the scan destinations of T for a result set once
+type mapper[T any] struct {
+	indexes [][]int
+	// scalar is set when T is scanned as a single column, ex: string
+	scalar bool
+}
+
+func newMapper[T any](rows *sql.Rows) (*mapper[T], error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct || isScanner(t) {
+		if len(cols) != 1 {
+			return nil, fmt.Errorf("postgres: scanning %d columns into %s, expected 1", len(cols), t)
+		}
+		return &mapper[T]{scalar: true}, nil
+	}
+	fields := fieldsOf(t)
+	m := &mapper[T]{indexes: make([][]int, len(cols))}
+	for i, c := range cols {
+		idx, ok := fields[c]
+		if !ok {
+			return nil, fmt.Errorf("postgres: missing destination for column %q in %s", c, t)
+		}
+		m.indexes[i] = idx
+	}
+	return m, nil
+}
+
+func (m *mapper[T]) scan(rows *sql.Rows) (T, error) {
+	var e T
+	if m.scalar {
+		err := rows.Scan(&e)
+		return e, err
+	}
+	v := reflect.ValueOf(&e).Elem()
+	dest := make([]any, len(m.indexes))
+	for i, idx := range m.indexes {
+		dest[i] = fieldByIndex(v, idx).Addr().Interface()
+	}
+	err := rows.Scan(dest...)
+	return e, err
+}
+
+// ScanAll scans every row into a T matching the columns to the
+// fields by the db tag, the rows are closed when done
+// ex: contents, err := ScanAll[entity.Content](rows)
+func ScanAll[T any](rows *sql.Rows) ([]T, error) {
+	defer rows.Close()
+	m, err := newMapper[T](rows)
+	if err != nil {
+		return nil, err
+	}
+	d := []T{}
+	for rows.Next() {
+		e, err := m.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		d = append(d, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ScanOne scans the first row into a T, sql.ErrNoRows is
+// returned if there is none, the rows are closed when done
+func ScanOne[T any](rows *sql.Rows) (T, error) {
+	var e T
+	defer rows.Close()
+	m, err := newMapper[T](rows)
+	if err != nil {
+		return e, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return e, err
+		}
+		return e, sql.ErrNoRows
+	}
+	e, err = m.scan(rows)
+	if err != nil {
+		return e, err
+	}
+	return e, rows.Close()
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type base struct {
+	ID        string `db:"id"`
+	CreatedAt int64  `db:"created_at"`
+}
+
+type Audit struct {
+	UpdatedBy NullString `db:"updated_by"`
+	UpdatedAt time.Time  `db:"updated_at"`
+}
+
+type mapped struct {
+	base
+	*Audit
+	ID      string          `db:"key"`
+	Name    string          `db:"name,omitempty"`
+	Tags    PGArray[string] `db:"tags"`
+	Meta    JsonObject      `db:"meta"`
+	Ignored string          `db:"-"`
+	Plain   int
+	private string
+	Extra   map[string]string `db:"extra"`
+}
+
+func TestFieldsOf(t *testing.T) {
+	expected := map[string][]int{
+		"id":         {0, 0},
+		"created_at": {0, 1},
+		"updated_by": {1, 0},
+		"updated_at": {1, 1},
+		"key":        {2},
+		"name":       {3},
+		"tags":       {4},
+		"meta":       {5},
+		"plain":      {7},
+		"extra":      {9},
+	}
+	got := fieldsOf(reflect.TypeOf(mapped{}))
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected '%v', but got '%v'", expected, got)
+	}
+}
+
+func TestFieldByIndexAllocates(t *testing.T) {
+	var e mapped
+	v := reflect.ValueOf(&e).Elem()
+	fieldByIndex(v, []int{1, 0}).Addr().Interface().(*NullString).String = "a"
+	if e.Audit == nil || e.UpdatedBy.String != "a" {
+		t.Errorf("Expected '%v', but got '%v'", "a", e.Audit)
+	}
+}
+
+func TestPGArrayScan(t *testing.T) {
+	var a PGArray[string]
+	if err := a.Scan("{a,b}"); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Valid || !reflect.DeepEqual(a.Elements, []string{"a", "b"}) {
+		t.Errorf("Expected '%v', but got '%v'", []string{"a", "b"}, a.Elements)
+	}
+	if err := a.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+	if a.Valid {
+		t.Errorf("Expected '%v', but got '%v'", false, a.Valid)
+	}
+}
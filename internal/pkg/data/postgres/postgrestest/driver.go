@@ -0,0 +1,158 @@
+// Package postgrestest is a fake database/sql driver recording the
+// statements it runs, to test the transactions without a server
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Recorder records the statements run on its db, BEGIN, COMMIT &
+// ROLLBACK included
+type Recorder struct {
+	mu  sync.Mutex
+	log []string
+	// Fail returns the error of the statement, nil runs it, ex: a
+	// serialization failure on COMMIT
+	Fail func(stmt string) error
+	// Rows returns the columns & the rows of the query, nil returns
+	// no rows
+	Rows func(stmt string) (columns []string, values [][]driver.Value)
+}
+
+// NewDB returns a db on a fake driver & the recorder of its statements
+func NewDB() (*sql.DB, *Recorder) {
+	r := new(Recorder)
+	return sql.OpenDB(connector{r}), r
+}
+
+// Log returns the statements run so far
+func (r *Recorder) Log() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.log...)
+}
+
+// Reset clears the statements run so far
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = nil
+}
+
+func (r *Recorder) rows(stmt string) rows {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Rows == nil {
+		return rows{}
+	}
+	cols, values := r.Rows(stmt)
+	return rows{columns: cols, values: values}
+}
+
+func (r *Recorder) run(stmt string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = append(r.log, stmt)
+	if r.Fail != nil {
+		return r.Fail(stmt)
+	}
+	return nil
+}
+
+type connector struct {
+	r *Recorder
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return conn{c.r}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return fakeDriver{c.r}
+}
+
+type fakeDriver struct {
+	r *Recorder
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return conn{d.r}, nil
+}
+
+type conn struct {
+	r *Recorder
+}
+
+func (c conn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("postgrestest: prepare isn't supported")
+}
+
+func (c conn) Close() error {
+	return nil
+}
+
+func (c conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	if err := c.r.run("BEGIN"); err != nil {
+		return nil, err
+	}
+	return tx{c.r}, nil
+}
+
+func (c conn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := c.r.run(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c conn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := c.r.run(query); err != nil {
+		return nil, err
+	}
+	d := c.r.rows(query)
+	return &d, nil
+}
+
+type tx struct {
+	r *Recorder
+}
+
+func (t tx) Commit() error {
+	return t.r.run("COMMIT")
+}
+
+func (t tx) Rollback() error {
+	return t.r.run("ROLLBACK")
+}
+
+// rows is the result of a query, empty unless Recorder.Rows returns some
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
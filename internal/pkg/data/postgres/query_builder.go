@@ -2,62 +2,42 @@ package postgres
 
 import "strconv"
 
-func BuildInsertQuery(tableName string, columns []string, clause string) string {
-	q := "INSERT INTO " + tableName
-	cols := " ("
-	vals := " VALUES ("
-	for i, v := range columns {
-		cols += v + ", "
-		vals += "$" + strconv.Itoa(i+1) + ", "
+// placeholders returns n nil args, the legacy builders only
+// return the sql and the caller passes the args in the same order
+func placeholders(n int) []any {
+	return make([]any, n)
+}
+
+// whereEq builds col = $n predicates for the legacy builders
+func whereEq(cols []string) []Expr {
+	exprs := make([]Expr, len(cols))
+	for i, c := range cols {
+		exprs[i] = Eq(c, nil)
 	}
-	// remove trailing space & comma
-	// there are two chars to be removed
-	cols = cols[:len(cols)-2]
-	vals = vals[:len(vals)-2]
-	// add closing parentheses
-	cols += ")"
-	vals += ")"
-	return q + cols + vals + " " + clause
+	return exprs
+}
+
+// BuildInsertQuery builds an insert of the columns,
+// clause is appended as is, ex: "RETURNING id"
+func BuildInsertQuery(tableName string, columns []string, clause string) string {
+	q, _ := Insert(tableName).Columns(columns...).Values(placeholders(len(columns))...).Suffix(clause).Build()
+	return q
 }
 
+// BuildSelectQuery builds a select with where clause cols joined by AND,
+// the operator can be changed by passing it as the first arg, ex: "OR"
+// placeholders in clause must continue the numbering of the where clause
 func BuildSelectQuery(tableName string, projections []string, whereClauseCols []string, clause string, args ...string) string {
-	q := "SELECT "
-	p := ""
-	w := ""
-	op := "AND"
-	cl := ""
-	// set clause & operator
-	if clause != "" {
-		cl = clause
-	}
-	argLen := len(args)
-	if argLen > 0 {
-		// operator is in the first arg
-		op = args[0]
-	}
-	if len(projections) > 0 {
-		for _, v := range projections {
-			p += v + ", "
+	b := Select(projections...).From(tableName).Suffix(clause)
+	if len(whereClauseCols) > 0 {
+		if len(args) > 0 && args[0] == "OR" {
+			b.Where(Or(whereEq(whereClauseCols)...))
+		} else {
+			b.Where(whereEq(whereClauseCols)...)
 		}
-		// remove trailing space & comma
-		// there are two chars to be removed
-		p = p[:len(p)-2]
-	} else {
-		p += "*"
 	}
-	// where clause length
-	lenWhereClause := len(whereClauseCols)
-	if lenWhereClause > 0 {
-		opLen := len(op)
-		w += " WHERE "
-		for i, v := range whereClauseCols {
-			w += v + " = $" + strconv.Itoa(i+1) + " " + op + " "
-		}
-		// remove trailing space & operator
-		// there are two spaces and operator length to be removed
-		w = w[:len(w)-opLen-2]
-	}
-	return q + p + " FROM " + tableName + w + " " + cl
+	q, _ := b.Build()
+	return q
 }
 
 func BuildQueryPlaceholder(count int) string {
@@ -71,37 +51,18 @@ func BuildQueryPlaceholder(count int) string {
 	return q
 }
 
+// BuildUpdateQuery builds an update of the columns, the where
+// clause placeholders are numbered after the columns
 func BuildUpdateQuery(tableName string, columns []string, whereClauseCols []string, clause string) string {
-	q := "UPDATE " + tableName
-	c := " SET "
-	w := " WHERE "
-	// col length
-	lenCols := len(columns)
-	if lenCols > 0 {
-		for i, v := range columns {
-			c += v + " = $" + strconv.Itoa(i+1) + ", "
-		}
-		// remove trailing space & comma
-		// there are two chars to be removed
-		c = c[:len(c)-2]
-	}
-	for i, v := range whereClauseCols {
-		w += v + " = $" + strconv.Itoa(i+1+lenCols) + ", "
+	b := Update(tableName).Where(whereEq(whereClauseCols)...).Suffix(clause)
+	for _, c := range columns {
+		b.Set(c, nil)
 	}
-	// remove trailing space & comma
-	// there are two chars to be removed
-	w = w[:len(w)-2]
-	return q + c + w + " " + clause
+	q, _ := b.Build()
+	return q
 }
 
 func BuildDeleteQuery(tableName string, whereClauseCols []string, clause string) string {
-	q := "DELETE FROM " + tableName
-	w := " WHERE "
-	for i, v := range whereClauseCols {
-		w += v + " = $" + strconv.Itoa(i+1) + ", "
-	}
-	// remove trailing space & comma
-	// there are two chars to be removed
-	w = w[:len(w)-2]
-	return q + w + " " + clause
+	q, _ := Delete(tableName).Where(whereEq(whereClauseCols)...).Suffix(clause).Build()
+	return q
 }
@@ -0,0 +1,44 @@
+package postgres
+
+// soft delete columns, the rows with deleted_at set are hidden from the reads
+const (
+	ColDeletedAt = "deleted_at"
+	ColDeletedBy = "deleted_by"
+)
+
+// NotDeleted scopes a query to the rows which are not soft deleted
+func NotDeleted() Expr {
+	return IsNull(ColDeletedAt)
+}
+
+// SoftDelete builds the update marking the row as deleted at
+// the time by the user, by is stored as NULL when empty
+func SoftDelete(table, id, by string, at int64) *UpdateBuilder {
+	var deletedBy any
+	if by != "" {
+		deletedBy = by
+	}
+	return Update(table).
+		Set(ColDeletedAt, at).
+		Set(ColDeletedBy, deletedBy).
+		Set("updated_at", at).
+		Where(Eq("id", id), NotDeleted())
+}
+
+// Restore builds the update clearing the soft delete of the row
+func Restore(table, id string, at int64) *UpdateBuilder {
+	return Update(table).
+		Set(ColDeletedAt, nil).
+		Set(ColDeletedBy, nil).
+		Set("updated_at", at).
+		Where(Eq("id", id), IsNotNull(ColDeletedAt))
+}
+
+// Purge builds the delete of at most limit rows soft deleted before the
+// time & matching where, ex: not referenced by rows still to keep
+func Purge(table string, before int64, limit int, where ...Expr) *DeleteBuilder {
+	return Delete(table).Where(In("id", Select("id").
+		From(table).
+		Where(append([]Expr{Lt(ColDeletedAt, before)}, where...)...).
+		Limit(limit)))
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartQuery starts the client span of the query, named by its
+// operation, ex: SELECT
+func StartQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	op := operation(query)
+	return tracing.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(op),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+// EndQuery ends the span of the query, no rows isn't an error
+func EndQuery(span trace.Span, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
+func operation(query string) string {
+	f := strings.Fields(query)
+	if len(f) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(f[0])
+}
+
+// tracedDBTX starts a span per query of the db or the tx
+type tracedDBTX struct {
+	DBTX
+}
+
+func (t tracedDBTX) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := StartQuery(ctx, query)
+	res, err := t.DBTX.ExecContext(ctx, query, args...)
+	EndQuery(span, err)
+	return res, err
+}
+
+func (t tracedDBTX) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := StartQuery(ctx, query)
+	rows, err := t.DBTX.QueryContext(ctx, query, args...)
+	EndQuery(span, err)
+	return rows, err
+}
+
+func (t tracedDBTX) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := StartQuery(ctx, query)
+	row := t.DBTX.QueryRowContext(ctx, query, args...)
+	EndQuery(span, row.Err())
+	return row
+}
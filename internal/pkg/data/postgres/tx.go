@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/errorext"
+)
+
+// txKey is the context key for the active transaction
+type txKey struct{}
+
+// txState is the active transaction with
+// a counter to generate unique savepoint names
+type txState struct {
+	tx         *sql.Tx
+	savepoints int
+}
+
+// DBTX is implemented by both *sql.DB and *sql.Tx
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// TxFromContext returns the transaction started by TxManager.WithinTx if any
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	s, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return s.tx, true
+}
+
+// Conn returns the active transaction from the context
+// or falls back to the db, repositories should run
+// their queries on it to take part in the transaction,
+// every query is traced
+func Conn(ctx context.Context, db *sql.DB) DBTX {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tracedDBTX{tx}
+	}
+	return tracedDBTX{db}
+}
+
+// IsRetryable reports whether the error is a serialization
+// failure or a deadlock, retrying the whole transaction may succeed
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == errorext.SQLCodeSerializationFailure || pgErr.Code == errorext.SQLCodeDeadlockDetected
+	}
+	return false
+}
+
+// TxOptions configures a transaction
+type TxOptions struct {
+	Isolation  sql.IsolationLevel
+	ReadOnly   bool
+	MaxRetries int
+}
+
+type TxOption func(*TxOptions)
+
+// WithIsolation sets the isolation level of the transaction
+func WithIsolation(l sql.IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = l
+	}
+}
+
+// WithReadOnly starts a read only transaction
+func WithReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = true
+	}
+}
+
+// WithMaxRetries sets how many times the transaction is
+// retried on serialization failures, 0 disables the retry
+func WithMaxRetries(n int) TxOption {
+	return func(o *TxOptions) {
+		o.MaxRetries = n
+	}
+}
+
+// TxManager runs funcs inside a transaction
+// which is threaded to the repositories through the context
+type TxManager struct {
+	db         *sql.DB
+	maxRetries int
+	backoff    time.Duration
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	m := new(TxManager)
+	m.db = db
+	m.maxRetries = 3
+	m.backoff = 20 * time.Millisecond
+	return m
+}
+
+// WithinTx runs fn in a transaction, fn must use the passed ctx
+// the transaction is committed if fn returns nil, rolled back otherwise
+// if ctx already carries a transaction fn runs in a savepoint of it,
+// options are ignored in that case as they apply to the outer transaction
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error {
+	if s, ok := ctx.Value(txKey{}).(*txState); ok {
+		return m.withinSavepoint(ctx, s, fn)
+	}
+	o := TxOptions{MaxRetries: m.maxRetries}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, o, fn)
+		if err == nil || !IsRetryable(err) || attempt >= o.MaxRetries {
+			return err
+		}
+		// linear backoff before retrying the whole transaction
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(m.backoff * time.Duration(attempt+1)):
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, o TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *TxManager) withinSavepoint(ctx context.Context, s *txState, fn func(ctx context.Context) error) error {
+	s.savepoints++
+	name := "sp_" + strconv.Itoa(s.savepoints)
+	if _, err := s.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = s.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+	if err := fn(ctx); err != nil {
+		if _, rbErr := s.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	_, err := s.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres/postgrestest"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&pgconn.PgError{Code: "40001"}, true},
+		{&pgconn.PgError{Code: "40P01"}, true},
+		{fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40001"}), true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{errors.New("connection reset"), false},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		g := IsRetryable(tc.err)
+		if tc.want != g {
+			t.Errorf("Expected '%t', but got '%t' for '%v'", tc.want, g, tc.err)
+		}
+	}
+}
+
+func TestConnWithoutTx(t *testing.T) {
+	if _, ok := TxFromContext(context.Background()); ok {
+		t.Errorf("Expected no tx in an empty context")
+	}
+}
+
+func TestWithinTx(t *testing.T) {
+	errFn := errors.New("fn failed")
+	serialization := &pgconn.PgError{Code: "40001"}
+	cases := []struct {
+		name     string
+		fn       func(m *TxManager, ctx context.Context) error
+		fail     map[string]int
+		expected []string
+		err      error
+	}{
+		{
+			name:     "commit",
+			fn:       func(m *TxManager, ctx context.Context) error { return nil },
+			expected: []string{"BEGIN", "COMMIT"},
+		},
+		{
+			name:     "rollback",
+			fn:       func(m *TxManager, ctx context.Context) error { return errFn },
+			expected: []string{"BEGIN", "ROLLBACK"},
+			err:      errFn,
+		},
+		{
+			name: "savepoints",
+			fn: func(m *TxManager, ctx context.Context) error {
+				// the failed nested tx is rolled back to its savepoint only
+				_ = m.WithinTx(ctx, func(ctx context.Context) error { return errFn })
+				return m.WithinTx(ctx, func(ctx context.Context) error {
+					return m.WithinTx(ctx, func(ctx context.Context) error { return nil })
+				})
+			},
+			expected: []string{
+				"BEGIN",
+				"SAVEPOINT sp_1", "ROLLBACK TO SAVEPOINT sp_1",
+				"SAVEPOINT sp_2", "SAVEPOINT sp_3", "RELEASE SAVEPOINT sp_3", "RELEASE SAVEPOINT sp_2",
+				"COMMIT",
+			},
+		},
+		{
+			name:     "retry on serialization failure",
+			fn:       func(m *TxManager, ctx context.Context) error { return nil },
+			fail:     map[string]int{"COMMIT": 2},
+			expected: []string{"BEGIN", "COMMIT", "BEGIN", "COMMIT", "BEGIN", "COMMIT"},
+		},
+		{
+			name:     "retries exhausted",
+			fn:       func(m *TxManager, ctx context.Context) error { return nil },
+			fail:     map[string]int{"COMMIT": 5},
+			expected: []string{"BEGIN", "COMMIT", "BEGIN", "COMMIT", "BEGIN", "COMMIT", "BEGIN", "COMMIT"},
+			err:      serialization,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, r := postgrestest.NewDB()
+			defer db.Close()
+			r.Fail = func(stmt string) error {
+				if tc.fail[stmt] > 0 {
+					tc.fail[stmt]--
+					return serialization
+				}
+				return nil
+			}
+			m := NewTxManager(db)
+			m.backoff = 0
+			err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+				if _, ok := TxFromContext(ctx); !ok {
+					t.Errorf("Expected a tx in the context")
+				}
+				return tc.fn(m, ctx)
+			})
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Expected '%v', but got '%v'", tc.err, err)
+			}
+			if got := r.Log(); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, got)
+			}
+		})
+	}
+}
@@ -2,9 +2,11 @@ package postgres
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
@@ -221,7 +223,6 @@ func (p Point) MarshalJSON() ([]byte, error) {
 }
 
 /* func (p Point) Value() (driver.Value, error) {
-	log.Println("Value() called.point: ", p)
 	if p.Lat == constant.InvalidLatLng && p.Lng == constant.InvalidLatLng {
 		return nil, nil
 	}
@@ -239,6 +240,13 @@ type JsonArray []map[string]any
 func (j *JsonArray) Scan(val any) error {
 	var jsonData []map[string]any
 	switch v := val.(type) {
+	case nil:
+		*j = nil
+		return nil
+	case string:
+		val = []byte(v)
+	}
+	switch v := val.(type) {
 	case []byte:
 		err := json.Unmarshal(v, &jsonData)
 		if err != nil {
@@ -256,8 +264,12 @@ type JsonObject map[string]any
 // Scan implements the Scanner interface for JsonObject
 func (j *JsonObject) Scan(val any) error {
 	var jsonObj map[string]any
-	if val == nil {
-		j = nil		
+	switch v := val.(type) {
+	case nil:
+		*j = nil
+		return nil
+	case string:
+		val = []byte(v)
 	}
 	switch v := val.(type) {
 	case []byte:
@@ -278,6 +290,13 @@ type JsonStringArray []string
 func (j *JsonStringArray) Scan(val any) error {
 	var jsonData JsonStringArray
 	switch v := val.(type) {
+	case nil:
+		*j = nil
+		return nil
+	case string:
+		val = []byte(v)
+	}
+	switch v := val.(type) {
 	case []byte:
 		err := json.Unmarshal(v, &jsonData)
 		if err != nil {
@@ -296,6 +315,13 @@ type Json2dArray [][]string
 func (j *Json2dArray) Scan(val any) error {
 	var jsonData Json2dArray
 	switch v := val.(type) {
+	case nil:
+		*j = nil
+		return nil
+	case string:
+		val = []byte(v)
+	}
+	switch v := val.(type) {
 	case []byte:
 		err := json.Unmarshal(v, &jsonData)
 		if err != nil {
@@ -314,3 +340,21 @@ type PGArray[T any] struct {
 func MakePGArray[T any](arr []T, valid bool) PGArray[T] {
 	return PGArray[T]{pgtype.Array[T]{Elements: arr, Valid: valid}}
 }
+
+// pgtypeMaps pools the type maps used to scan arrays, a map is not safe for concurrent use
+var pgtypeMaps = sync.Pool{New: func() any { return pgtype.NewMap() }}
+
+// Scan implements the Scanner interface for PGArray
+func (a *PGArray[T]) Scan(val any) error {
+	m := pgtypeMaps.Get().(*pgtype.Map)
+	defer pgtypeMaps.Put(m)
+	return m.SQLScanner(&a.Array).Scan(val)
+}
+
+// Value implements the driver Valuer interface for PGArray
+func (a PGArray[T]) Value() (driver.Value, error) {
+	if !a.Valid {
+		return nil, nil
+	}
+	return a.Elements, nil
+}
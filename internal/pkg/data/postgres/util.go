@@ -2,34 +2,38 @@ package postgres
 
 import (
 	"database/sql"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/errorext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
 )
 
 // ScanRows convert rows to struct slice
 // must provide pointer address for params
+//
+// Deprecated: params must point into e and every row overwrites
+// the same e, use ScanAll which maps the columns by the db tag
 func ScanRows[T any](rows *sql.Rows, e *T, params ...any) ([]T, errorext.HTTPError) {
 	d := []T{}
 	// Loop through rows, using Scan to assign column data to struct fields.
 	for rows.Next() {
 		if err := rows.Scan(params...); err != nil {
-			log.Println("error: ", err)
+			slog.Error("scan row failed", logext.Err(err))
 			return nil, errorext.BuildDBError(err)
 		}
 		d = append(d, *e)
-		fmt.Println("scan.e.pointer: ", &e)
 	}
 	return d, errorext.HTTPError{}
 }
 
 // ScanRow convert row to struct
 // must provide pointer address for params
+//
+// Deprecated: use ScanOne
 func ScanRow[T any](row *sql.Row, obj *T, params ...any) errorext.HTTPError {
 	if err := row.Scan(params...); err != nil {
-		log.Println("error: ", err)
+		slog.Error("scan row failed", logext.Err(err))
 		return errorext.BuildDBError(err)
 	}
 	return errorext.HTTPError{}
@@ -74,7 +78,7 @@ func ScanRowBasic[T any](row *sql.Row, e *T) errorext.HTTPError {
 func GetRowsAffected(result sql.Result) int64 {
 	rows, err := result.RowsAffected()
 	if err != nil {
-		log.Println(err)
+		slog.Error("read rows affected failed", logext.Err(err))
 	}
 	return rows
 }
@@ -1,84 +1,59 @@
 package sqlxext
 
 import (
-	"fmt"
-	"log"
-	"sync"
+	"context"
+	"log/slog"
 	"sync/atomic"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
 )
 
-var (
-	instance    *Client
-	once        sync.Once
-	mu          sync.Mutex
-	initialized uint32
-)
-
 type Client struct {
 	DB *sqlx.DB
+	// password replaces the one of the config when set
+	password atomic.Pointer[string]
 }
 
-func GetInstance() *Client {
-	once.Do(func() {
-		instance = new(Client)
-		instance.init()
-	})
-	return instance
-}
-
-func GetInstanceMutex() *Client {
-	if instance == nil {
-		mu.Lock()
-		defer mu.Unlock()
-		if instance == nil {
-			instance = new(Client)
-			instance.init()
-		}
-	}
-	return instance
-}
-
-func GetInstanceAtomic() *Client {
-	if atomic.LoadUint32(&initialized) == 1 {
-		return instance
+// NewClient connects to the db of the config
+func NewClient(c config.DB) (*Client, error) {
+	client := new(Client)
+	if err := client.init(c); err != nil {
+		return nil, err
 	}
-	mu.Lock()
-	defer mu.Unlock()
-	if initialized == 0 {
-		instance = new(Client)
-		instance.init()
-		atomic.StoreUint32(&initialized, 1)
-	}
-	return instance
+	return client, nil
 }
 
-func (c *Client) init() {
+func (c *Client) init(cfg config.DB) error {
 	// connection properties.
-	info := fmt.Sprintf("host=%s port=%s user=%s "+
-		"password=%s dbname=%s sslmode=disable",
-		config.GetEnvValue("DB_HOST"), config.GetEnvValue("DB_PORT"), config.GetEnvValue("DB_USER"), config.GetEnvValue("DB_PASS"), config.GetEnvValue("DB_NAME"))
-	var err error
-	c.DB, err = sqlx.Open("pgx", info)
+	info := cfg.DSN()
+	connConfig, err := pgx.ParseConfig(info)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	// the new connections use the current password so it can rotate
+	c.DB = sqlx.NewDb(stdlib.OpenDB(*connConfig, stdlib.OptionBeforeConnect(func(ctx context.Context, cc *pgx.ConnConfig) error {
+		if p := c.password.Load(); p != nil {
+			cc.Password = *p
+		}
+		return nil
+	})), "pgx")
 	// ping is necessary to create connection
 	err = c.DB.Ping()
 	if err != nil {
-		panic(err)
-	}
-	log.Println("Successfully connected!")
-	// create table if not exists
-	_, err = c.DB.Exec("CREATE TABLE IF NOT EXISTS users (id uuid PRIMARY KEY DEFAULT gen_random_uuid(), name VARCHAR NOT NULL, role VARCHAR NOT NULL, created_at BIGINT, updated_at BIGINT)")
-	if err != nil {
-		panic(err)
-	}
-	_, err = c.DB.Exec("CREATE TABLE IF NOT EXISTS contents (id uuid PRIMARY KEY DEFAULT gen_random_uuid(), name VARCHAR NOT NULL, created_at BIGINT, updated_at BIGINT)")
-	if err != nil {
-		panic(err)
+		c.DB.Close()
+		return err
 	}
+	slog.Info("connected to db")
+	// tables are managed by the versioned migrations
+	// in the migrations dir, see internal/pkg/data/migrate
+	return nil
+}
+
+// SetPassword sets the password of the new connections, the
+// open ones are kept as the server doesn't drop them
+func (c *Client) SetPassword(p string) {
+	c.password.Store(&p)
 }
@@ -4,18 +4,37 @@ import (
 	"context"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
 )
 
 type Repository[T any] interface {
-	Create(e T, ctx context.Context) error
+	// Create inserts e and returns the id of the row
+	Create(e T, ctx context.Context) (string, error)
 
-	ReadMany(limit, offset int, ctx context.Context) ([]T, error)
+	ReadMany(limit, offset int, opts postgres.ListOptions, ctx context.Context) ([]T, error)
+
+	// ReadManySeek reads the rows after the cursor
+	// in the order of pagination.Seek
+	ReadManySeek(limit int, cursor *pagination.Cursor, opts postgres.ListOptions, ctx context.Context) ([]T, error)
+
+	Count(mode pagination.TotalMode, opts postgres.ListOptions, ctx context.Context) (int64, error)
 
 	ReadOne(id string, ctx context.Context) (T, error)
 
+	// Update writes e if the row version is still e.Version
 	Update(id string, e T, ctx context.Context) (int64, error)
 
-	Delete(id string, ctx context.Context) (int64, error)
+	// Delete soft deletes the row if its version is still version,
+	// by is the id of the deleting user
+	Delete(id, by string, version int64, ctx context.Context) (int64, error)
+
+	Restore(id string, ctx context.Context) (T, error)
+
+	DeleteHard(id string, ctx context.Context) (T, error)
+
+	// Purge permanently deletes at most limit rows soft deleted before the time
+	Purge(before int64, limit int, ctx context.Context) (int64, error)
 
 	DB() *sqlx.DB
 }
@@ -0,0 +1,83 @@
+package sqlxext
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+)
+
+// Querier is implemented by both *sqlx.DB and *sqlx.Tx
+type Querier interface {
+	sqlx.ExtContext
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// Conn returns the transaction started by postgres.TxManager
+// wrapped as *sqlx.Tx if the context carries one, otherwise the db,
+// every query is traced
+func Conn(ctx context.Context, db *sqlx.DB) Querier {
+	if tx, ok := postgres.TxFromContext(ctx); ok {
+		return tracedQuerier{&sqlx.Tx{Tx: tx, Mapper: db.Mapper}}
+	}
+	return tracedQuerier{db}
+}
+
+// tracedQuerier starts a span per query, sqlx runs Get & Select
+// on the wrapped querier so they aren't traced twice
+type tracedQuerier struct {
+	Querier
+}
+
+func (t tracedQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := postgres.StartQuery(ctx, query)
+	res, err := t.Querier.ExecContext(ctx, query, args...)
+	postgres.EndQuery(span, err)
+	return res, err
+}
+
+func (t tracedQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := postgres.StartQuery(ctx, query)
+	rows, err := t.Querier.QueryContext(ctx, query, args...)
+	postgres.EndQuery(span, err)
+	return rows, err
+}
+
+func (t tracedQuerier) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	ctx, span := postgres.StartQuery(ctx, query)
+	rows, err := t.Querier.QueryxContext(ctx, query, args...)
+	postgres.EndQuery(span, err)
+	return rows, err
+}
+
+func (t tracedQuerier) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	ctx, span := postgres.StartQuery(ctx, query)
+	row := t.Querier.QueryRowxContext(ctx, query, args...)
+	postgres.EndQuery(span, row.Err())
+	return row
+}
+
+func (t tracedQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := postgres.StartQuery(ctx, query)
+	row := t.Querier.QueryRowContext(ctx, query, args...)
+	postgres.EndQuery(span, row.Err())
+	return row
+}
+
+func (t tracedQuerier) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := postgres.StartQuery(ctx, query)
+	err := t.Querier.GetContext(ctx, dest, query, args...)
+	postgres.EndQuery(span, err)
+	return err
+}
+
+func (t tracedQuerier) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := postgres.StartQuery(ctx, query)
+	err := t.Querier.SelectContext(ctx, dest, query, args...)
+	postgres.EndQuery(span, err)
+	return err
+}
@@ -2,13 +2,15 @@ package sqlxext
 
 import (
 	"database/sql"
-	"log"
+	"log/slog"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
 )
 
 func GetRowsAffected(result sql.Result) int64 {
 	rows, err := result.RowsAffected()
 	if err != nil {
-		log.Println(err)
+		slog.Error("read rows affected failed", logext.Err(err))
 	}
 	return rows
 }
@@ -0,0 +1,231 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Lifetime is how long a built value is reused
+type Lifetime int
+
+const (
+	// Singleton is built once per container
+	Singleton Lifetime = iota
+	// Scoped is built once per scope, ex: per test or per request
+	Scoped
+	// Transient is built on every resolve
+	Transient
+)
+
+var (
+	ErrNotProvided = errors.New("not provided")
+	ErrCycle       = errors.New("dependency cycle")
+	ErrNoScope     = errors.New("scoped value resolved outside of a scope")
+)
+
+type provider struct {
+	lifetime Lifetime
+	build    func(c *Container) (any, error)
+}
+
+type registry struct {
+	mu        sync.RWMutex
+	providers map[reflect.Type]*provider
+}
+
+// slot holds the value of a type, its mutex is held while building
+// so concurrent resolves share the value
+type slot struct {
+	mu    sync.Mutex
+	built bool
+	value any
+}
+
+type instances struct {
+	mu    sync.Mutex
+	slots map[reflect.Type]*slot
+}
+
+func newInstances() *instances {
+	in := new(instances)
+	in.slots = make(map[reflect.Type]*slot)
+	return in
+}
+
+func (in *instances) get(t reflect.Type, build func() (any, error)) (any, error) {
+	in.mu.Lock()
+	s, ok := in.slots[t]
+	if !ok {
+		s = new(slot)
+		in.slots[t] = s
+	}
+	in.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.built {
+		return s.value, nil
+	}
+	// a failed build is not kept so it is retried
+	v, err := build()
+	if err != nil {
+		return nil, err
+	}
+	s.value, s.built = v, true
+	return v, nil
+}
+
+// Container builds the values of the provided types with their
+// dependencies, it holds no global state so each App has its own
+type Container struct {
+	registry   *registry
+	singletons *instances
+	// scoped is nil in the root container & while building a singleton
+	scoped *instances
+	// path is the types being built, to detect the cycles
+	path []reflect.Type
+}
+
+func New() *Container {
+	c := new(Container)
+	c.registry = &registry{providers: make(map[reflect.Type]*provider)}
+	c.singletons = newInstances()
+	return c
+}
+
+// Scope returns a container sharing the providers & the singletons
+// of c with its own scoped values
+func (c *Container) Scope() *Container {
+	s := new(Container)
+	s.registry = c.registry
+	s.singletons = c.singletons
+	s.scoped = newInstances()
+	return s
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Provide registers the constructor of T, it replaces the one provided
+// before, ex: a fake in a test, the values built already are kept
+func Provide[T any](c *Container, lifetime Lifetime, fn func(c *Container) (T, error)) {
+	c.registry.mu.Lock()
+	defer c.registry.mu.Unlock()
+	c.registry.providers[typeOf[T]()] = &provider{
+		lifetime: lifetime,
+		build: func(c *Container) (any, error) {
+			return fn(c)
+		},
+	}
+}
+
+// Value provides v as the singleton of T
+func Value[T any](c *Container, v T) {
+	Provide(c, Singleton, func(*Container) (T, error) { return v, nil })
+}
+
+// Resolve returns the value of T built by its provider
+func Resolve[T any](c *Container) (T, error) {
+	var zero T
+	v, err := c.resolve(typeOf[T]())
+	if err != nil {
+		return zero, err
+	}
+	// a nil interface or pointer is kept as any(nil)
+	if v == nil {
+		return zero, nil
+	}
+	return v.(T), nil
+}
+
+// MustResolve is Resolve panicking on error, within a provider, Invoke
+// or Module the panic is returned as the error of the resolve
+func MustResolve[T any](c *Container) T {
+	v, err := Resolve[T](c)
+	if err != nil {
+		panic(resolveError{err})
+	}
+	return v
+}
+
+// resolveError is the panic of MustResolve
+type resolveError struct {
+	err error
+}
+
+func (e resolveError) Error() string {
+	return e.err.Error()
+}
+
+func (e resolveError) Unwrap() error {
+	return e.err
+}
+
+func (c *Container) resolve(t reflect.Type) (any, error) {
+	for i, p := range c.path {
+		if p == t {
+			return nil, fmt.Errorf("%w: %s", ErrCycle, pathString(append(c.path[i:], t)))
+		}
+	}
+	c.registry.mu.RLock()
+	p, ok := c.registry.providers[t]
+	c.registry.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", t, ErrNotProvided)
+	}
+	next := &Container{
+		registry:   c.registry,
+		singletons: c.singletons,
+		scoped:     c.scoped,
+		path:       append(c.path[:len(c.path):len(c.path)], t),
+	}
+	build := func() (any, error) {
+		return invoke(next, p.build)
+	}
+	switch p.lifetime {
+	case Singleton:
+		// a singleton outlives the scopes so it can't depend on a scoped value
+		next.scoped = nil
+		return c.singletons.get(t, build)
+	case Scoped:
+		if c.scoped == nil {
+			return nil, fmt.Errorf("%s: %w", t, ErrNoScope)
+		}
+		return c.scoped.get(t, build)
+	default:
+		return build()
+	}
+}
+
+func pathString(path []reflect.Type) string {
+	s := make([]string, len(path))
+	for i, t := range path {
+		s[i] = t.String()
+	}
+	return strings.Join(s, " -> ")
+}
+
+// invoke calls fn recovering the panics of MustResolve
+func invoke(c *Container, fn func(c *Container) (any, error)) (v any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(resolveError)
+			if !ok {
+				panic(r)
+			}
+			err = e.err
+		}
+	}()
+	return fn(c)
+}
+
+// Invoke calls fn with c, MustResolve can be used within
+func Invoke(c *Container, fn func(c *Container) error) error {
+	_, err := invoke(c, func(c *Container) (any, error) {
+		return nil, fn(c)
+	})
+	return err
+}
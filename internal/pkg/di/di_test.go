@@ -0,0 +1,151 @@
+package di
+
+import (
+	"errors"
+	"testing"
+)
+
+type a struct{ b *b }
+
+type b struct{ n int }
+
+type scoped struct{ n int }
+
+func TestLifetimes(t *testing.T) {
+	tests := []struct {
+		name     string
+		lifetime Lifetime
+		same     bool
+	}{
+		{"singleton", Singleton, true},
+		{"scoped", Scoped, true},
+		{"transient", Transient, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New()
+			n := 0
+			Provide(c, tc.lifetime, func(*Container) (*b, error) {
+				n++
+				return &b{n: n}, nil
+			})
+			s := c.Scope()
+			x, err := Resolve[*b](s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			y, _ := Resolve[*b](s)
+			if got := x == y; got != tc.same {
+				t.Errorf("Expected '%v', but got '%v'", tc.same, got)
+			}
+		})
+	}
+}
+
+func TestScope(t *testing.T) {
+	c := New()
+	n := 0
+	Provide(c, Scoped, func(*Container) (*scoped, error) {
+		n++
+		return &scoped{n: n}, nil
+	})
+	if _, err := Resolve[*scoped](c); !errors.Is(err, ErrNoScope) {
+		t.Errorf("Expected '%v', but got '%v'", ErrNoScope, err)
+	}
+	x := MustResolve[*scoped](c.Scope())
+	y := MustResolve[*scoped](c.Scope())
+	if x == y {
+		t.Errorf("Expected a value per scope, but got '%v' twice", x.n)
+	}
+	// a singleton outlives the scopes so it can't capture one
+	Provide(c, Singleton, func(c *Container) (*b, error) {
+		return &b{n: MustResolve[*scoped](c).n}, nil
+	})
+	if _, err := Resolve[*b](c.Scope()); !errors.Is(err, ErrNoScope) {
+		t.Errorf("Expected '%v', but got '%v'", ErrNoScope, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	c := New()
+	Provide(c, Singleton, func(c *Container) (*a, error) {
+		return &a{b: MustResolve[*b](c)}, nil
+	})
+	if _, err := Resolve[*a](c); !errors.Is(err, ErrNotProvided) {
+		t.Errorf("Expected '%v', but got '%v'", ErrNotProvided, err)
+	}
+	Provide(c, Singleton, func(*Container) (*b, error) {
+		return &b{n: 1}, nil
+	})
+	// the failed build isn't kept
+	x, err := Resolve[*a](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.b != MustResolve[*b](c) {
+		t.Errorf("Expected the singleton of b, but got '%v'", x.b)
+	}
+	// a container of its own doesn't share the values
+	d := New()
+	Value(d, &b{n: 2})
+	if got := MustResolve[*b](d).n; got != 2 {
+		t.Errorf("Expected '%v', but got '%v'", 2, got)
+	}
+}
+
+func TestCycle(t *testing.T) {
+	c := New()
+	Provide(c, Singleton, func(c *Container) (*a, error) {
+		MustResolve[*b](c)
+		return new(a), nil
+	})
+	Provide(c, Transient, func(c *Container) (*b, error) {
+		MustResolve[*a](c)
+		return new(b), nil
+	})
+	_, err := Resolve[*a](c)
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("Expected '%v', but got '%v'", ErrCycle, err)
+	}
+	want := "dependency cycle: *di.a -> *di.b -> *di.a"
+	if err.Error() != want {
+		t.Errorf("Expected '%v', but got '%v'", want, err)
+	}
+}
+
+type module struct {
+	registered *[]string
+	name       string
+}
+
+func (m module) Provide(c *Container) {
+	Value(c, &b{n: len(m.name)})
+}
+
+func (m module) Register(c *Container) error {
+	*m.registered = append(*m.registered, m.name)
+	if m.name == "" {
+		MustResolve[*scoped](c)
+	}
+	return nil
+}
+
+func TestInstall(t *testing.T) {
+	c := New()
+	var registered []string
+	err := Install(c, module{&registered, "a"}, module{&registered, "bb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the later module replaces the provider of b
+	if got := MustResolve[*b](c).n; got != 2 {
+		t.Errorf("Expected '%v', but got '%v'", 2, got)
+	}
+	if len(registered) != 2 {
+		t.Errorf("Expected '%v', but got '%v'", 2, len(registered))
+	}
+	// the panic of MustResolve is the error of Install
+	if err := Install(New(), module{&registered, ""}); !errors.Is(err, ErrNotProvided) {
+		t.Errorf("Expected '%v', but got '%v'", ErrNotProvided, err)
+	}
+}
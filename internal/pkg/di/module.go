@@ -0,0 +1,24 @@
+package di
+
+// Module provides the constructors of its components & registers
+// its routes or anything else needing the other modules
+type Module interface {
+	// Provide registers the providers, it must not resolve
+	Provide(c *Container)
+	// Register runs once every module is provided
+	Register(c *Container) error
+}
+
+// Install provides the modules in order then registers them, a module
+// may replace the providers of the ones before it, ex: a fake in a test
+func Install(c *Container, modules ...Module) error {
+	for _, m := range modules {
+		m.Provide(c)
+	}
+	for _, m := range modules {
+		if err := Invoke(c, m.Register); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -22,17 +22,41 @@ const (
 	SQLCodeUndefinedParam = "42P02"
 	// invalid_column_reference
 	SQLInvalidColumnReference = "42P10"
+	// unique_violation
+	SQLCodeUniqueViolation = "23505"
+	// foreign_key_violation
+	SQLCodeForeignKeyViolation = "23503"
+	// serialization_failure
+	SQLCodeSerializationFailure = "40001"
+	// deadlock_detected
+	SQLCodeDeadlockDetected = "40P01"
 )
 
+// ErrPreconditionFailed is returned when the version of a resource
+// does not match the If-Match of the request or it was modified concurrently
+var ErrPreconditionFailed = errors.New("the resource was modified, fetch it and retry")
+
+// ErrForbidden is returned when the principal may see
+// the resource but not act on it
+var ErrForbidden = errors.New("forbidden")
+
 func BuildDBError(err error) HTTPError {
 	httpErr := HTTPError{Code: http.StatusInternalServerError, Err: errors.New("internal server error")}
-	// check if it's an sql error
-	switch err {
-	case sql.ErrNoRows:
+	// check if it's an sql error, they may be wrapped
+	switch {
+	case errors.Is(err, ErrForbidden):
+		httpErr.Code = http.StatusForbidden
+		httpErr.Err = ErrForbidden
+		return httpErr
+	case errors.Is(err, sql.ErrNoRows):
 		httpErr.Code = http.StatusNotFound
 		httpErr.Err = errors.New("not found")
 		return httpErr
-	case sql.ErrTxDone:
+	case errors.Is(err, ErrPreconditionFailed):
+		httpErr.Code = http.StatusPreconditionFailed
+		httpErr.Err = ErrPreconditionFailed
+		return httpErr
+	case errors.Is(err, sql.ErrTxDone):
 		httpErr.Code = http.StatusNotFound
 		httpErr.Err = errors.New("transaction already closed")
 		return httpErr
@@ -82,6 +106,22 @@ func BuildDBError(err error) HTTPError {
 			httpErr.Code = http.StatusInternalServerError
 			httpErr.Err = errors.New("the expected resource is not available")
 			return httpErr
+		case SQLCodeUniqueViolation:
+			httpErr.MainErr = pgErr
+			httpErr.Code = http.StatusConflict
+			httpErr.Err = errors.New("the resource already exists")
+			return httpErr
+		case SQLCodeForeignKeyViolation:
+			httpErr.MainErr = pgErr
+			httpErr.Code = http.StatusConflict
+			httpErr.Err = errors.New("the resource is referenced by other resources")
+			return httpErr
+		case SQLCodeSerializationFailure, SQLCodeDeadlockDetected:
+			// retries are exhausted at this point
+			httpErr.MainErr = pgErr
+			httpErr.Code = http.StatusConflict
+			httpErr.Err = errors.New("the resource was modified concurrently, please retry")
+			return httpErr
 		}
 	}
 	return httpErr
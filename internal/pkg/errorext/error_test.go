@@ -0,0 +1,31 @@
+package errorext
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestBuildDBError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "no rows", err: sql.ErrNoRows, expected: http.StatusNotFound},
+		{name: "wrapped no rows", err: fmt.Errorf("read user: %w", sql.ErrNoRows), expected: http.StatusNotFound},
+		{name: "precondition", err: ErrPreconditionFailed, expected: http.StatusPreconditionFailed},
+		{name: "wrapped precondition", err: fmt.Errorf("update: %w", ErrPreconditionFailed), expected: http.StatusPreconditionFailed},
+		{name: "wrapped forbidden", err: fmt.Errorf("authorize: %w", ErrForbidden), expected: http.StatusForbidden},
+		{name: "other", err: errors.New("connection reset"), expected: http.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := BuildDBError(tc.err).Code; got != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// DefaultTimeout is the timeout of a check registered without one
+const DefaultTimeout = 2 * time.Second
+
+// Checker checks a dependency of the app, a nil error means it's healthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a func to a Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Duration  string `json:"duration"`
+	CheckedAt int64  `json:"checkedAt"`
+}
+
+// Report is the outcome of all the checks
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name    string
+	checker Checker
+	timeout time.Duration
+	// mu serializes the runs so concurrent probes share the result
+	mu     sync.Mutex
+	result Result
+	expiry time.Time
+}
+
+// Registry runs the registered checks, the results are cached for the
+// ttl so frequent probes don't hammer the dependencies
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []*check
+	ttl      time.Duration
+	draining atomic.Bool
+	now      func() time.Time
+}
+
+func NewRegistry(ttl time.Duration) *Registry {
+	r := new(Registry)
+	r.ttl = ttl
+	r.now = time.Now
+	return r
+}
+
+// Register adds the checker under the name, it panics if the
+// name is already registered, timeout <= 0 means DefaultTimeout
+func (r *Registry) Register(name string, timeout time.Duration, c Checker) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ch := range r.checks {
+		if ch.name == name {
+			panic(fmt.Sprintf("health: check %q registered twice", name))
+		}
+	}
+	r.checks = append(r.checks, &check{name: name, checker: c, timeout: timeout})
+}
+
+// Drain makes the app not ready, it's called on shutdown so the
+// load balancer stops sending requests before the server stops
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether Drain was called
+func (r *Registry) Draining() bool {
+	return r.draining.Load()
+}
+
+// Check runs the checks concurrently, the report is up only if all are up
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.checks
+	r.mu.RUnlock()
+	rep := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+	for i, c := range checks {
+		rep.Checks[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			rep.Status = StatusDown
+		}
+	}
+	return rep
+}
+
+func (r *Registry) run(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := r.now()
+	if now.Before(c.expiry) {
+		return c.result
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	err := c.checker.Check(ctx)
+	res := Result{Status: StatusUp, Duration: r.now().Sub(now).String(), CheckedAt: now.UnixMilli()}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	c.result = res
+	c.expiry = now.Add(r.ttl)
+	return res
+}
+
+// Liveness responds 200 as long as the process serves requests,
+// it doesn't check the dependencies so an outage of one
+// doesn't get the app restarted
+func (r *Registry) Liveness(w http.ResponseWriter, req *http.Request) {
+	response.Respond(http.StatusOK, map[string]string{"status": StatusUp}, w)
+}
+
+// Readiness responds 503 when draining or a check is down
+func (r *Registry) Readiness(w http.ResponseWriter, req *http.Request) {
+	if r.Draining() {
+		response.Respond(http.StatusServiceUnavailable, map[string]string{"status": StatusDown, "reason": "draining"}, w)
+		return
+	}
+	rep := r.Check(req.Context())
+	response.Respond(code(rep), map[string]string{"status": rep.Status}, w)
+}
+
+// Report responds the result of every check
+func (r *Registry) Report(w http.ResponseWriter, req *http.Request) {
+	rep := r.Check(req.Context())
+	if r.Draining() {
+		rep.Status = StatusDown
+	}
+	response.Respond(code(rep), rep, w)
+}
+
+func code(rep Report) int {
+	if rep.Status != StatusUp {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// DB checks the db is reachable
+func DB(db *sql.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+}
+
+// HTTP checks the url responds without a server error
+func HTTP(client *http.Client, url string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("unexpected status %d", res.StatusCode)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	now := time.Now()
+	r := NewRegistry(time.Second)
+	r.now = func() time.Time { return now }
+	calls := 0
+	var err error
+	r.Register("db", time.Second, CheckerFunc(func(ctx context.Context) error {
+		calls++
+		return err
+	}))
+	r.Register("slow", 10*time.Millisecond, CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	rep := r.Check(context.Background())
+	if rep.Status != StatusDown || rep.Checks["db"].Status != StatusUp || rep.Checks["slow"].Status != StatusDown {
+		t.Errorf("Expected '%v', but got '%v'", StatusDown, rep)
+	}
+	// cached until the ttl expires
+	err = errors.New("down")
+	r.Check(context.Background())
+	if calls != 1 {
+		t.Errorf("Expected '%v', but got '%v'", 1, calls)
+	}
+	now = now.Add(time.Second)
+	rep = r.Check(context.Background())
+	if calls != 2 || rep.Checks["db"].Error != "down" {
+		t.Errorf("Expected '%v', but got '%v'", "down", rep.Checks["db"])
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register("db", 0, CheckerFunc(func(ctx context.Context) error { return nil }))
+	tests := []struct {
+		name     string
+		drain    bool
+		expected int
+	}{
+		{name: "ready", expected: http.StatusOK},
+		{name: "draining", drain: true, expected: http.StatusServiceUnavailable},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.drain {
+				r.Drain()
+			}
+			w := httptest.NewRecorder()
+			r.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, w.Code)
+			}
+		})
+	}
+}
@@ -1,10 +1,22 @@
 package httpext
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/contextext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/metrics"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ClientProvider struct {
@@ -16,17 +28,112 @@ func NewClientProvider(timeout time.Duration, transport *http.Transport, checkRe
 	c.HTTPClient = &http.Client{
 		Timeout: timeout,
 	}
+	var base http.RoundTripper = http.DefaultTransport
 	if transport != nil {
-		c.HTTPClient.Transport = transport
+		base = transport
 	}
+	c.HTTPClient.Transport = RequestIDTransport{Base: TracingTransport{Base: MetricsTransport{Base: base}}}
 	if checkRedirectFunc != nil {
 		c.HTTPClient.CheckRedirect = checkRedirectFunc
 	}
 	return c
 }
 
-func (c *ClientProvider) Request(method string, url string, header http.Header, body io.Reader) (int, io.ReadCloser, error) {
-	req, err := http.NewRequest(method, url, body)
+// RequestIDTransport sets the X-Request-ID of the request ctx on the
+// outgoing requests so the calls to other services can be correlated
+type RequestIDTransport struct {
+	// Base is http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+func (t RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id, ok := contextext.RequestIDFrom(req.Context()); ok && req.Header.Get(constant.HeaderRequestID) == "" {
+		// a RoundTripper must not modify the request
+		req = req.Clone(req.Context())
+		req.Header.Set(constant.HeaderRequestID, id)
+	}
+	return base.RoundTrip(req)
+}
+
+var (
+	clientRequests = metrics.Default.NewCounterVec(
+		"http_client_requests_total",
+		"The number of outbound http requests by host, method & status, error if none.",
+		"host", "method", "status",
+	)
+	clientDuration = metrics.Default.NewHistogramVec(
+		"http_client_request_duration_seconds",
+		"The latency of the outbound http requests by host & method.",
+		nil,
+		"host", "method",
+	)
+)
+
+// MetricsTransport records the rate, errors & duration
+// of the outgoing requests per host
+type MetricsTransport struct {
+	// Base is http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+func (t MetricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	start := time.Now()
+	res, err := base.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(res.StatusCode)
+	}
+	clientRequests.Inc(req.URL.Host, req.Method, status)
+	clientDuration.Observe(time.Since(start).Seconds(), req.URL.Host, req.Method)
+	return res, err
+}
+
+// TracingTransport starts a client span per outgoing request
+// & propagates the trace context in its headers
+type TracingTransport struct {
+	// Base is http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+func (t TracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx, span := tracing.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			semconv.URLPath(req.URL.Path),
+		),
+	)
+	// a RoundTripper must not modify the request
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	res, err := base.RoundTrip(req)
+	if err == nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(res.StatusCode))
+		if res.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+		}
+	}
+	tracing.End(span, err)
+	return res, err
+}
+
+// Request sends the request in ctx, ex: the one of the incoming
+// request so the call is part of its trace
+func (c *ClientProvider) Request(method string, url string, header http.Header, body io.Reader, ctx context.Context) (int, io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return -1, nil, err
 	}
@@ -44,7 +151,7 @@ func (c *ClientProvider) Request(method string, url string, header http.Header,
 // resp, err := http.PostForm("http://example.com/form",
 // url.Values{"key": {"Value"}, "id": {"123"}})
 func (c *ClientProvider) PostForm(url string, header http.Header, values url.Values) (int, io.ReadCloser, error) {
-	res, err := http.PostForm(url, values)
+	res, err := c.HTTPClient.PostForm(url, values)
 	if err != nil {
 		return -1, nil, err
 	}
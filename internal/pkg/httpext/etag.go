@@ -0,0 +1,53 @@
+package httpext
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ETagList is the value of an If-Match or If-None-Match header
+type ETagList string
+
+func IfMatch(r *http.Request) ETagList {
+	return ETagList(r.Header.Get("If-Match"))
+}
+
+func IfNoneMatch(r *http.Request) ETagList {
+	return ETagList(r.Header.Get("If-None-Match"))
+}
+
+// Matches reports whether the list is "*" or has etag using the
+// strong comparison of If-Match, weak tags never match
+func (l ETagList) Matches(etag string) bool {
+	return l.match(etag, false)
+}
+
+// MatchesWeak reports whether the list is "*" or has etag
+// using the weak comparison of If-None-Match
+func (l ETagList) MatchesWeak(etag string) bool {
+	return l.match(etag, true)
+}
+
+func (l ETagList) match(etag string, weak bool) bool {
+	if l == "" || etag == "" {
+		return false
+	}
+	if strings.TrimSpace(string(l)) == "*" {
+		return true
+	}
+	if weak {
+		etag = strings.TrimPrefix(etag, "W/")
+	} else if strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	for _, t := range strings.Split(string(l), ",") {
+		t = strings.TrimSpace(t)
+		if weak {
+			t = strings.TrimPrefix(t, "W/")
+		}
+		if t == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package httpext
+
+import "testing"
+
+func TestETagList(t *testing.T) {
+	tests := []struct {
+		list   ETagList
+		etag   string
+		strong bool
+		weak   bool
+	}{
+		{"", `"1"`, false, false},
+		{"*", `"1"`, true, true},
+		{`"1"`, `"1"`, true, true},
+		{`"2", "1"`, `"1"`, true, true},
+		{`"2"`, `"1"`, false, false},
+		{`W/"1"`, `"1"`, false, true},
+		{`"1"`, `W/"1"`, false, true},
+	}
+	for _, tc := range tests {
+		if got := tc.list.Matches(tc.etag); got != tc.strong {
+			t.Errorf("Expected '%v', but got '%v' for %s %s", tc.strong, got, tc.list, tc.etag)
+		}
+		if got := tc.list.MatchesWeak(tc.etag); got != tc.weak {
+			t.Errorf("Expected '%v', but got '%v' for %s %s", tc.weak, got, tc.list, tc.etag)
+		}
+	}
+}
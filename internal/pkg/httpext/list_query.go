@@ -0,0 +1,298 @@
+package httpext
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+)
+
+// filter operators of the query grammar
+const (
+	OpEq    = "eq"
+	OpNe    = "ne"
+	OpGt    = "gt"
+	OpGte   = "gte"
+	OpLt    = "lt"
+	OpLte   = "lte"
+	OpLike  = "like"
+	OpILike = "ilike"
+	OpIn    = "in"
+)
+
+var (
+	stringOps  = []string{OpEq, OpNe, OpLike, OpILike, OpIn}
+	numberOps  = []string{OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn}
+	boolOps    = []string{OpEq, OpNe}
+	defaultOps = []string{OpEq, OpNe}
+)
+
+// SchemaField is a field which can be filtered, sorted & selected
+type SchemaField struct {
+	// Name is the json name used in the query string
+	Name string
+	// Column is the db column
+	Column string
+	Kind   reflect.Kind
+	// Ops are the filter operators, none when it can't be filtered
+	Ops []string
+	// Unsortable fields can't be sorted on, see Schema.Restrict
+	Unsortable bool
+}
+
+// Schema is the whitelist of the fields of an entity for list queries
+type Schema struct {
+	fields map[string]SchemaField
+}
+
+// NewSchema derives the schema from the struct v, every field with both
+// a db & a json tag is allowed, fields tagged with "-" in either are not
+// ex: NewSchema(entity.Content{})
+func NewSchema(v any) *Schema {
+	s := new(Schema)
+	s.fields = make(map[string]SchemaField)
+	s.collect(reflect.TypeOf(v))
+	return s
+}
+
+func (s *Schema) collect(t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			s.collect(f.Type)
+			continue
+		}
+		col, _, _ := strings.Cut(f.Tag.Get("db"), ",")
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || col == "" || col == "-" || name == "" || name == "-" {
+			continue
+		}
+		ft := f.Type
+		// nullable columns filter like their values
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		sf := SchemaField{Name: name, Column: col, Kind: ft.Kind()}
+		switch sf.Kind {
+		case reflect.String:
+			sf.Ops = stringOps
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			sf.Ops = numberOps
+		case reflect.Bool:
+			sf.Ops = boolOps
+		default:
+			// the value is passed as text and postgres casts it
+			sf.Ops = defaultOps
+		}
+		s.fields[name] = sf
+	}
+}
+
+// Restrict lets the fields of the json names be selected only, not
+// filtered nor sorted on, ex: the personal data which would otherwise
+// be enumerable by anyone listing, it returns s for chaining
+func (s *Schema) Restrict(names ...string) *Schema {
+	for _, name := range names {
+		if f, ok := s.fields[name]; ok {
+			f.Ops = nil
+			f.Unsortable = true
+			s.fields[name] = f
+		}
+	}
+	return s
+}
+
+// Field returns the field of the json name
+func (s *Schema) Field(name string) (SchemaField, bool) {
+	f, ok := s.fields[name]
+	return f, ok
+}
+
+func (f SchemaField) allows(op string) bool {
+	for _, o := range f.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// parse converts the raw value to the go type of the field
+func (f SchemaField) parse(raw string) (any, error) {
+	var (
+		v   any
+		err error
+	)
+	switch f.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err = strconv.ParseInt(raw, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err = strconv.ParseUint(raw, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		v, err = strconv.ParseFloat(raw, 64)
+	case reflect.Bool:
+		v, err = strconv.ParseBool(raw)
+	default:
+		v = raw
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q for field %q", raw, f.Name)
+	}
+	return v, nil
+}
+
+// Filter is a validated predicate, Values holds one
+// value per operand, more than one only for the in operator
+type Filter struct {
+	Field  SchemaField
+	Op     string
+	Values []any
+}
+
+// Sort is a validated sort field
+type Sort struct {
+	Field SchemaField
+	Desc  bool
+}
+
+// ListQuery is the parsed filter, sort & fields query of a list request
+type ListQuery struct {
+	Filters []Filter
+	Sort    []Sort
+	// Fields are the json names of the sparse fieldset
+	Fields  []string
+	columns []string
+}
+
+// ParseListQuery parses the list query grammar validated against the schema
+//
+//	filter[name]=foo             name = 'foo'
+//	filter[name][ilike]=%foo%    name ILIKE '%foo%'
+//	filter[createdAt][gte]=1700000000000
+//	filter[role][in]=admin,user
+//	sort=-createdAt,name         ORDER BY created_at DESC, name ASC
+//	fields=id,name
+func ParseListQuery(r *http.Request, s *Schema) (ListQuery, error) {
+	var q ListQuery
+	values := r.URL.Query()
+	var keys []string
+	for key := range values {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	// keep the generated sql stable
+	sort.Strings(keys)
+	for _, key := range keys {
+		vals := values[key]
+		name, op, err := parseFilterKey(key)
+		if err != nil {
+			return q, err
+		}
+		f, ok := s.Field(name)
+		if !ok || len(f.Ops) == 0 {
+			return q, fmt.Errorf("unknown filter field %q", name)
+		}
+		if !f.allows(op) {
+			return q, fmt.Errorf("operator %q is not allowed on field %q", op, name)
+		}
+		for _, raw := range vals {
+			operands := []string{raw}
+			if op == OpIn {
+				operands = strings.Split(raw, ",")
+			}
+			filter := Filter{Field: f, Op: op}
+			for _, o := range operands {
+				v, err := f.parse(o)
+				if err != nil {
+					return q, err
+				}
+				filter.Values = append(filter.Values, v)
+			}
+			q.Filters = append(q.Filters, filter)
+		}
+	}
+	if sorts := GetQueryParam(r, "sort"); sorts != "" {
+		for _, name := range strings.Split(sorts, ",") {
+			desc := strings.HasPrefix(name, "-")
+			name = strings.TrimPrefix(name, "-")
+			f, ok := s.Field(name)
+			if !ok || f.Unsortable {
+				return q, fmt.Errorf("unknown sort field %q", name)
+			}
+			q.Sort = append(q.Sort, Sort{Field: f, Desc: desc})
+		}
+	}
+	if fields := GetQueryParam(r, "fields"); fields != "" {
+		for _, name := range strings.Split(fields, ",") {
+			f, ok := s.Field(name)
+			if !ok {
+				return q, fmt.Errorf("unknown field %q", name)
+			}
+			q.Fields = append(q.Fields, name)
+			q.columns = append(q.columns, f.Column)
+		}
+	}
+	return q, nil
+}
+
+// parseFilterKey parses filter[name] or filter[name][op]
+func parseFilterKey(key string) (string, string, error) {
+	rest := strings.TrimPrefix(key, "filter[")
+	name, rest, ok := strings.Cut(rest, "]")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid filter %q", key)
+	}
+	if rest == "" {
+		return name, OpEq, nil
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") || len(rest) < 3 {
+		return "", "", fmt.Errorf("invalid filter %q", key)
+	}
+	return name, rest[1 : len(rest)-1], nil
+}
+
+// Options converts the query to parameterized predicates, order & columns
+func (q ListQuery) Options() postgres.ListOptions {
+	o := postgres.ListOptions{Columns: q.columns}
+	for _, f := range q.Filters {
+		o.Where = append(o.Where, f.expr())
+	}
+	for _, v := range q.Sort {
+		if v.Desc {
+			o.OrderBy = append(o.OrderBy, postgres.Desc(v.Field.Column))
+		} else {
+			o.OrderBy = append(o.OrderBy, postgres.Asc(v.Field.Column))
+		}
+	}
+	return o
+}
+
+func (f Filter) expr() postgres.Expr {
+	col := f.Field.Column
+	switch f.Op {
+	case OpNe:
+		return postgres.NotEq(col, f.Values[0])
+	case OpGt:
+		return postgres.Gt(col, f.Values[0])
+	case OpGte:
+		return postgres.Gte(col, f.Values[0])
+	case OpLt:
+		return postgres.Lt(col, f.Values[0])
+	case OpLte:
+		return postgres.Lte(col, f.Values[0])
+	case OpLike:
+		return postgres.Like(col, f.Values[0])
+	case OpILike:
+		return postgres.ILike(col, f.Values[0])
+	case OpIn:
+		return postgres.In(col, f.Values...)
+	}
+	return postgres.Eq(col, f.Values[0])
+}
@@ -0,0 +1,94 @@
+package httpext
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+)
+
+type listEntity struct {
+	ID        string `db:"id" json:"id"`
+	Name      string `db:"name" json:"name"`
+	Secret    string `db:"secret" json:"-"`
+	Internal  string `json:"internal"`
+	Email     string `db:"email" json:"email"`
+	CreatedAt int64  `db:"created_at" json:"createdAt"`
+}
+
+func TestParseListQuery(t *testing.T) {
+	s := NewSchema(listEntity{}).Restrict("email")
+	tests := []struct {
+		name    string
+		query   url.Values
+		sql     string
+		args    []any
+		fields  []string
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			query: url.Values{},
+			sql:   `SELECT * FROM "t"`,
+		},
+		{
+			name: "filters sort fields",
+			query: url.Values{
+				"filter[name][ilike]":    {"%foo%"},
+				"filter[createdAt][gte]": {"10"},
+				"filter[id][in]":         {"a,b"},
+				"sort":                   {"-createdAt,name"},
+				"fields":                 {"id,name"},
+			},
+			sql:    `SELECT "id", "created_at", "name" FROM "t" WHERE "created_at" >= $1 AND "id" IN ($2, $3) AND "name" ILIKE $4 ORDER BY "created_at" DESC, "name" ASC`,
+			args:   []any{int64(10), "a", "b", "%foo%"},
+			fields: []string{"id", "name"},
+		},
+		{
+			name:  "default eq",
+			query: url.Values{"filter[name]": {"x'; DROP TABLE t; --"}},
+			sql:   `SELECT * FROM "t" WHERE "name" = $1`,
+			args:  []any{"x'; DROP TABLE t; --"},
+		},
+		{name: "unknown field", query: url.Values{"filter[secret]": {"x"}}, wantErr: true},
+		{name: "untagged field", query: url.Values{"sort": {"internal"}}, wantErr: true},
+		{name: "operator not allowed", query: url.Values{"filter[name][gt]": {"x"}}, wantErr: true},
+		{name: "unknown operator", query: url.Values{"filter[name][drop]": {"x"}}, wantErr: true},
+		{name: "invalid value", query: url.Values{"filter[createdAt][lt]": {"abc"}}, wantErr: true},
+		{name: "malformed key", query: url.Values{"filter[name": {"x"}}, wantErr: true},
+		{name: "unknown fields", query: url.Values{"fields": {"id,secret"}}, wantErr: true},
+		{name: "restricted filter", query: url.Values{"filter[email]": {"a@b.c"}}, wantErr: true},
+		{name: "restricted sort", query: url.Values{"sort": {"email"}}, wantErr: true},
+		{
+			name:   "restricted field",
+			query:  url.Values{"fields": {"id,email"}},
+			sql:    `SELECT "id", "created_at", "email" FROM "t"`,
+			fields: []string{"id", "email"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/t?"+tc.query.Encode(), nil)
+			q, err := ParseListQuery(r, s)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected '%v', but got '%v'", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			o := q.Options()
+			sql, args := postgres.Select(o.Projection(nil)...).From("t").Where(o.Where...).OrderBy(o.OrderBy...).Build()
+			if sql != tc.sql {
+				t.Errorf("Expected '%v', but got '%v'", tc.sql, sql)
+			}
+			if len(args) != len(tc.args) || (len(args) > 0 && !reflect.DeepEqual(args, tc.args)) {
+				t.Errorf("Expected '%v', but got '%v'", tc.args, args)
+			}
+			if !reflect.DeepEqual(q.Fields, tc.fields) {
+				t.Errorf("Expected '%v', but got '%v'", tc.fields, q.Fields)
+			}
+		})
+	}
+}
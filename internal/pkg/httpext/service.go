@@ -1,14 +1,15 @@
 package httpext
 
 import (
+	"context"
 	"io"
 	"net/http"
 
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jsonext"
 )
 
-func Request[T any](method string, url string, header http.Header, body io.Reader, client *ClientProvider) (*T, map[string]any, error) {
-	code, resBody, err := client.Request(method, url, header, body)
+func Request[T any](method string, url string, header http.Header, body io.Reader, client *ClientProvider, ctx context.Context) (*T, map[string]any, error) {
+	code, resBody, err := client.Request(method, url, header, body, ctx)
 	if err != nil {
 		return nil, nil, err
 	}
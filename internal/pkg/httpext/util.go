@@ -2,9 +2,11 @@ package httpext
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi"
@@ -19,6 +21,19 @@ func GetQueryParam(r *http.Request, key string) string {
 	return r.URL.Query().Get(key)
 }
 
+// GetQueryFlag parses a boolean query param, false if absent
+func GetQueryFlag(r *http.Request, key string) (bool, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", key)
+	}
+	return b, nil
+}
+
 func ParseAuthToken(r *http.Request) ([]string, error) {
 	tkHeader := r.Header.Get("Authorization")
 	if tkHeader == "" {
@@ -1,8 +1,9 @@
 package jwk
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/MicahParks/keyfunc/v2"
@@ -24,18 +25,27 @@ func ParseClaims(token *jwt.Token) jwt.MapClaims {
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
 		return claims
 	} else {
-		log.Printf("Invalid JWT Token")
+		slog.Warn("invalid jwt claims")
 		return nil
 	}
 }
 
-func CreateJWKS(jwksURL string, refreshTimeout time.Duration, errHandler keyfunc.ErrorHandler) (*keyfunc.JWKS, error) {
+// CreateJWKS fetches the jwks, it is refreshed in the background every
+// refreshInterval until ctx is done, and at most every refreshInterval/10
+// on an unknown kid, 0 disables the background refresh
+func CreateJWKS(ctx context.Context, jwksURL string, refreshInterval, refreshTimeout time.Duration, errHandler keyfunc.ErrorHandler) (*keyfunc.JWKS, error) {
 	// Create the keyfunc options. Use an error handler that logs. Timeout the initial JWKS refresh request after 10
 	// seconds. This timeout is also used to create the initial context.Context for keyfunc.Get.
 	options := keyfunc.Options{
+		Ctx:                 ctx,
 		RefreshTimeout:      refreshTimeout,
 		RefreshErrorHandler: errHandler,
 	}
+	if refreshInterval > 0 {
+		options.RefreshInterval = refreshInterval
+		options.RefreshRateLimit = refreshInterval / 10
+		options.RefreshUnknownKID = true
+	}
 	// Create the JWKS from the resource at the given URL.
 	return keyfunc.Get(jwksURL, options)
 }
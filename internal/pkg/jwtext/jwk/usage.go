@@ -1,15 +1,17 @@
 package jwk
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
 )
 
 func Usage() error {
-	jwksURL := fmt.Sprintf("%s/.well-known/jwks", config.GetEnvValue("jwkDomain"))
-	jwks, err := CreateJWKS(jwksURL, 24*time.Hour, nil)
+	jwksURL := fmt.Sprintf("%s/.well-known/jwks.json", config.GetEnvValue("jwkDomain"))
+	jwks, err := CreateJWKS(context.Background(), jwksURL, time.Hour, 10*time.Second, nil)
 	if err != nil {
 		return err
 	}
@@ -18,14 +20,14 @@ func Usage() error {
 	if err != nil {
 		return err
 	}
-	fmt.Println("jwtToken: ", *jwtToken)
+	slog.Debug("parsed jwt", "token", jwtToken.Raw)
 	// extract the claims
 	c := ParseClaims(jwtToken)
 	if c == nil {
 		// handle error
 	}
-	fmt.Println("claims: ", c)
+	slog.Debug("jwt claims", "claims", c)
 	id := c["userId"].(string)
-	fmt.Println("id: ", id)
+	slog.Debug("jwt user", "id", id)
 	return nil
 }
@@ -0,0 +1,116 @@
+package jwtext
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JWK is the public part of a key as in RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC & OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is the key set served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK converts the public key to a JWK without kid, use & alg
+func PublicJWK(public crypto.PublicKey) (JWK, error) {
+	switch p := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   encodeBase64(p.N.Bytes()),
+			E:   encodeBase64(big.NewInt(int64(p.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		// the coordinates are padded to the size of the curve
+		size := (p.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Crv: p.Curve.Params().Name,
+			X:   encodeBase64(p.X.FillBytes(make([]byte, size))),
+			Y:   encodeBase64(p.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: encodeBase64(p)}, nil
+	}
+	return JWK{}, fmt.Errorf("jwtext: unsupported public key type %T", public)
+}
+
+// Thumbprint is the RFC 7638 SHA-256 thumbprint of the public key
+func Thumbprint(public crypto.PublicKey) (string, error) {
+	j, err := PublicJWK(public)
+	if err != nil {
+		return "", err
+	}
+	// only the required members in lexicographic order
+	var members any
+	switch j.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Crv, j.Kty, j.X, j.Y}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	}
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return encodeBase64(h[:]), nil
+}
+
+// JWKS returns the published keys, including the ones not
+// signing yet & the retired ones in their grace period
+func (s *KeySet) JWKS() JWKS {
+	d := JWKS{Keys: []JWK{}}
+	for _, k := range s.Keys() {
+		j, err := PublicJWK(k.Public())
+		if err != nil {
+			// NewKey only accepts the supported types
+			continue
+		}
+		j.Kid = k.ID
+		j.Use = "sig"
+		j.Alg = k.Method.Alg()
+		d.Keys = append(d.Keys, j)
+	}
+	return d
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -2,15 +2,131 @@ package jwtext
 
 import (
 	"errors"
-	"log"
+	"log/slog"
+	"sync/atomic"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
 	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
 )
 
-// Create the JWT key used to create the signature
-var JwtKey = []byte(config.GetEnvValue("secret"))
+// Signer signs the tokens issued here & verifies them, with the
+// asymmetric keys of its key set when set or HS256 with its secret,
+// the app owns one so several apps don't share their keys
+type Signer struct {
+	// Issuer is the iss claim of the issued tokens
+	Issuer string
+	// Audience is the aud claim of the issued tokens, omitted when empty
+	Audience string
+	secret   atomic.Pointer[[]byte]
+	keys     atomic.Pointer[KeySet]
+}
+
+func NewSigner(issuer, audience string) *Signer {
+	s := new(Signer)
+	s.Issuer = issuer
+	s.Audience = audience
+	return s
+}
+
+// Default is the signer of the package funcs, ex: NewToken, it has
+// no secret until UseSecret, the app signs with a signer of its own
+var Default = NewSigner("stdlib-go-template", "")
+
+// UseSecret sets the HS256 key, it may be called again
+// to rotate the key which invalidates the tokens
+func (s *Signer) UseSecret(secret string) {
+	b := []byte(secret)
+	s.secret.Store(&b)
+}
+
+// errNoSecret is returned rather than signing with an empty key
+var errNoSecret = errors.New("jwtext: no secret, see UseSecret")
+
+// hmacKey is the HS256 key, read on use so it can rotate
+func (s *Signer) hmacKey() ([]byte, error) {
+	if b := s.secret.Load(); b != nil && len(*b) > 0 {
+		return *b, nil
+	}
+	return nil, errNoSecret
+}
+
+// UseKeySet switches the tokens to the asymmetric keys of the set,
+// the HS256 tokens are not accepted anymore
+func (s *Signer) UseKeySet(ks *KeySet) {
+	s.keys.Store(ks)
+}
+
+// KeySet returns the key set in use, nil for HS256
+func (s *Signer) KeySet() *KeySet {
+	return s.keys.Load()
+}
+
+// JWKS returns the public keys of the key set, none for HS256
+func (s *Signer) JWKS() JWKS {
+	if ks := s.KeySet(); ks != nil {
+		return ks.JWKS()
+	}
+	return JWKS{Keys: []JWK{}}
+}
+
+// sign signs the claims with the active key of the set or HS256
+func (s *Signer) sign(claims jwt.Claims) (string, error) {
+	ks := s.KeySet()
+	if ks == nil {
+		key, err := s.hmacKey()
+		if err != nil {
+			return "", err
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	}
+	k, err := ks.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(k.Method, claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.Private)
+}
+
+// VerificationKeys returns the keyfunc & the accepted algs of the tokens issued here
+func (s *Signer) VerificationKeys() (jwt.Keyfunc, []string) {
+	ks := s.KeySet()
+	if ks == nil {
+		return func(t *jwt.Token) (any, error) { return s.hmacKey() }, []string{jwt.SigningMethodHS256.Alg()}
+	}
+	algs := []string{
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodES256.Alg(),
+		jwt.SigningMethodES384.Alg(),
+		jwt.SigningMethodES512.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}
+	return ks.Keyfunc, algs
+}
+
+// UseSecret sets the HS256 key of Default
+func UseSecret(secret string) {
+	Default.UseSecret(secret)
+}
+
+// UseKeySet sets the key set of Default
+func UseKeySet(ks *KeySet) {
+	Default.UseKeySet(ks)
+}
+
+// CurrentKeySet returns the key set of Default, nil for HS256
+func CurrentKeySet() *KeySet {
+	return Default.KeySet()
+}
+
+// VerificationKeys returns the keyfunc & the accepted algs of Default
+func VerificationKeys() (jwt.Keyfunc, []string) {
+	return Default.VerificationKeys()
+}
+
+func sign(claims jwt.Claims) (string, error) {
+	return Default.sign(claims)
+}
 
 // GenerateToken generates a new token
 func GenerateToken(payload map[string]any) string {
@@ -29,21 +145,13 @@ func GenerateToken(payload map[string]any) string {
 	claims["exp"] = jwt.NewNumericDate(timeext.AddDate(0, 0, 3))
 	claims["authorized"] = true
 	claims["id"] = payload["id"]
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, _ := token.SignedString(JwtKey)
+	tokenString, _ := sign(claims)
 	return tokenString
 }
 
 func Parse(tokenBody string) (*jwt.Token, error) {
-	token, err := jwt.Parse(
-		tokenBody,
-		func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return JwtKey, nil
-		},
-	)
+	kf, algs := VerificationKeys()
+	token, err := jwt.Parse(tokenBody, kf, jwt.WithValidMethods(algs))
 	if err != nil {
 		return nil, errors.New("malformed token")
 	}
@@ -57,7 +165,7 @@ func ParseClaims(token *jwt.Token) jwt.MapClaims {
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
 		return claims
 	} else {
-		log.Printf("Invalid JWT Token")
+		slog.Warn("invalid jwt claims")
 		return nil
 	}
 }
@@ -2,15 +2,13 @@ package jwtext
 
 import (
 	"errors"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
+	"github.com/google/uuid"
 	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
 )
 
-// Create the JWT key used to create the signature
-var JwtKey1 = []byte(config.GetEnvValue("secret"))
-
 // jwt.RegisteredClaims is an embedded type
 type Payload struct {
 	Id string `json:"id"`
@@ -23,6 +21,8 @@ type Claims struct {
 }
 
 // GenerateToken generates a new token
+//
+// Deprecated: the token can't be revoked, use NewToken
 func GenerateToken1(payload Payload) string {
 	/* RegisteredClaims: jwt.RegisteredClaims{
 		ExpiresAt: jwt.NewNumericDate(expirationTime),
@@ -39,23 +39,43 @@ func GenerateToken1(payload Payload) string {
 		Payload: payload,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			Issuer:    "test",
+			Issuer:    Default.Issuer,
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, _ := token.SignedString(JwtKey)
+	tokenString, _ := sign(claims)
 	return tokenString
 }
 
+// NewToken signs a token of the payload valid for ttl with Default
+func NewToken(payload Payload, ttl time.Duration) (string, *Claims, error) {
+	return Default.NewToken(payload, ttl)
+}
+
+// NewToken signs a token of the payload valid for ttl, the returned
+// claims carry the jti & the expiry needed to revoke it
+func (s *Signer) NewToken(payload Payload, ttl time.Duration) (string, *Claims, error) {
+	now := time.Now()
+	claims := &Claims{
+		Payload: payload,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   payload.Id,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			Issuer:    s.Issuer,
+		},
+	}
+	if s.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.Audience}
+	}
+	t, err := s.sign(claims)
+	return t, claims, err
+}
+
 func VerifyToken1(tokenBody string) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(
-		tokenBody,
-		claims,
-		func(token *jwt.Token) (interface{}, error) {
-			return JwtKey, nil
-		},
-	)
+	kf, algs := VerificationKeys()
+	token, err := jwt.ParseWithClaims(tokenBody, claims, kf, jwt.WithValidMethods(algs))
 	if err != nil {
 		return nil, errors.New("malformed token")
 	}
@@ -0,0 +1,39 @@
+package jwtext
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewToken(t *testing.T) {
+	UseSecret("secret")
+	if _, _, err := NewSigner("iss", "").NewToken(Payload{Id: "1"}, time.Minute); err != errNoSecret {
+		t.Errorf("Expected '%v', but got '%v'", errNoSecret, err)
+	}
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wantErr bool
+	}{
+		{name: "valid", ttl: time.Minute},
+		{name: "expired", ttl: -time.Minute, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			token, issued, err := NewToken(Payload{Id: "1"}, tc.ttl)
+			if err != nil {
+				t.Fatalf("Expected no error, but got '%v'", err)
+			}
+			claims, err := VerifyToken1(token)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Expected error '%v', but got '%v'", tc.wantErr, err)
+			}
+			if err == nil && (claims.Payload.Id != "1" || claims.Subject != "1") {
+				t.Errorf("Expected '%v', but got '%v'", "1", claims.Payload.Id)
+			}
+			if err == nil && (claims.ID == "" || claims.ID != issued.ID) {
+				t.Errorf("Expected '%v', but got '%v'", issued.ID, claims.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package jwtext
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
+)
+
+var (
+	ErrNoSigningKey = errors.New("jwtext: no active signing key")
+	ErrUnknownKey   = errors.New("jwtext: unknown key id")
+)
+
+// Key is an asymmetric signing key identified by its kid
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	// ActiveFrom is when it starts signing, it is published
+	// before so the verifiers can fetch it in advance
+	ActiveFrom time.Time
+	// RetiredAt is when it stops signing, ahead when its file was
+	// replaced, it keeps verifying for the grace period after
+	RetiredAt time.Time
+}
+
+// Public returns the public key
+func (k *Key) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+// NewKey derives the signing method & the kid of the private key, the
+// method is RS256 for RSA, ES256/384/512 by the curve & EdDSA for Ed25519
+// the kid is the RFC 7638 thumbprint of the public key
+func NewKey(private crypto.Signer) (*Key, error) {
+	k := &Key{Private: private}
+	switch p := private.(type) {
+	case *rsa.PrivateKey:
+		k.Method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		switch p.Curve {
+		case elliptic.P256():
+			k.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			k.Method = jwt.SigningMethodES384
+		case elliptic.P521():
+			k.Method = jwt.SigningMethodES512
+		default:
+			return nil, fmt.Errorf("jwtext: unsupported curve %s", p.Curve.Params().Name)
+		}
+	case ed25519.PrivateKey:
+		k.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("jwtext: unsupported key type %T", private)
+	}
+	id, err := Thumbprint(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	k.ID = id
+	return k, nil
+}
+
+// ParseKeyPEM parses a PKCS #8, PKCS #1 (RSA) or SEC 1 (EC) private key
+func ParseKeyPEM(b []byte) (*Key, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("jwtext: no pem block found")
+	}
+	var (
+		private any
+		err     error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jwtext: unsupported pem block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("jwtext: unsupported key type %T", private)
+	}
+	return NewKey(signer)
+}
+
+// KeySet holds the signing keys, the newest active key signs
+// and every published key verifies, ex:
+//
+//	ks := NewKeySet(time.Hour, 10*time.Minute)
+//	err := ks.Load("/etc/app/keys")
+//	go ks.Watch(ctx, "/etc/app/keys", time.Minute)
+type KeySet struct {
+	mu   sync.RWMutex
+	keys map[string]*Key
+	// files maps the loaded files to their kid
+	files map[string]string
+	// grace is how long a retired key keeps verifying,
+	// it should be longer than the access token ttl
+	grace time.Duration
+	// delay is how long a new key is published before it signs
+	delay  time.Duration
+	loaded bool
+	now    func() time.Time
+}
+
+func NewKeySet(grace, delay time.Duration) *KeySet {
+	s := new(KeySet)
+	s.keys = make(map[string]*Key)
+	s.files = make(map[string]string)
+	s.grace = grace
+	s.delay = delay
+	s.now = time.Now
+	return s
+}
+
+// Add adds the key, it starts signing after the delay
+// unless its ActiveFrom is already set
+func (s *KeySet) Add(k *Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(k, s.delay)
+}
+
+func (s *KeySet) add(k *Key, delay time.Duration) {
+	if _, ok := s.keys[k.ID]; ok {
+		return
+	}
+	if k.ActiveFrom.IsZero() {
+		k.ActiveFrom = s.now().Add(delay)
+	}
+	s.keys[k.ID] = k
+}
+
+// Retire stops the key from signing, it
+// keeps verifying for the grace period
+func (s *KeySet) Retire(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k, ok := s.keys[kid]; ok && k.RetiredAt.IsZero() {
+		k.RetiredAt = s.now()
+	}
+}
+
+// SigningKey returns the most recently activated key
+func (s *KeySet) SigningKey() (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := s.now()
+	var cur *Key
+	for _, k := range s.keys {
+		if (!k.RetiredAt.IsZero() && !k.RetiredAt.After(now)) || k.ActiveFrom.After(now) {
+			continue
+		}
+		if cur == nil || k.ActiveFrom.After(cur.ActiveFrom) {
+			cur = k
+		}
+	}
+	if cur == nil {
+		return nil, ErrNoSigningKey
+	}
+	return cur, nil
+}
+
+// Keys returns the published keys sorted by kid, the retired
+// keys are dropped once their grace period is over
+func (s *KeySet) Keys() []*Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	d := make([]*Key, 0, len(s.keys))
+	for id, k := range s.keys {
+		if !k.RetiredAt.IsZero() && now.Sub(k.RetiredAt) > s.grace {
+			delete(s.keys, id)
+			continue
+		}
+		d = append(d, k)
+	}
+	sort.Slice(d, func(i, j int) bool { return d[i].ID < d[j].ID })
+	return d
+}
+
+// Key returns the published key of the kid
+func (s *KeySet) Key(kid string) (*Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[kid]
+	if !ok || (!k.RetiredAt.IsZero() && s.now().Sub(k.RetiredAt) > s.grace) {
+		return nil, false
+	}
+	return k, true
+}
+
+// Keyfunc resolves the public key of the token by its kid header,
+// the alg of the token has to be the one of the key
+func (s *KeySet) Keyfunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	k, ok := s.Key(kid)
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if t.Method.Alg() != k.Method.Alg() {
+		return nil, fmt.Errorf("jwtext: unexpected signing method %s", t.Method.Alg())
+	}
+	return k.Public(), nil
+}
+
+// Load loads the private key pem file or every *.pem file of the
+// directory, the keys of the previously loaded files which are gone
+// or hold another key are retired once the new keys sign, the keys of
+// the first load sign right away
+func (s *KeySet) Load(path string) error {
+	files, err := keyFiles(path)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]*Key, len(files))
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		k, err := ParseKeyPEM(b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+		keys[f] = k
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("jwtext: no keys found in %s", path)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delay := s.delay
+	if !s.loaded {
+		delay = 0
+	}
+	for f, k := range keys {
+		s.add(k, delay)
+		// a file replaced in place, ex: a kubernetes secret mount, retires
+		// its old key once the new one signs so there's always a signer
+		if id, ok := s.files[f]; ok && id != k.ID {
+			if old, ok := s.keys[id]; ok && old.RetiredAt.IsZero() {
+				old.RetiredAt = s.keys[k.ID].ActiveFrom
+			}
+		}
+		s.files[f] = k.ID
+	}
+	// a removed file keeps signing until the newest pending key is
+	// active, so swapping the files in one reload leaves a signer
+	retireAt := s.now()
+	for _, k := range s.keys {
+		if k.RetiredAt.IsZero() && k.ActiveFrom.After(retireAt) {
+			retireAt = k.ActiveFrom
+		}
+	}
+	for f, id := range s.files {
+		if _, ok := keys[f]; ok {
+			continue
+		}
+		if k, ok := s.keys[id]; ok && k.RetiredAt.IsZero() {
+			k.RetiredAt = retireAt
+		}
+		delete(s.files, f)
+	}
+	s.loaded = true
+	return nil
+}
+
+func keyFiles(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".pem") {
+			continue
+		}
+		files = append(files, filepath.Join(path, e.Name()))
+	}
+	return files, nil
+}
+
+// Watch reloads the path every interval until ctx is done, so a
+// key is rotated by adding the new file and later removing the old
+func (s *KeySet) Watch(ctx context.Context, path string, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.Load(path); err != nil {
+				slog.Error("reload jwt keys failed", logext.Err(err))
+			}
+		}
+	}
+}
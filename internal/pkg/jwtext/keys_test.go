@@ -0,0 +1,218 @@
+package jwtext
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeKey(t *testing.T, dir, name string, k crypto.Signer) {
+	t.Helper()
+	b, err := x509.MarshalPKCS8PrivateKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b})
+	if err := os.WriteFile(filepath.Join(dir, name), p, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeySetSignVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	tests := []struct {
+		name     string
+		key      crypto.Signer
+		expected string
+	}{
+		{name: "rsa", key: rsaKey, expected: "RS256"},
+		{name: "ecdsa", key: ecKey, expected: "ES256"},
+		{name: "ed25519", key: edKey, expected: "EdDSA"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeKey(t, dir, "key.pem", tc.key)
+			ks := NewKeySet(time.Hour, time.Minute)
+			if err := ks.Load(dir); err != nil {
+				t.Fatal(err)
+			}
+			UseKeySet(ks)
+			defer UseKeySet(nil)
+			token, _, err := NewToken(Payload{Id: "1"}, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := VerifyToken1(token)
+			if err != nil || claims.Payload.Id != "1" {
+				t.Errorf("Expected '%v', but got '%v'", "1", err)
+			}
+			// other services verify with the published set
+			b, _ := json.Marshal(ks.JWKS())
+			jwks, err := keyfunc.NewJSON(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := jwt.Parse(token, jwks.Keyfunc)
+			if err != nil {
+				t.Fatalf("Expected no error, but got '%v'", err)
+			}
+			if parsed.Method.Alg() != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, parsed.Method.Alg())
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeKey(t, dir, "old.pem", oldKey)
+	now := time.Now()
+	ks := NewKeySet(time.Hour, 10*time.Minute)
+	ks.now = func() time.Time { return now }
+	if err := ks.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	oldID, _ := Thumbprint(oldKey.Public())
+	newID, _ := Thumbprint(newKey.Public())
+	// the new key is published but doesn't sign before the delay
+	writeKey(t, dir, "new.pem", newKey)
+	if err := ks.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		name      string
+		after     time.Duration
+		removeOld bool
+		signing   string
+		published int
+	}{
+		{name: "new published", signing: oldID, published: 2},
+		{name: "new active", after: 11 * time.Minute, signing: newID, published: 2},
+		{name: "old in grace", after: 12 * time.Minute, removeOld: true, signing: newID, published: 2},
+		{name: "old dropped", after: 73 * time.Minute, signing: newID, published: 1},
+	}
+	for _, st := range steps {
+		ks.now = func() time.Time { return now.Add(st.after) }
+		if st.removeOld {
+			_ = os.Remove(filepath.Join(dir, "old.pem"))
+			if err := ks.Load(dir); err != nil {
+				t.Fatal(err)
+			}
+		}
+		k, err := ks.SigningKey()
+		if err != nil || k.ID != st.signing {
+			t.Errorf("%s: Expected '%v', but got '%v'", st.name, st.signing, k)
+		}
+		if n := len(ks.JWKS().Keys); n != st.published {
+			t.Errorf("%s: Expected '%v', but got '%v'", st.name, st.published, n)
+		}
+	}
+}
+
+func TestKeySetRotationInPlace(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeKey(t, dir, "key.pem", oldKey)
+	now := time.Now()
+	ks := NewKeySet(time.Hour, 10*time.Minute)
+	ks.now = func() time.Time { return now }
+	if err := ks.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	oldID, _ := Thumbprint(oldKey.Public())
+	newID, _ := Thumbprint(newKey.Public())
+	// the same file now holds the new key
+	writeKey(t, dir, "key.pem", newKey)
+	if err := ks.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		name      string
+		after     time.Duration
+		signing   string
+		published int
+		verifies  bool
+	}{
+		{name: "old signs until the new is active", signing: oldID, published: 2, verifies: true},
+		{name: "new active, old in grace", after: 11 * time.Minute, signing: newID, published: 2, verifies: true},
+		{name: "old dropped", after: 71 * time.Minute, signing: newID, published: 1},
+	}
+	for _, st := range steps {
+		ks.now = func() time.Time { return now.Add(st.after) }
+		k, err := ks.SigningKey()
+		if err != nil || k.ID != st.signing {
+			t.Errorf("%s: Expected '%v', but got '%v'", st.name, st.signing, k)
+		}
+		if n := len(ks.JWKS().Keys); n != st.published {
+			t.Errorf("%s: Expected '%v', but got '%v'", st.name, st.published, n)
+		}
+		if _, ok := ks.Key(oldID); ok != st.verifies {
+			t.Errorf("%s: Expected '%v', but got '%v'", st.name, st.verifies, ok)
+		}
+	}
+}
+
+func TestKeySetRotationSwap(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeKey(t, dir, "old.pem", oldKey)
+	now := time.Now()
+	ks := NewKeySet(time.Hour, 10*time.Minute)
+	ks.now = func() time.Time { return now }
+	if err := ks.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	oldID, _ := Thumbprint(oldKey.Public())
+	newID, _ := Thumbprint(newKey.Public())
+	// one reload sees the old file gone & the new one added
+	_ = os.Remove(filepath.Join(dir, "old.pem"))
+	writeKey(t, dir, "new.pem", newKey)
+	if err := ks.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		name      string
+		after     time.Duration
+		signing   string
+		published int
+		verifies  bool
+	}{
+		{name: "old signs until the new is active", signing: oldID, published: 2, verifies: true},
+		{name: "old still signs", after: 9 * time.Minute, signing: oldID, published: 2, verifies: true},
+		{name: "new active, old in grace", after: 11 * time.Minute, signing: newID, published: 2, verifies: true},
+		{name: "old dropped", after: 71 * time.Minute, signing: newID, published: 1},
+	}
+	for _, st := range steps {
+		ks.now = func() time.Time { return now.Add(st.after) }
+		k, err := ks.SigningKey()
+		if err != nil || k.ID != st.signing {
+			t.Errorf("%s: Expected '%v', but got '%v' '%v'", st.name, st.signing, k, err)
+		}
+		if n := len(ks.JWKS().Keys); n != st.published {
+			t.Errorf("%s: Expected '%v', but got '%v'", st.name, st.published, n)
+		}
+		if _, ok := ks.Key(oldID); ok != st.verifies {
+			t.Errorf("%s: Expected '%v', but got '%v'", st.name, st.verifies, ok)
+		}
+	}
+}
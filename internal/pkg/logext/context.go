@@ -0,0 +1,45 @@
+package logext
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type loggerKey struct{}
+
+// holder lets the middlewares down the chain add attrs which
+// the middlewares up the chain see too, ex: the user
+type holder struct {
+	mu sync.RWMutex
+	l  *slog.Logger
+}
+
+// WithLogger returns a copy of ctx carrying the logger
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, &holder{l: l})
+}
+
+// FromContext returns the logger of ctx, the default one if none
+func FromContext(ctx context.Context) *slog.Logger {
+	h, ok := ctx.Value(loggerKey{}).(*holder)
+	if !ok {
+		return slog.Default()
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.l
+}
+
+// AddAttrs adds the attrs to the logger of ctx in place, so every
+// holder of ctx logs them from then on, it is a no-op without a logger
+// ex: logext.AddAttrs(r.Context(), "user_id", p.Subject)
+func AddAttrs(ctx context.Context, args ...any) {
+	h, ok := ctx.Value(loggerKey{}).(*holder)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.l = h.l.With(args...)
+}
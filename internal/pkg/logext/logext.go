@@ -0,0 +1,55 @@
+package logext
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New builds a logger writing to w in the format, json or text,
+// at the level, debug, info, warn or error
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var l slog.Level
+	if level != "" {
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q", level)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: l}
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q", format)
+}
+
+// Init sets the default logger to a stderr one, the log
+// package writes through it too
+func Init(format, level string) error {
+	l, err := New(os.Stderr, format, level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(l)
+	return nil
+}
+
+// Fatal logs the msg at error level and exits
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// Err is the attr of an error
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
@@ -0,0 +1,37 @@
+package logext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestContextLogger(t *testing.T) {
+	var b bytes.Buffer
+	l, err := New(&b, FormatJSON, "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithLogger(context.Background(), l.With("request_id", "1"))
+	// added down the chain, logged by the holders of the parent ctx too
+	AddAttrs(context.WithValue(ctx, struct{}{}, nil), "user_id", "2")
+	FromContext(ctx).Info("request")
+	FromContext(ctx).Debug("skipped")
+	var m map[string]any
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["request_id"] != "1" || m["user_id"] != "2" || m["msg"] != "request" {
+		t.Errorf("Expected '%v', but got '%v'", "request_id & user_id", m)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", ""); err == nil {
+		t.Errorf("Expected '%v', but got '%v'", "error", err)
+	}
+	if _, err := New(&bytes.Buffer{}, FormatText, "verbose"); err == nil {
+		t.Errorf("Expected '%v', but got '%v'", "error", err)
+	}
+}
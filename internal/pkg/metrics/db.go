@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// dbStats exposes the sql.DBStats of the registered pools
+type dbStats struct {
+	mu  sync.Mutex
+	dbs map[string]*sql.DB
+}
+
+// RegisterDB exposes the pool stats of db labeled by name,
+// ex: r.RegisterDB("sqlxext", client.DB.DB)
+func (r *Registry) RegisterDB(name string, db *sql.DB) {
+	r.mu.Lock()
+	s := r.dbs
+	if s == nil {
+		s = &dbStats{dbs: make(map[string]*sql.DB)}
+		r.dbs = s
+	}
+	r.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first := len(s.dbs) == 0
+	s.dbs[name] = db
+	if first {
+		s.register(r)
+	}
+}
+
+func (s *dbStats) register(r *Registry) {
+	labels := []string{"db"}
+	gauge := func(name, help string, fn func(st sql.DBStats) float64) {
+		r.NewGaugeFunc(name, help, labels, s.each(fn))
+	}
+	counter := func(name, help string, fn func(st sql.DBStats) float64) {
+		r.NewCounterFunc(name, help, labels, s.each(fn))
+	}
+	gauge("db_max_open_connections", "Maximum number of open connections to the database.",
+		func(st sql.DBStats) float64 { return float64(st.MaxOpenConnections) })
+	gauge("db_open_connections", "The number of established connections both in use and idle.",
+		func(st sql.DBStats) float64 { return float64(st.OpenConnections) })
+	gauge("db_in_use_connections", "The number of connections currently in use.",
+		func(st sql.DBStats) float64 { return float64(st.InUse) })
+	gauge("db_idle_connections", "The number of idle connections.",
+		func(st sql.DBStats) float64 { return float64(st.Idle) })
+	counter("db_wait_count_total", "The total number of connections waited for.",
+		func(st sql.DBStats) float64 { return float64(st.WaitCount) })
+	counter("db_wait_duration_seconds_total", "The total time blocked waiting for a new connection.",
+		func(st sql.DBStats) float64 { return st.WaitDuration.Seconds() })
+	counter("db_max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns.",
+		func(st sql.DBStats) float64 { return float64(st.MaxIdleClosed) })
+	counter("db_max_idle_time_closed_total", "The total number of connections closed due to SetConnMaxIdleTime.",
+		func(st sql.DBStats) float64 { return float64(st.MaxIdleTimeClosed) })
+	counter("db_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.",
+		func(st sql.DBStats) float64 { return float64(st.MaxLifetimeClosed) })
+}
+
+// each emits fn of the stats of every pool
+func (s *dbStats) each(fn func(st sql.DBStats) float64) func(emit func(v float64, labelValues ...string)) {
+	return func(emit func(v float64, labelValues ...string)) {
+		s.mu.Lock()
+		dbs := make(map[string]*sql.DB, len(s.dbs))
+		for n, db := range s.dbs {
+			dbs[n] = db
+		}
+		s.mu.Unlock()
+		for _, n := range sortedKeys(dbs) {
+			emit(fn(dbs[n].Stats()), n)
+		}
+	}
+}
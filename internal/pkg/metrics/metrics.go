@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are the default histogram buckets in seconds,
+// they fit the latencies of http requests & db queries
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default holds the metrics of the packages, ex: the http requests,
+// the ones of an app, ex: its db pool, are on a registry of its own
+var Default = NewRegistry()
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// collector is a metric family written in the text exposition format
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds the metric families, it writes them in the
+// Prometheus text exposition format
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+	names      map[string]struct{}
+	dbs        *dbStats
+}
+
+func NewRegistry() *Registry {
+	r := new(Registry)
+	r.names = make(map[string]struct{})
+	return r
+}
+
+// register panics on duplicate names as it is a programming error
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.names[c.name()]; ok {
+		panic(fmt.Sprintf("metrics: %s is already registered", c.name()))
+	}
+	r.names[c.name()] = struct{}{}
+	r.collectors = append(r.collectors, c)
+}
+
+// Write writes every metric family sorted by name
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	cs := make([]collector, len(r.collectors))
+	copy(cs, r.collectors)
+	r.mu.Unlock()
+	sort.Slice(cs, func(i, j int) bool { return cs[i].name() < cs[j].name() })
+	for _, c := range cs {
+		c.write(w)
+	}
+}
+
+// Handler serves the metrics for scraping
+func (r *Registry) Handler() http.Handler {
+	return Handler(r)
+}
+
+// Handler serves the metrics of the registries for scraping,
+// their names must not clash, ex: Handler(appRegistry, Default)
+func Handler(registries ...*Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, r := range registries {
+			r.Write(w)
+		}
+	})
+}
+
+// desc is the shared part of the metric families
+type desc struct {
+	fqName string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d desc) name() string {
+	return d.fqName
+}
+
+func (d desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.fqName, escapeHelp(d.help), d.fqName, d.typ)
+}
+
+// writeSample writes a sample, extra is an additional label like le
+func (d desc) writeSample(w io.Writer, suffix string, labelValues []string, extra string, v float64) {
+	var b strings.Builder
+	b.WriteString(d.fqName)
+	b.WriteString(suffix)
+	if len(d.labels) > 0 || extra != "" {
+		b.WriteByte('{')
+		for i, l := range d.labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(l)
+			b.WriteString(`="`)
+			b.WriteString(escapeLabel(labelValues[i]))
+			b.WriteByte('"')
+		}
+		if extra != "" {
+			if len(d.labels) > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(extra)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(v))
+	b.WriteByte('\n')
+	io.WriteString(w, b.String())
+}
+
+// key joins the label values to key the series of a vec
+func key(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func (d desc) check(labelValues []string) {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", d.fqName, len(d.labels), len(labelValues)))
+	}
+}
+
+// sortedKeys returns the keys of the series in a stable order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("requests_total", "The requests.", "route", "status")
+	c.Inc("/contents/{id}", "200")
+	c.Add(2, "/contents/{id}", "200")
+	c.Inc(`/a"b`, "500")
+	h := r.NewHistogramVec("duration_seconds", "The latency.", []float64{0.1, 1}, "route")
+	h.Observe(0.05, "/")
+	h.Observe(0.5, "/")
+	h.Observe(5, "/")
+	r.NewGaugeFunc("open_connections", "The connections.", []string{"db"}, func(emit func(v float64, labelValues ...string)) {
+		emit(3, "sqlxext")
+	})
+	var b strings.Builder
+	r.Write(&b)
+	expected := `# HELP duration_seconds The latency.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{route="/",le="0.1"} 1
+duration_seconds_bucket{route="/",le="1"} 2
+duration_seconds_bucket{route="/",le="+Inf"} 3
+duration_seconds_sum{route="/"} 5.55
+duration_seconds_count{route="/"} 3
+# HELP open_connections The connections.
+# TYPE open_connections gauge
+open_connections{db="sqlxext"} 3
+# HELP requests_total The requests.
+# TYPE requests_total counter
+requests_total{route="/a\"b",status="500"} 1
+requests_total{route="/contents/{id}",status="200"} 3
+`
+	if b.String() != expected {
+		t.Errorf("Expected '%v', but got '%v'", expected, b.String())
+	}
+}
+
+func TestHandler(t *testing.T) {
+	app := NewRegistry()
+	app.NewGaugeFunc("open_connections", "The connections.", []string{"db"}, func(emit func(v float64, labelValues ...string)) {
+		emit(3, "sqlxext")
+	})
+	pkg := NewRegistry()
+	pkg.NewCounterVec("requests_total", "The requests.", "route").Inc("/")
+	w := httptest.NewRecorder()
+	Handler(app, pkg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, expected := range []string{`open_connections{db="sqlxext"} 3`, `requests_total{route="/"} 1`} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("Expected '%v', but got '%v'", expected, w.Body.String())
+		}
+	}
+}
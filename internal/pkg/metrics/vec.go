@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec registers a counter, its name should end with _total
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{fqName: name, help: help, typ: typeCounter, labels: labels}, series: make(map[string]*counterSeries)}
+	r.register(c)
+	return c
+}
+
+// Inc adds 1 to the series of the label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which can't be negative, to the series of the label values
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	c.check(labelValues)
+	if v < 0 {
+		panic("metrics: counters can't decrease")
+	}
+	k := key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[k]
+	if !ok {
+		s = &counterSeries{labelValues: labelValues}
+		c.series[k] = s
+	}
+	s.value += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w)
+	for _, k := range sortedKeys(c.series) {
+		s := c.series[k]
+		c.writeSample(w, "", s.labelValues, "", s.value)
+	}
+}
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{desc: desc{fqName: name, help: help, typ: typeGauge, labels: labels}, series: make(map[string]*counterSeries)}
+	r.register(g)
+	return g
+}
+
+// Add adds v, which can be negative, to the series of the label values
+func (g *GaugeVec) Add(v float64, labelValues ...string) {
+	g.check(labelValues)
+	k := key(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.series[k]
+	if !ok {
+		s = &counterSeries{labelValues: labelValues}
+		g.series[k] = s
+	}
+	s.value += v
+}
+
+// Set sets the series of the label values to v
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.check(labelValues)
+	k := key(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.series[k] = &counterSeries{labelValues: labelValues, value: v}
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(w)
+	for _, k := range sortedKeys(g.series) {
+		s := g.series[k]
+		g.writeSample(w, "", s.labelValues, "", s.value)
+	}
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	// counts are per bucket, not cumulative
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a histogram with the upper bounds of the
+// buckets, DefBuckets if nil, the +Inf bucket is implicit
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	b := make([]float64, len(buckets))
+	copy(b, buckets)
+	sort.Float64s(b)
+	h := &HistogramVec{desc: desc{fqName: name, help: help, typ: typeHistogram, labels: labels}, buckets: b, series: make(map[string]*histogramSeries)}
+	r.register(h)
+	return h
+}
+
+// Observe adds v to the series of the label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.check(labelValues)
+	k := key(labelValues)
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogramSeries{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.series[k] = s
+	}
+	if i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w)
+	for _, k := range sortedKeys(h.series) {
+		s := h.series[k]
+		var cum uint64
+		for i, b := range h.buckets {
+			cum += s.counts[i]
+			h.writeSample(w, "_bucket", s.labelValues, `le="`+formatFloat(b)+`"`, float64(cum))
+		}
+		h.writeSample(w, "_bucket", s.labelValues, `le="+Inf"`, float64(s.count))
+		h.writeSample(w, "_sum", s.labelValues, "", s.sum)
+		h.writeSample(w, "_count", s.labelValues, "", float64(s.count))
+	}
+}
+
+// FuncVec reads its series when scraped, ex: from sql.DBStats
+type FuncVec struct {
+	desc
+	fn func(emit func(v float64, labelValues ...string))
+}
+
+// NewGaugeFunc registers a gauge whose series fn emits on every scrape
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, fn func(emit func(v float64, labelValues ...string))) {
+	r.register(&FuncVec{desc: desc{fqName: name, help: help, typ: typeGauge, labels: labels}, fn: fn})
+}
+
+// NewCounterFunc registers a counter whose series fn emits on every
+// scrape, the values have to be monotonic like sql.DBStats.WaitCount
+func (r *Registry) NewCounterFunc(name, help string, labels []string, fn func(emit func(v float64, labelValues ...string))) {
+	r.register(&FuncVec{desc: desc{fqName: name, help: help, typ: typeCounter, labels: labels}, fn: fn})
+}
+
+func (f *FuncVec) write(w io.Writer) {
+	type sample struct {
+		labelValues []string
+		v           float64
+	}
+	var samples []sample
+	f.fn(func(v float64, labelValues ...string) {
+		f.check(labelValues)
+		samples = append(samples, sample{labelValues, v})
+	})
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].labelValues, "\xff") < strings.Join(samples[j].labelValues, "\xff")
+	})
+	f.writeHeader(w)
+	for _, s := range samples {
+		f.writeSample(w, "", s.labelValues, "", s.v)
+	}
+}
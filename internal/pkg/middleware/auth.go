@@ -1,9 +1,13 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/contextext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth"
 )
@@ -21,11 +25,36 @@ func NewAuth(s *auth.Service) *Auth {
 // AuthUserMiddleWare auth user
 func (m *Auth) AuthUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, err := m.Service.Authorize(r)
+		e, p, err := m.Service.Authenticate(r)
 		if err != nil {
-			response.RespondError(http.StatusForbidden, constant.Error, err, w)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			response.RespondError(http.StatusUnauthorized, constant.Error, err, w)
 			return
 		}
-		next.ServeHTTP(w, r)
+		logext.AddAttrs(r.Context(), "user_id", p.Subject)
+		ctx := context.WithValue(r.Context(), constant.KeyAuthUser, e)
+		ctx = contextext.WithPrincipal(ctx, p)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// OptionalUser authenticates the request like AuthUser when
+// it has a token or an api key, requests without pass through anonymous
+func (m *Auth) OptionalUser(next http.Handler) http.Handler {
+	auth := m.AuthUser(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" && r.Header.Get(constant.HeaderAPIKey) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		auth.ServeHTTP(w, r)
+	})
+}
+
+// QueryFlag reports whether the boolean query param is true
+func QueryFlag(key string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		b, _ := strconv.ParseBool(r.URL.Query().Get(key))
+		return b
+	}
+}
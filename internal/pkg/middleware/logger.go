@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/contextext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
+)
+
+// maxRequestIDLen bounds the X-Request-ID accepted from clients
+const maxRequestIDLen = 128
+
+// RequestID takes the X-Request-ID of the request or generates one,
+// puts it in the context & sets it on the response
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(constant.HeaderRequestID)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(constant.HeaderRequestID, id)
+		next.ServeHTTP(w, r.WithContext(contextext.WithRequestID(r.Context(), id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		// printable ascii only, it ends up in the logs & headers
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Logger puts a logger with the request id, method, route & path
+// in the context and logs every request once it is served, the
+// auth middlewares add the user to it
+func Logger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id, _ := contextext.RequestIDFrom(r.Context())
+		l := slog.Default()
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			l = slog.New(routeHandler{Handler: l.Handler(), rctx: rctx})
+		}
+		l = l.With(
+			slog.String("request_id", id),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+		ctx := logext.WithLogger(r.Context(), l)
+		rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+		level := slog.LevelInfo
+		if rw.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logext.FromContext(ctx).LogAttrs(ctx, level, "request",
+			slog.Int("status", rw.status),
+			slog.Int("bytes", rw.bytes),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+// routeHandler adds the chi route pattern to the records, it is known
+// only once the request is routed so it can't be set by With which
+// renders the attrs right away
+type routeHandler struct {
+	slog.Handler
+	rctx *chi.Context
+}
+
+func (h routeHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(slog.String("route", h.rctx.RoutePattern()))
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h routeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return routeHandler{Handler: h.Handler.WithAttrs(attrs), rctx: h.rctx}
+}
+
+func (h routeHandler) WithGroup(name string) slog.Handler {
+	return routeHandler{Handler: h.Handler.WithGroup(name), rctx: h.rctx}
+}
+
+// statusWriter records the status & the size of the response
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the flusher & co
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
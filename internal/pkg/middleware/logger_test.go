@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var b bytes.Buffer
+	def := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&b, nil)))
+	defer slog.SetDefault(def)
+	r := chi.NewRouter()
+	r.Use(RequestID, Logger)
+	r.Get("/contents/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	tests := []struct {
+		name     string
+		id       string
+		expected bool
+	}{
+		{name: "accepted", id: "abc-123", expected: true},
+		{name: "generated", id: ""},
+		{name: "invalid", id: "a b"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/contents/1", nil)
+			req.Header.Set(constant.HeaderRequestID, tc.id)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			id := w.Header().Get(constant.HeaderRequestID)
+			if (id == tc.id) != tc.expected || id == "" {
+				t.Errorf("Expected '%v', but got '%v'", tc.id, id)
+			}
+			var m map[string]any
+			if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+				t.Fatal(err)
+			}
+			if m["request_id"] != id || m["route"] != "/contents/{id}" || m["status"] != float64(http.StatusTeapot) {
+				t.Errorf("Expected '%v', but got '%v'", "request log", m)
+			}
+		})
+	}
+}
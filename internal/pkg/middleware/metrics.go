@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/metrics"
+)
+
+var (
+	httpRequests = metrics.Default.NewCounterVec(
+		"http_requests_total",
+		"The number of http requests served by method, route & status.",
+		"method", "route", "status",
+	)
+	httpDuration = metrics.Default.NewHistogramVec(
+		"http_request_duration_seconds",
+		"The latency of the http requests by method & route.",
+		nil,
+		"method", "route",
+	)
+	httpInFlight = metrics.Default.NewGaugeVec(
+		"http_requests_in_flight",
+		"The number of http requests being served.",
+	)
+)
+
+// unmatchedRoute labels the requests not matching any route so
+// scanners can't blow up the cardinality with raw paths
+const unmatchedRoute = "unmatched"
+
+// Metrics records the rate, errors & duration of the requests
+// per chi route pattern
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		httpInFlight.Add(1)
+		defer httpInFlight.Add(-1)
+		rw, ok := w.(*statusWriter)
+		if !ok {
+			rw = &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		}
+		next.ServeHTTP(rw, r)
+		route := routePattern(r)
+		httpRequests.Inc(r.Method, route, strconv.Itoa(rw.status))
+		httpDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
+
+// routePattern returns the chi route pattern the request matched,
+// it is complete only once the request is served
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		return rctx.RoutePattern()
+	}
+	return unmatchedRoute
+}
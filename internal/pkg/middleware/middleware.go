@@ -1,14 +1,14 @@
 package middleware
 
 import (
-	"context"
-	"errors"
 	"net/http"
-	"strings"
 	"time"
 
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/contextext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
 )
 
@@ -25,7 +25,8 @@ func CORSEnableMiddleWare(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token, X-API-Key, X-Request-ID, traceparent, tracestate")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, X-Trace-ID")
 		next.ServeHTTP(w, r)
 	})
 }
@@ -37,29 +38,23 @@ func TimeoutHandler(timeout time.Duration) func(next http.Handler) http.Handler
 	}
 }
 
-// JWTMiddleWare checks auth of the request
-func JWTMiddleWare(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenHeader := r.Header.Get("Authorization")
-		if tokenHeader == "" {
-			// Token is missing
-			response.RespondError(http.StatusForbidden, "error", errors.New("auth token is missing"), w)
-			return
-		}
-		split := strings.Split(tokenHeader, " ")
-		// token format is `Bearer {tokenBody}`
-		if len(split) != 2 {
-			response.RespondError(http.StatusForbidden, "error", errors.New("token format is invalid"), w)
-			return
-		}
-		tokenBody := split[1]
-		claims, err := jwtext.VerifyToken1(tokenBody)
-		if err != nil {
-			response.RespondError(http.StatusForbidden, "error", err, w)
-			return
-		}
-		ctx := context.WithValue(r.Context(), constant.ContextPayloadKey, claims.Payload)
-		req := r.WithContext(ctx)
-		next.ServeHTTP(w, req)
-	})
+// JWTMiddleWare verifies the bearer token of the request and puts
+// its principal in the context, unlike Auth it doesn't need a local user
+func JWTMiddleWare(v authn.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			splits, err := httpext.ParseAuthToken(r)
+			if err != nil {
+				response.RespondError(http.StatusUnauthorized, constant.Error, err, w)
+				return
+			}
+			p, err := v.Verify(r.Context(), splits[1])
+			if err != nil {
+				response.RespondError(http.StatusUnauthorized, constant.Error, err, w)
+				return
+			}
+			logext.AddAttrs(r.Context(), "user_id", p.Subject)
+			next.ServeHTTP(w, r.WithContext(contextext.WithPrincipal(r.Context(), p)))
+		})
+	}
 }
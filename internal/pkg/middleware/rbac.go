@@ -1,68 +1,135 @@
 package middleware
 
 import (
-	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/contextext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/rbac"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth"
 
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
 )
 
-// RBAC Role Based Access Control middleware
+// RBAC Role Based Access Control middleware, the routes declare
+// the permission they require and the policy maps roles to them
 type RBAC struct {
-	service *auth.Service
+	service  *auth.Service
+	policy   atomic.Pointer[rbac.Policy]
+	mu       sync.Mutex
+	declared map[string]struct{}
 }
 
-func NewRBAC(s *auth.Service) *RBAC {
+func NewRBAC(s *auth.Service, p *rbac.Policy) *RBAC {
 	m := new(RBAC)
 	m.service = s
+	m.policy.Store(p)
+	m.declared = make(map[string]struct{})
 	return m
 }
 
-// Authorize handles authorization for a request
-func (r *RBAC) Authorize(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		e, err := r.service.AuthorizeForRoleBasic(request)
-		if err != nil {
-			response.RespondError(http.StatusForbidden, constant.Error, err.Error(), writer)
-			return
-		}
-		ctx := context.WithValue(request.Context(), constant.KeyAuthUser, e)
-		_ = request.WithContext(ctx)
-		next.ServeHTTP(writer, request)
-	})
+// Policy returns the policy in use
+func (m *RBAC) Policy() *rbac.Policy {
+	return m.policy.Load()
 }
 
-// AuthUserMiddleWare auth user
-func (r *RBAC) AuthRole(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		fmt.Println("AuthRole.method", request.Method)
-		fmt.Println("AuthRole.RequestURI", request.RequestURI)
-		fmt.Println("AuthRole.RemoteAddr", request.RemoteAddr)
-		fmt.Println("AuthRole.URL", request.URL)
-		fmt.Println("AuthRole.URL.RawPath", request.URL.RawPath)
-		fmt.Println("AuthRole.URL.Fragment", request.URL.Fragment)
-		fmt.Println("AuthRole.URL.Path", request.URL.Path)
-		fmt.Println("AuthRole.URL.EscapedPath", request.URL.EscapedPath())
-		d := rbac.GetRBAC(request.URL.Path, request.Method)
-		if d == nil {
-			// could not resolve access control stop the request
-			response.RespondError(http.StatusForbidden, constant.Error, constant.Unauthorized, writer)
-			return
-		}
-		d = d.(rbac.RBACModel)
-		fmt.Println("GetRBAC: ", d)
-		e, err := r.service.AuthorizeForRoleBasic(request)
-		if err != nil {
-			response.RespondError(http.StatusForbidden, constant.Error, err.Error(), writer)
-			return
+// SetPolicy swaps the policy, the requests in flight keep the old one
+func (m *RBAC) SetPolicy(p *rbac.Policy) {
+	m.policy.Store(p)
+}
+
+// UserRoles returns the roles assigned to the user by the policy in use
+func (m *RBAC) UserRoles(userID string) []string {
+	return m.policy.Load().UserRoles(userID)
+}
+
+// Require authenticates the request if it isn't yet and requires the
+// permission, it responds 401 without a valid token and 403 without the
+// permission, the permission is recorded for Check
+// ex: r.With(rbacMiddleWare.Require("contents:read")).Get("/", h)
+func (m *RBAC) Require(permission string) func(http.Handler) http.Handler {
+	m.declare(permission)
+	return m.require(func(*http.Request) bool { return true }, permission)
+}
+
+// RequireIf requires the permission when cond reports true for the
+// request, other requests pass through as is
+// ex: RequireIf(QueryFlag("hard"), "contents:delete:hard")
+func (m *RBAC) RequireIf(cond func(r *http.Request) bool, permission string) func(http.Handler) http.Handler {
+	m.declare(permission)
+	return m.require(cond, permission)
+}
+
+func (m *RBAC) require(cond func(r *http.Request) bool, permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cond(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			p, ok := contextext.PrincipalFrom(r.Context())
+			if !ok {
+				var err error
+				_, p, err = m.service.Authenticate(r)
+				if err != nil {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					response.RespondError(http.StatusUnauthorized, constant.Error, constant.Unauthorized, w)
+					return
+				}
+				logext.AddAttrs(r.Context(), "user_id", p.Subject)
+				r = r.WithContext(contextext.WithPrincipal(r.Context(), p))
+			}
+			if !m.allows(p, permission) {
+				response.RespondError(http.StatusForbidden, constant.Error, constant.Forbidden, w)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allows reports whether the roles of the principal have the permission,
+// an api key has to have it in its scopes too
+func (m *RBAC) allows(p authn.Principal, permission string) bool {
+	if p.Kind == authn.KindAPIKey && !rbac.Grants(p.Scopes, permission) {
+		return false
+	}
+	return m.policy.Load().Allows(p.Roles, permission)
+}
+
+func (m *RBAC) declare(permission string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.declared[permission] = struct{}{}
+}
+
+// Check fails if a permission declared by the routes isn't granted
+// to any role of the policy, it is run after registering the routes
+func (m *RBAC) Check() error {
+	return m.CheckPolicy(m.policy.Load())
+}
+
+// CheckPolicy is Check of the policy p rather than the one in use,
+// ex: a policy about to be written
+func (m *RBAC) CheckPolicy(p *rbac.Policy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var missing []string
+	for perm := range m.declared {
+		if !p.Defines(perm) {
+			missing = append(missing, perm)
 		}
-		ctx := context.WithValue(request.Context(), constant.KeyAuthUser, e)
-		_ = request.WithContext(ctx)
-		next.ServeHTTP(writer, request)
-	})
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("rbac: no role has the permissions %s", strings.Join(missing, ", "))
+	}
+	return nil
 }
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/rbac"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth"
+)
+
+func TestRBACAllows(t *testing.T) {
+	p, err := rbac.NewPolicy(map[string]rbac.Role{
+		"admin": {Permissions: []string{"*"}},
+		"user":  {Permissions: []string{"contents:read"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewRBAC(nil, p)
+	tests := []struct {
+		name       string
+		principal  authn.Principal
+		permission string
+		expected   bool
+	}{
+		{name: "token", principal: authn.Principal{Roles: []string{"admin"}}, permission: "users:delete", expected: true},
+		{name: "token without role", principal: authn.Principal{Roles: []string{"user"}}, permission: "users:delete"},
+		{name: "key in scope", principal: authn.Principal{Roles: []string{"admin"}, Kind: authn.KindAPIKey, Scopes: []string{"users:*"}}, permission: "users:delete", expected: true},
+		{name: "key out of scope", principal: authn.Principal{Roles: []string{"admin"}, Kind: authn.KindAPIKey, Scopes: []string{"contents:read"}}, permission: "users:delete"},
+		{name: "key beyond roles", principal: authn.Principal{Roles: []string{"user"}, Kind: authn.KindAPIKey, Scopes: []string{"*"}}, permission: "users:delete"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := m.allows(tc.principal, tc.permission); got != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRBACCheckPolicy(t *testing.T) {
+	p, err := rbac.NewPolicy(map[string]rbac.Role{
+		"admin": {Permissions: []string{"rbac:*", "contents:read"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewRBAC(nil, p)
+	m.Require("contents:read")
+	m.Require("rbac:write")
+	if err := m.Check(); err != nil {
+		t.Errorf("Expected '%v', but got '%v'", nil, err)
+	}
+	// a policy about to replace it, not the one in use, is checked
+	q, err := rbac.NewPolicy(map[string]rbac.Role{
+		"admin": {Permissions: []string{"rbac:*"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "rbac: no role has the permissions contents:read"
+	if err := m.CheckPolicy(q); err == nil || err.Error() != want {
+		t.Errorf("Expected '%v', but got '%v'", want, err)
+	}
+	if m.Policy() != p {
+		t.Errorf("Expected the policy in use kept, but got '%v'", m.Policy())
+	}
+}
+
+// emptyDenylist denies no token, the other methods
+// of the repository aren't used by Authenticate
+type emptyDenylist struct {
+	auth.TokenRepository
+}
+
+func (emptyDenylist) IsDenied(jti string, ctx context.Context) (bool, error) {
+	return false, nil
+}
+
+func TestRBACRequireJWKS(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := jwtext.NewKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := jwtext.NewKeySet(time.Hour, 0)
+	ks.Add(k)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(ks.JWKS())
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v, err := authn.NewJWKSVerifier(ctx, srv.URL, 0, authn.Rules{Issuer: "idp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the subjects of the idp have no local user
+	s := auth.NewService(nil, emptyDenylist{}, nil, v, nil, time.Minute, time.Hour)
+	p, err := rbac.NewPolicy(map[string]rbac.Role{
+		"admin": {Permissions: []string{"*"}},
+		"user":  {Permissions: []string{"contents:read"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewRBAC(s, p).Require("users:delete")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	newToken := func(role string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   "idp",
+			"sub":   "idp|42",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"roles": []string{role},
+		})
+		token.Header["kid"] = k.ID
+		b, err := token.SignedString(private)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	tests := []struct {
+		name     string
+		token    string
+		expected int
+	}{
+		{name: "admin claim", token: newToken("admin"), expected: http.StatusNoContent},
+		{name: "user claim", token: newToken("user"), expected: http.StatusForbidden},
+		{name: "no token", expected: http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, "/users/1", nil)
+			if tc.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, w.Code)
+			}
+		})
+	}
+}
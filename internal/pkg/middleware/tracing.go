@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts the server span of the request continuing the trace of
+// the traceparent header if any, the trace id is set on the response
+// so the errors can be looked up & added to the logger
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+		if id := tracing.TraceID(ctx); id != "" {
+			w.Header().Set(constant.HeaderTraceID, id)
+			logext.AddAttrs(ctx, "trace_id", id)
+		}
+		rw, ok := w.(*statusWriter)
+		if !ok {
+			rw = &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+		route := routePattern(r)
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(rw.status))
+		if rw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.status))
+		}
+	})
+}
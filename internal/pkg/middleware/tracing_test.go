@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/tracing"
+)
+
+func TestTracing(t *testing.T) {
+	if _, err := tracing.Init(context.Background(), tracing.ExporterNone, "test"); err != nil {
+		t.Fatal(err)
+	}
+	r := chi.NewRouter()
+	r.Use(Tracing)
+	r.Get("/contents/{id}", func(w http.ResponseWriter, r *http.Request) {
+		response.RespondError(http.StatusNotFound, constant.Error, "not found", w)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/contents/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	expected := "4bf92f3577b34da6a3ce929d0e0e4736"
+	if id := w.Header().Get(constant.HeaderTraceID); id != expected {
+		t.Errorf("Expected '%v', but got '%v'", expected, id)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["traceId"] != expected {
+		t.Errorf("Expected '%v', but got '%v'", expected, m["traceId"])
+	}
+}
@@ -0,0 +1,109 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrSortWithCursor is returned as the cursors only seek on (created_at, id)
+	ErrSortWithCursor = errors.New("sort can not be used with cursor pagination")
+)
+
+// Cursor is the (created_at, id) key of the row a page starts after
+type Cursor struct {
+	CreatedAt int64  `json:"c"`
+	ID        string `json:"i"`
+	// Backward seeks the page before the key instead of after it
+	Backward bool `json:"b,omitempty"`
+}
+
+// Codec encodes cursors to opaque tokens signed with HMAC-SHA256
+// so the clients can't forge a key they have not been given
+type Codec struct {
+	secret atomic.Pointer[[]byte]
+}
+
+func NewCodec(secret []byte) *Codec {
+	c := new(Codec)
+	c.SetSecret(secret)
+	return c
+}
+
+// SetSecret rotates the secret, the cursors signed with
+// the previous one are invalid & the clients start over
+func (c *Codec) SetSecret(secret []byte) {
+	c.secret.Store(&secret)
+}
+
+func (c *Codec) sign(payload string) string {
+	mac := hmac.New(sha256.New, *c.secret.Load())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Encode returns the token of the cursor, payload.signature
+func (c *Codec) Encode(cur Cursor) string {
+	b, _ := json.Marshal(cur)
+	p := base64.RawURLEncoding.EncodeToString(b)
+	return p + "." + c.sign(p)
+}
+
+// Decode verifies and decodes the token, an empty
+// token is the first page and returns a nil cursor
+func (c *Codec) Decode(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	p, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(c.sign(p))) {
+		return nil, ErrInvalidCursor
+	}
+	b, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cur := new(Cursor)
+	if err := json.Unmarshal(b, cur); err != nil || cur.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return cur, nil
+}
+
+// Cursors trims the extra row fetched by the seek query to
+// detect a following page and builds the next & prev tokens,
+// items must be fetched with limit+1 in the order of Seek
+func Cursors[T any](items []T, limit int, cur *Cursor, key func(T) Cursor, codec *Codec) ([]T, string, string) {
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+	backward := cur != nil && cur.Backward
+	if backward {
+		// a backward seek is fetched in reverse order
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if len(items) == 0 {
+		return items, "", ""
+	}
+	var next, prev string
+	// going back there is always a page after this one
+	if more || backward {
+		next = codec.Encode(key(items[len(items)-1]))
+	}
+	// going forward there is a page before unless this is the first one
+	if (backward && more) || (!backward && cur != nil) {
+		k := key(items[0])
+		k.Backward = true
+		prev = codec.Encode(k)
+	}
+	return items, next, prev
+}
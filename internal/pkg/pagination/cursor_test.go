@@ -0,0 +1,80 @@
+package pagination
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodec(t *testing.T) {
+	c := NewCodec([]byte("secret"))
+	cur := Cursor{CreatedAt: 1700000000000, ID: "6f1c", Backward: true}
+	got, err := c.Decode(c.Encode(cur))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != cur {
+		t.Errorf("Expected '%v', but got '%v'", cur, *got)
+	}
+	first, err := c.Decode("")
+	if err != nil || first != nil {
+		t.Errorf("Expected '%v', but got '%v'", nil, first)
+	}
+	tampered := c.Encode(Cursor{CreatedAt: 1, ID: "a"})
+	tests := []string{
+		"garbage",
+		tampered[:len(tampered)-1],
+		NewCodec([]byte("other")).Encode(cur),
+		c.Encode(Cursor{CreatedAt: 1}),
+	}
+	for _, tc := range tests {
+		if _, err := c.Decode(tc); err != ErrInvalidCursor {
+			t.Errorf("Expected '%v', but got '%v'", ErrInvalidCursor, err)
+		}
+	}
+	// the cursors signed before a rotation are invalid
+	token := c.Encode(cur)
+	c.SetSecret([]byte("rotated"))
+	if _, err := c.Decode(token); err != ErrInvalidCursor {
+		t.Errorf("Expected '%v', but got '%v'", ErrInvalidCursor, err)
+	}
+}
+
+func TestCursors(t *testing.T) {
+	c := NewCodec([]byte("secret"))
+	key := func(v int) Cursor { return Cursor{CreatedAt: int64(v), ID: "id"} }
+	back := func(v int) string {
+		k := key(v)
+		k.Backward = true
+		return c.Encode(k)
+	}
+	tests := []struct {
+		name  string
+		items []int
+		cur   *Cursor
+		want  []int
+		next  string
+		prev  string
+	}{
+		{"first page with more", []int{9, 8, 7}, nil, []int{9, 8}, c.Encode(key(8)), ""},
+		{"first page is last", []int{9, 8}, nil, []int{9, 8}, "", ""},
+		{"middle page", []int{7, 6, 5}, &Cursor{CreatedAt: 8}, []int{7, 6}, c.Encode(key(6)), back(7)},
+		{"last page", []int{5}, &Cursor{CreatedAt: 6}, []int{5}, "", back(5)},
+		{"backward with more", []int{8, 9, 10}, &Cursor{CreatedAt: 7, Backward: true}, []int{9, 8}, c.Encode(key(8)), back(9)},
+		{"backward to first", []int{8, 9}, &Cursor{CreatedAt: 7, Backward: true}, []int{9, 8}, c.Encode(key(8)), ""},
+		{"empty", []int{}, &Cursor{CreatedAt: 1}, []int{}, "", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			items, next, prev := Cursors(tc.items, 2, tc.cur, key, c)
+			if !reflect.DeepEqual(items, tc.want) {
+				t.Errorf("Expected '%v', but got '%v'", tc.want, items)
+			}
+			if next != tc.next {
+				t.Errorf("Expected '%v', but got '%v'", tc.next, next)
+			}
+			if prev != tc.prev {
+				t.Errorf("Expected '%v', but got '%v'", tc.prev, prev)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package pagination
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+)
+
+const (
+	DefaultLimit = 10
+	MaxLimit     = 100
+)
+
+// TotalMode selects how the total count of a list is computed
+type TotalMode string
+
+const (
+	TotalNone TotalMode = ""
+	// TotalExact runs a COUNT(*) which is slow on large tables
+	TotalExact TotalMode = "exact"
+	// TotalEstimated reads the planner's row estimate of the table
+	TotalEstimated TotalMode = "estimated"
+)
+
+// Params are the paging query params of a list request
+type Params struct {
+	Limit int
+	// Page is the 1 based page number, 0 in cursor mode
+	Page int
+	// Cursor is the token of the page, empty for the first page
+	Cursor string
+	// CursorMode is set when the cursor param is present, ex: ?cursor=&limit=10
+	CursorMode bool
+	Total      TotalMode
+}
+
+// ParseParams parses limit, page, cursor & total from the query,
+// page & cursor are exclusive and page mode is the default
+func ParseParams(r *http.Request) (Params, error) {
+	p := Params{Limit: DefaultLimit, Page: 1}
+	q := r.URL.Query()
+	var err error
+	if s := q.Get(constant.KeyLimit); s != "" {
+		p.Limit, err = strconv.Atoi(s)
+		if err != nil || p.Limit < 1 || p.Limit > MaxLimit {
+			return p, errors.New("limit must be between 1 and " + strconv.Itoa(MaxLimit))
+		}
+	}
+	if q.Has(constant.KeyCursor) {
+		if q.Has(constant.KeyPage) {
+			return p, errors.New("page and cursor can not be used together")
+		}
+		p.Page = 0
+		p.CursorMode = true
+		p.Cursor = q.Get(constant.KeyCursor)
+	} else if s := q.Get(constant.KeyPage); s != "" {
+		p.Page, err = strconv.Atoi(s)
+		if err != nil || p.Page < 1 {
+			return p, errors.New("page must be a positive number")
+		}
+	}
+	switch t := TotalMode(q.Get(constant.KeyTotal)); t {
+	case TotalNone, TotalExact, TotalEstimated:
+		p.Total = t
+	default:
+		return p, errors.New("total must be exact or estimated")
+	}
+	return p, nil
+}
+
+// Offset is the offset of the page in page mode
+func (p Params) Offset() int {
+	return p.Limit * (p.Page - 1)
+}
+
+// Seek adds the keyset predicate & order on (created_at, id) of the
+// cursor to b, newest first, the limit must be set to limit+1
+func Seek(b *postgres.SelectBuilder, cur *Cursor) *postgres.SelectBuilder {
+	cols := []string{"created_at", "id"}
+	switch {
+	case cur == nil:
+		return b.OrderBy(postgres.Desc("created_at"), postgres.Desc("id"))
+	case cur.Backward:
+		return b.Where(postgres.TupleGt(cols, cur.CreatedAt, cur.ID)).
+			OrderBy(postgres.Asc("created_at"), postgres.Asc("id"))
+	default:
+		return b.Where(postgres.TupleLt(cols, cur.CreatedAt, cur.ID)).
+			OrderBy(postgres.Desc("created_at"), postgres.Desc("id"))
+	}
+}
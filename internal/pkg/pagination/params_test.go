@@ -0,0 +1,35 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    Params
+		wantErr bool
+	}{
+		{"", Params{Limit: DefaultLimit, Page: 1}, false},
+		{"?limit=20&page=3&total=exact", Params{Limit: 20, Page: 3, Total: TotalExact}, false},
+		{"?cursor=", Params{Limit: DefaultLimit, CursorMode: true}, false},
+		{"?cursor=abc&limit=5&total=estimated", Params{Limit: 5, Cursor: "abc", CursorMode: true, Total: TotalEstimated}, false},
+		{"?cursor=abc&page=2", Params{}, true},
+		{"?limit=0", Params{}, true},
+		{"?limit=1000", Params{}, true},
+		{"?page=0", Params{}, true},
+		{"?total=all", Params{}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			p, err := ParseParams(httptest.NewRequest("GET", "/contents"+tc.query, nil))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected '%v', but got '%v'", tc.wantErr, err)
+			}
+			if !tc.wantErr && p != tc.want {
+				t.Errorf("Expected '%v', but got '%v'", tc.want, p)
+			}
+		})
+	}
+}
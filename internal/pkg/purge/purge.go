@@ -0,0 +1,81 @@
+package purge
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
+)
+
+// DefaultBatchSize is the max number of rows deleted per statement
+const DefaultBatchSize = 1000
+
+// Purger permanently deletes at most limit rows soft deleted before the time
+type Purger interface {
+	Purge(before int64, limit int, ctx context.Context) (int64, error)
+}
+
+// Job periodically purges the rows which were soft deleted longer
+// than the retention ago, the purgers run in the given order so
+// the referencing tables must come before the referenced ones
+type Job struct {
+	purgers   []Purger
+	retention time.Duration
+	interval  time.Duration
+	batchSize int
+	now       func() time.Time
+}
+
+func NewJob(retention, interval time.Duration, purgers ...Purger) *Job {
+	j := new(Job)
+	j.purgers = purgers
+	j.retention = retention
+	j.interval = interval
+	j.batchSize = DefaultBatchSize
+	j.now = time.Now
+	return j
+}
+
+// Run purges every interval until ctx is done
+func (j *Job) Run(ctx context.Context) {
+	t := time.NewTicker(j.interval)
+	defer t.Stop()
+	for {
+		n, err := j.RunOnce(ctx)
+		if err != nil {
+			slog.Error("purge failed", logext.Err(err))
+		} else if n > 0 {
+			slog.Info("purged rows", "rows", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce purges the expired rows in batches, a failing purger
+// does not stop the others, the first error is returned
+func (j *Job) RunOnce(ctx context.Context) (int64, error) {
+	before := j.now().Add(-j.retention).UnixMilli()
+	var total int64
+	var firstErr error
+	for _, p := range j.purgers {
+		for {
+			n, err := p.Purge(before, j.batchSize, ctx)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				break
+			}
+			total += n
+			if n < int64(j.batchSize) || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+	return total, firstErr
+}
@@ -0,0 +1,52 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakePurger struct {
+	rows   int64
+	err    error
+	before int64
+	calls  int
+}
+
+func (f *fakePurger) Purge(before int64, limit int, ctx context.Context) (int64, error) {
+	f.calls++
+	f.before = before
+	if f.err != nil {
+		return -1, f.err
+	}
+	n := f.rows
+	if n > int64(limit) {
+		n = int64(limit)
+	}
+	f.rows -= n
+	return n, nil
+}
+
+func TestRunOnce(t *testing.T) {
+	now := time.UnixMilli(10_000_000)
+	failing := &fakePurger{err: errors.New("fk violation")}
+	big := &fakePurger{rows: 25}
+	j := NewJob(time.Hour, time.Minute, failing, big)
+	j.batchSize = 10
+	j.now = func() time.Time { return now }
+	n, err := j.RunOnce(context.Background())
+	if err != failing.err {
+		t.Errorf("Expected '%v', but got '%v'", failing.err, err)
+	}
+	if n != 25 {
+		t.Errorf("Expected '%v', but got '%v'", 25, n)
+	}
+	// 10 + 10 + 5
+	if big.calls != 3 {
+		t.Errorf("Expected '%v', but got '%v'", 3, big.calls)
+	}
+	if expected := now.Add(-time.Hour).UnixMilli(); big.before != expected {
+		t.Errorf("Expected '%v', but got '%v'", expected, big.before)
+	}
+}
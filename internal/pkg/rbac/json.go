@@ -2,33 +2,41 @@ package rbac
 
 import (
 	"encoding/json"
-	"log"
 
 	"github.com/tanveerprottoy/stdlib-go-template/pkg/file"
 )
 
-var (
-	// configs
-	Data map[string]RBACModel
-)
+// Config is the json of config/rbac.json
+// ex: {"roles": {"admin": {"inherits": ["user"], "permissions": ["users:*"]}}}
+type Config struct {
+	Roles map[string]Role `json:"roles"`
+}
 
-func init() {
-	pwd, _ := file.GetPWD()
-	log.Println(pwd)
-	b, _ := file.ReadFile(pwd + "/config/rbac.json")
-	_ = json.Unmarshal(b, &Data)
+// Parse parses the json config to a policy
+func Parse(b []byte) (*Policy, error) {
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return NewPolicy(c.Roles)
 }
 
-func GetJsonValue(key string) any {
-	v, ok := Data[key]
-	// If the key exists
-	if ok {
-		return v
+// ReadConfigFile reads the json config at path
+func ReadConfigFile(path string) (Config, error) {
+	var c Config
+	b, err := file.ReadFile(path)
+	if err != nil {
+		return c, err
 	}
-	return nil
+	err = json.Unmarshal(b, &c)
+	return c, err
 }
 
-type RBACModel struct {
-	Name  string   `json:"name"`
-	Roles []string `json:"roles"`
+// LoadFile reads the json config at path to a policy
+func LoadFile(path string) (*Policy, error) {
+	c, err := ReadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewPolicy(c.Roles)
 }
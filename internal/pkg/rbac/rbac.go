@@ -1,62 +1,181 @@
 package rbac
 
 import (
+	"errors"
 	"fmt"
-	"net/url"
-
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/uuidext"
-	"github.com/tanveerprottoy/stdlib-go-template/pkg/stringsext"
+	"sort"
+	"strings"
 )
 
-func buildAndFindForKey(slice []string, offset int, method, param string) any {
-	// build the key
-	l := len(slice)
-	if l-offset >= 0 {
-		var k string
-		if param == "" {
-			k = fmt.Sprintf("%s.%s", slice[len(slice)-offset], method)
-		} else {
-			k = fmt.Sprintf("%s.%s.%s", slice[len(slice)-offset], method, param)
-		}
-		fmt.Println(k)
-		return GetJsonValue(k)
-	}
-	return nil
-}
-
-// GetRBAC fetches from the Role based access control
-// json file if the data exists
-func GetRBAC(path, method string) any {
-	s := stringsext.Split(path, "/")
-	fmt.Println(s)
-	v := buildAndFindForKey(s, 1, method, "")
-	if v == nil {
-		// try with the value before the last one
-		return buildAndFindForKey(s, 2, method, "p")
-	}
-	return v
-}
-
-// BuildKey builds the mapping key with action
-func BuildKey(url *url.URL, method string) string {
-	s := stringsext.Split(url.Path, "/")
-	// get the last elements
-	paths := s[3:]
-	var k string
-	// var builder strings.Builder
-	for i, path := range paths {
-		// if first element if not add a "."
-		if i > 0 {
-			k = k + "."
-		}
-		isUUID := uuidext.IsValidUUID(path)
-		if isUUID {
-			// replace uuid with p
-			k = k + "p"
-		} else {
-			k = k + path
-		}
-	}
-	k = k + "." + method
-	return k	
+// Wildcard grants every permission, "contents:*" grants
+// every permission of contents
+const Wildcard = "*"
+
+// ErrInvalidPolicy is wrapped by the errors of the
+// roles which don't resolve to a policy
+var ErrInvalidPolicy = errors.New("rbac: invalid policy")
+
+// Role is a role as in config/rbac.json, it has its own permissions
+// and all the permissions of the roles it inherits
+type Role struct {
+	Inherits    []string `json:"inherits,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// Policy maps the roles to their resolved permissions and the users to
+// their extra roles, it is immutable, a change is loaded as a new policy
+type Policy struct {
+	roles map[string]map[string]struct{}
+	users map[string][]string
+}
+
+// NewPolicy resolves the inheritance of the roles, it fails
+// on unknown inherited roles & cycles
+func NewPolicy(roles map[string]Role) (*Policy, error) {
+	p := &Policy{roles: make(map[string]map[string]struct{}, len(roles))}
+	var resolve func(name string, path []string) (map[string]struct{}, error)
+	resolve = func(name string, path []string) (map[string]struct{}, error) {
+		if perms, ok := p.roles[name]; ok {
+			return perms, nil
+		}
+		for _, n := range path {
+			if n == name {
+				return nil, fmt.Errorf("%w: role inheritance cycle %s", ErrInvalidPolicy, strings.Join(append(path, name), " -> "))
+			}
+		}
+		r, ok := roles[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown role %q inherited by %q", ErrInvalidPolicy, name, path[len(path)-1])
+		}
+		perms := make(map[string]struct{})
+		for _, perm := range r.Permissions {
+			perms[perm] = struct{}{}
+		}
+		for _, parent := range r.Inherits {
+			inherited, err := resolve(parent, append(path, name))
+			if err != nil {
+				return nil, err
+			}
+			for perm := range inherited {
+				perms[perm] = struct{}{}
+			}
+		}
+		p.roles[name] = perms
+		return perms, nil
+	}
+	for name := range roles {
+		if _, err := resolve(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// WithUserRoles sets the roles assigned to the users on top of
+// their own role, keyed by user id, it returns p for chaining
+func (p *Policy) WithUserRoles(users map[string][]string) *Policy {
+	p.users = users
+	return p
+}
+
+// UserRoles returns the roles assigned to the user
+func (p *Policy) UserRoles(userID string) []string {
+	return p.users[userID]
+}
+
+// Allows reports whether any of the roles has the permission
+func (p *Policy) Allows(roles []string, permission string) bool {
+	for _, r := range roles {
+		if granted(p.roles[r], permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Defines reports whether any role has the permission
+func (p *Policy) Defines(permission string) bool {
+	for _, perms := range p.roles {
+		if granted(perms, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Names reports whether a role grants the permission other than by *,
+// or for a wildcard, a permission under it, unlike Defines a role having
+// * doesn't name every permission, ex: to reject the unknown scopes of an
+// api key while contents:* names contents:restore
+func (p *Policy) Names(permission string) bool {
+	wildcard := map[string]struct{}{permission: {}}
+	for _, perms := range p.roles {
+		if grantedByPrefix(perms, permission) {
+			return true
+		}
+		if !strings.HasSuffix(permission, Wildcard) {
+			continue
+		}
+		for perm := range perms {
+			if granted(wildcard, perm) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Permissions returns the resolved permissions of the role sorted
+func (p *Policy) Permissions(role string) []string {
+	d := make([]string, 0, len(p.roles[role]))
+	for perm := range p.roles[role] {
+		d = append(d, perm)
+	}
+	sort.Strings(d)
+	return d
+}
+
+// Roles returns the names of the roles sorted
+func (p *Policy) Roles() []string {
+	d := make([]string, 0, len(p.roles))
+	for r := range p.roles {
+		d = append(d, r)
+	}
+	sort.Strings(d)
+	return d
+}
+
+// Grants reports whether the permissions, which may have wildcards,
+// grant the permission, ex: the scopes of an api key
+func Grants(permissions []string, permission string) bool {
+	perms := make(map[string]struct{}, len(permissions))
+	for _, perm := range permissions {
+		perms[perm] = struct{}{}
+	}
+	return granted(perms, permission)
+}
+
+func granted(perms map[string]struct{}, permission string) bool {
+	if _, ok := perms[Wildcard]; ok {
+		return true
+	}
+	return grantedByPrefix(perms, permission)
+}
+
+// grantedByPrefix reports whether the permissions have the
+// permission or a wildcard of a prefix of it, * aside
+func grantedByPrefix(perms map[string]struct{}, permission string) bool {
+	if _, ok := perms[permission]; ok {
+		return true
+	}
+	// contents:* grants contents:read & contents:delete:hard
+	for i := 0; i < len(permission); i++ {
+		if permission[i] != ':' {
+			continue
+		}
+		if _, ok := perms[permission[:i+1]+Wildcard]; ok {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,97 @@
+package rbac
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyAllows(t *testing.T) {
+	p, err := Parse([]byte(`{"roles": {
+		"viewer": {"permissions": ["contents:read"]},
+		"user": {"inherits": ["viewer"], "permissions": ["contents:create"]},
+		"admin": {"inherits": ["user"], "permissions": ["users:*"]},
+		"root": {"permissions": ["*"]}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.WithUserRoles(map[string][]string{"1": {"admin"}})
+	tests := []struct {
+		name       string
+		roles      []string
+		permission string
+		expected   bool
+	}{
+		{name: "own", roles: []string{"viewer"}, permission: "contents:read", expected: true},
+		{name: "missing", roles: []string{"viewer"}, permission: "contents:create"},
+		{name: "inherited", roles: []string{"admin"}, permission: "contents:read", expected: true},
+		{name: "wildcard", roles: []string{"admin"}, permission: "users:delete:hard", expected: true},
+		{name: "wildcard other resource", roles: []string{"admin"}, permission: "contents:delete"},
+		{name: "root", roles: []string{"root"}, permission: "anything", expected: true},
+		{name: "unknown role", roles: []string{"guest"}, permission: "contents:read"},
+		{name: "any of roles", roles: []string{"guest", "viewer"}, permission: "contents:read", expected: true},
+		{name: "assigned role", roles: p.UserRoles("1"), permission: "users:read", expected: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := p.Allows(tc.roles, tc.permission); got != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestPolicyNames(t *testing.T) {
+	p, err := Parse([]byte(`{"roles": {
+		"user": {"permissions": ["contents:read", "users:*"]},
+		"root": {"permissions": ["*"]}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		permission string
+		expected   bool
+	}{
+		{permission: "contents:read", expected: true},
+		{permission: "contents:*", expected: true},
+		{permission: "users:*", expected: true},
+		{permission: "*", expected: true},
+		{permission: "users:read", expected: true},
+		{permission: "users:delete:hard", expected: true},
+		{permission: "contents:delete"},
+		{permission: "unknown:read"},
+		{permission: "unknown:*"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.permission, func(t *testing.T) {
+			if got := p.Names(tc.permission); got != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewPolicyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles map[string]Role
+	}{
+		{name: "cycle", roles: map[string]Role{"a": {Inherits: []string{"b"}}, "b": {Inherits: []string{"a"}}}},
+		{name: "unknown", roles: map[string]Role{"a": {Inherits: []string{"b"}}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewPolicy(tc.roles); !errors.Is(err, ErrInvalidPolicy) {
+				t.Errorf("Expected '%v', but got '%v'", ErrInvalidPolicy, err)
+			}
+		})
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	// the policy shipped with the app has to load
+	if _, err := LoadFile("../../../config/rbac.json"); err != nil {
+		t.Errorf("Expected no error, but got '%v'", err)
+	}
+}
@@ -7,6 +7,12 @@ import (
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
 )
 
+// ETagger is implemented by the versioned resources, Respond
+// sets the ETag header for them
+type ETagger interface {
+	ETag() string
+}
+
 type Response struct {
 	Data any `json:"data"`
 }
@@ -14,8 +20,57 @@ type Response struct {
 type ReadManyResponse[T any] struct {
 	Items []T `json:"items"`
 	Limit int `json:"limit"`
-	Page  int `json:"page"`
-	Total int `json:"total"`
+	// Page is omitted in cursor mode
+	Page       int    `json:"page,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+	// Total is only set when requested
+	Total *int64 `json:"total,omitempty"`
+}
+
+// MapItems maps the items of the page, ex: to the dto they are listed as
+func MapItems[T, U any](res ReadManyResponse[T], fn func(T) U) ReadManyResponse[U] {
+	out := ReadManyResponse[U]{
+		Items:      make([]U, len(res.Items)),
+		Limit:      res.Limit,
+		Page:       res.Page,
+		NextCursor: res.NextCursor,
+		PrevCursor: res.PrevCursor,
+		Total:      res.Total,
+	}
+	for i, e := range res.Items {
+		out.Items[i] = fn(e)
+	}
+	return out
+}
+
+// SelectFields keeps only the json fields of the items, used for sparse fieldsets
+func SelectFields[T any](res ReadManyResponse[T], fields []string) (ReadManyResponse[map[string]any], error) {
+	out := ReadManyResponse[map[string]any]{
+		Items:      make([]map[string]any, len(res.Items)),
+		Limit:      res.Limit,
+		Page:       res.Page,
+		NextCursor: res.NextCursor,
+		PrevCursor: res.PrevCursor,
+		Total:      res.Total,
+	}
+	for i, e := range res.Items {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return out, err
+		}
+		var m map[string]any
+		if err := json.Unmarshal(b, &m); err != nil {
+			return out, err
+		}
+		out.Items[i] = make(map[string]any, len(fields))
+		for _, f := range fields {
+			if v, ok := m[f]; ok {
+				out.Items[i][f] = v
+			}
+		}
+	}
+	return out, nil
 }
 
 func writeResponse(w http.ResponseWriter, b []byte) (int, error) {
@@ -32,13 +87,28 @@ func Respond(code int, payload any, w http.ResponseWriter) {
 		RespondError(http.StatusInternalServerError, "error", err, w)
 		return
 	}
+	if e, ok := payload.(ETagger); ok {
+		w.Header().Set("ETag", e.ETag())
+	}
 	w.WriteHeader(code)
 	writeResponse(w, res)
 }
 
+// RespondNotModified responds 304 to a conditional GET
+func RespondNotModified(etag string, w http.ResponseWriter) {
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// RespondError responds the error under key, with the trace id
+// set by the tracing middleware if any
 func RespondError(code int, key string, err any, w http.ResponseWriter) {
+	body := map[string]any{key: err}
+	if id := w.Header().Get(constant.HeaderTraceID); id != "" {
+		body["traceId"] = id
+	}
 	w.WriteHeader(code)
-	res, err := json.Marshal(map[string]any{key: err})
+	res, err := json.Marshal(body)
 	if err != nil {
 		// log failed to marshal
 		writeResponse(w, []byte(constant.InternalServerError))
@@ -48,8 +118,12 @@ func RespondError(code int, key string, err any, w http.ResponseWriter) {
 }
 
 func RespondErrorMessage(code int, msg string, w http.ResponseWriter) {
+	body := map[string]string{"error": msg}
+	if id := w.Header().Get(constant.HeaderTraceID); id != "" {
+		body["traceId"] = id
+	}
 	w.WriteHeader(code)
-	res, err := json.Marshal(map[string]string{"error": msg})
+	res, err := json.Marshal(body)
 	if err != nil {
 		writeResponse(w, []byte(err.Error()))
 		return
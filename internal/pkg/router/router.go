@@ -4,7 +4,6 @@ import (
 	middlewarepkg "github.com/tanveerprottoy/stdlib-go-template/internal/pkg/middleware"
 
 	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
 )
 
 // Router struct
@@ -21,7 +20,10 @@ func NewRouter() *Router {
 
 func (r *Router) registerGlobalMiddlewares() {
 	r.Mux.Use(
-		middleware.Logger,
+		middlewarepkg.RequestID,
+		middlewarepkg.Logger,
+		middlewarepkg.Tracing,
+		middlewarepkg.Metrics,
 		// middleware.Recoverer,
 		middlewarepkg.JSONContentTypeMiddleWare,
 		middlewarepkg.CORSEnableMiddleWare,
@@ -30,8 +32,8 @@ func (r *Router) registerGlobalMiddlewares() {
 			AllowedOrigins: []string{"https://*", "http://*"},
 			// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
 			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-			ExposedHeaders:   []string{"Link"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-API-Key", "X-Request-ID", "traceparent", "tracestate"},
+			ExposedHeaders:   []string{"Link", "X-Request-ID", "X-Trace-ID"},
 			AllowCredentials: false,
 			MaxAge:           300, // Maximum value not ignored by any of major browsers
 		}), */
@@ -1,29 +1,21 @@
 package s3ext
 
 import (
-	"sync"
-
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
-var (
-	instance *Clients
-	once     sync.Once
-)
-
 // S3Client struct
 type Clients struct {
 	S3Client      *s3.Client
 	PresignClient *s3.PresignClient
+	// httpClient is the one resolved by the options, kept for Close
+	httpClient s3.HTTPClient
 }
 
-// GetInstance returns a singleton of Client
-func GetInstance() *Clients {
-	once.Do(func() {
-		instance = new(Clients)
-	})
-	return instance
+// NewClients creates the clients, to be initialized with Init
+func NewClients() *Clients {
+	return new(Clients)
 }
 
 // Init initializes the client with options
@@ -41,14 +33,28 @@ func GetInstance() *Clients {
 //	})
 func (c *Clients) Init(o s3.Options, optFn func(*s3.Options)) {
 	if optFn != nil {
-		c.S3Client = s3.New(o, optFn)
+		c.S3Client = s3.New(o, optFn, c.keepHTTPClient)
 	} else {
-		c.S3Client = s3.New(o)
+		c.S3Client = s3.New(o, c.keepHTTPClient)
 	}
 	// init presignClient
 	c.PresignClient = s3.NewPresignClient(c.S3Client)
 }
 
+// Close closes the idle connections of the clients, the http
+// client of the options has to have CloseIdleConnections, ex:
+// awshttp.NewBuildableClient().Freeze(), otherwise it's a no-op
+func (c *Clients) Close() {
+	if h, ok := c.httpClient.(interface{ CloseIdleConnections() }); ok {
+		h.CloseIdleConnections()
+	}
+}
+
+// keepHTTPClient is applied after the other option funcs
+func (c *Clients) keepHTTPClient(o *s3.Options) {
+	c.httpClient = o.HTTPClient
+}
+
 // InitWithConfig initializes the client with the
 // passed config and if override needed pass the optFn
 // ex: cfg, err := config.LoadDefaultConfig(context.TODO())
@@ -59,8 +65,8 @@ func (c *Clients) Init(o s3.Options, optFn func(*s3.Options)) {
 //	})
 func (c *Clients) InitWithConfig(cfg aws.Config, optFn func(*s3.Options)) {
 	if optFn != nil {
-		c.S3Client = s3.NewFromConfig(cfg, optFn)
+		c.S3Client = s3.NewFromConfig(cfg, optFn, c.keepHTTPClient)
 	} else {
-		c.S3Client = s3.NewFromConfig(cfg)
+		c.S3Client = s3.NewFromConfig(cfg, c.keepHTTPClient)
 	}
 }
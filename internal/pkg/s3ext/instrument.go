@@ -0,0 +1,49 @@
+package s3ext
+
+import (
+	"context"
+	"time"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/metrics"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	s3Requests = metrics.Default.NewCounterVec(
+		"s3_requests_total",
+		"The number of s3 operations by operation & result.",
+		"operation", "result",
+	)
+	s3Duration = metrics.Default.NewHistogramVec(
+		"s3_request_duration_seconds",
+		"The latency of the s3 operations by operation.",
+		nil,
+		"operation",
+	)
+)
+
+// instrument starts the span of the operation, the returned func
+// ends it & records the metrics, it is deferred with the address
+// of the named error result
+func instrument(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "S3 "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("aws-api"),
+			semconv.RPCService("S3"),
+			semconv.RPCMethod(operation),
+		),
+	)
+	return ctx, func(err *error) {
+		result := "success"
+		if *err != nil {
+			result = "error"
+		}
+		s3Requests.Inc(operation, result)
+		s3Duration.Observe(time.Since(start).Seconds(), operation)
+		tracing.End(span, *err)
+	}
+}
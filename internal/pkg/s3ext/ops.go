@@ -18,14 +18,18 @@ import (
 //	 CreateBucketConfiguration: &types.CreateBucketConfiguration{
 //	 	LocationConstraint: types.BucketLocationConstraint(region),
 //	 },
-func CreateBucket(params *s3.CreateBucketInput, client *s3.Client, ctx context.Context, optFns ...func(*s3.Options)) (*s3.CreateBucketOutput, error) {
+func CreateBucket(params *s3.CreateBucketInput, client *s3.Client, ctx context.Context, optFns ...func(*s3.Options)) (_ *s3.CreateBucketOutput, err error) {
 	// Create the S3 Bucket
+	ctx, end := instrument(ctx, "CreateBucket")
+	defer end(&err)
 	return client.CreateBucket(ctx, params, optFns...)
 }
 
 // GetBucket determines whether we have this bucket
-func GetBucket(bucketName string, client *s3.Client, ctx context.Context, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+func GetBucket(bucketName string, client *s3.Client, ctx context.Context, optFns ...func(*s3.Options)) (_ *s3.HeadBucketOutput, err error) {
 	// Do we have this Bucket
+	ctx, end := instrument(ctx, "HeadBucket")
+	defer end(&err)
 	return client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucketName)}, optFns...)
 }
 
@@ -36,7 +40,9 @@ func GetBucket(bucketName string, client *s3.Client, ctx context.Context, optFns
 //	 	Key:    aws.String(fileName),
 //	 	Body:   file,
 //	}
-func PutObject(params *s3.PutObjectInput, client *s3.Client, ctx context.Context, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+func PutObject(params *s3.PutObjectInput, client *s3.Client, ctx context.Context, optFns ...func(*s3.Options)) (_ *s3.PutObjectOutput, err error) {
+	ctx, end := instrument(ctx, "PutObject")
+	defer end(&err)
 	return client.PutObject(ctx, params, optFns...)
 }
 
@@ -47,10 +53,12 @@ func PutObject(params *s3.PutObjectInput, client *s3.Client, ctx context.Context
 //	 	Key:    aws.String(fileName),
 //	 	Body:   file,
 //	}
-func PutObjectWG(params *s3.PutObjectInput, wg *sync.WaitGroup, client *s3.Client, ctx context.Context, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+func PutObjectWG(params *s3.PutObjectInput, wg *sync.WaitGroup, client *s3.Client, ctx context.Context, optFns ...func(*s3.Options)) (_ *s3.PutObjectOutput, err error) {
 	defer func() {
 		wg.Done()
 	}()
+	ctx, end := instrument(ctx, "PutObject")
+	defer end(&err)
 	return client.PutObject(ctx, params, optFns...)
 }
 
@@ -72,7 +80,9 @@ func PutObjectPresigned(params *s3.PutObjectInput, client *s3.PresignClient, ctx
 //			Bucket: aws.String(bucketName),
 //			Key:    aws.String(objectKey),
 //	}
-func GetObject(params *s3.GetObjectInput, client *s3.Client, ctx context.Context, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+func GetObject(params *s3.GetObjectInput, client *s3.Client, ctx context.Context, optFns ...func(*s3.Options)) (_ *s3.GetObjectOutput, err error) {
+	ctx, end := instrument(ctx, "GetObject")
+	defer end(&err)
 	return client.GetObject(ctx, params, optFns...)
 }
 
@@ -0,0 +1,147 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	SchemeEnv     = "env"
+	SchemeFile    = "file"
+	SchemeEncFile = "encfile"
+)
+
+var ErrNotFound = errors.New("secret not found")
+
+// Env gets the secret from the env var of the key, ex: env://DB_PASS
+type Env struct{}
+
+func (Env) Get(key string, ctx context.Context) (string, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+// File gets the secret from the file of the key, ex: the docker or
+// kubernetes secret file:///run/secrets/db_pass, the trailing
+// newline is trimmed
+type File struct{}
+
+func (File) Get(key string, ctx context.Context) (string, error) {
+	b, err := os.ReadFile(key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// EncryptedFile gets the secret of the name from the json object of
+// the secrets encrypted with Encrypt, ex: encfile:///etc/app/secrets.enc#db_pass
+type EncryptedFile struct {
+	key []byte
+}
+
+// NewEncryptedFile creates the provider decrypting with
+// the AES-256 master key, 32 bytes
+func NewEncryptedFile(key []byte) *EncryptedFile {
+	p := new(EncryptedFile)
+	p.key = key
+	return p
+}
+
+func (p *EncryptedFile) Get(key string, ctx context.Context) (string, error) {
+	if len(p.key) == 0 {
+		return "", errors.New("no master key")
+	}
+	path, name, ok := strings.Cut(key, "#")
+	if !ok || name == "" {
+		return "", fmt.Errorf("missing the secret name in %q, ex: /path#name", key)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	b, err = Decrypt(p.key, b)
+	if err != nil {
+		return "", err
+	}
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return "", err
+	}
+	v, ok := m[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+// NewDefaultResolver returns a resolver of the env, file & encfile
+// references, the encrypted files are decrypted with the master key
+func NewDefaultResolver(masterKey []byte) *Resolver {
+	r := NewResolver()
+	r.Register(SchemeEnv, Env{})
+	r.Register(SchemeFile, File{})
+	r.Register(SchemeEncFile, NewEncryptedFile(masterKey))
+	return r
+}
+
+// MasterKey decodes the base64 master key of the encrypted files, v
+// may be an env or a file reference to it but not an encfile one
+func MasterKey(v string, ctx context.Context) ([]byte, error) {
+	r := NewResolver()
+	r.Register(SchemeEnv, Env{})
+	r.Register(SchemeFile, File{})
+	v, err := r.Resolve(v, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(v)
+}
+
+// Encrypt seals plaintext with AES-GCM, the nonce is prepended
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens the ciphertext of Encrypt
+func Decrypt(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("the master key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
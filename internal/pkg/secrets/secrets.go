@@ -0,0 +1,134 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
+)
+
+// Provider gets the secret of the key, the part of
+// the reference after the scheme, ex: DB_PASS of env://DB_PASS
+type Provider interface {
+	Get(key string, ctx context.Context) (string, error)
+}
+
+type entry struct {
+	value     string
+	listeners []func(value string)
+}
+
+// Resolver resolves the references of the registered schemes, ex:
+// file:///run/secrets/db_pass, & keeps the resolved ones up to date
+type Resolver struct {
+	providers map[string]Provider
+	mu        sync.Mutex
+	entries   map[string]*entry
+}
+
+func NewResolver() *Resolver {
+	r := new(Resolver)
+	r.providers = make(map[string]Provider)
+	r.entries = make(map[string]*entry)
+	return r
+}
+
+// Register makes the provider resolve the references of the scheme
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.providers[scheme] = p
+}
+
+func (r *Resolver) parse(ref string) (Provider, string, bool) {
+	scheme, key, ok := strings.Cut(ref, "://")
+	if !ok {
+		return nil, "", false
+	}
+	p, ok := r.providers[scheme]
+	return p, key, ok
+}
+
+// IsRef reports whether s is a reference of a registered scheme
+func (r *Resolver) IsRef(s string) bool {
+	_, _, ok := r.parse(s)
+	return ok
+}
+
+// Resolve returns the secret of the reference, any other value is
+// returned as is so plain values keep working, ex: in development
+func (r *Resolver) Resolve(ref string, ctx context.Context) (string, error) {
+	p, key, ok := r.parse(ref)
+	if !ok {
+		return ref, nil
+	}
+	v, err := p.Get(key, ctx)
+	if err != nil {
+		return "", fmt.Errorf("resolve secret %s: %w", ref, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.entries[ref]; ok {
+		e.value = v
+	} else {
+		r.entries[ref] = &entry{value: v}
+	}
+	return v, nil
+}
+
+// OnChange calls fn with the new secret whenever Refresh finds the
+// resolved reference changed, ex: after a rotation
+func (r *Resolver) OnChange(ref string, fn func(value string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.entries[ref]; ok {
+		e.listeners = append(e.listeners, fn)
+	}
+}
+
+// Refresh resolves the references again & notifies the changed
+// ones, a failing reference keeps its value, the errors are logged
+func (r *Resolver) Refresh(ctx context.Context) {
+	r.mu.Lock()
+	refs := make([]string, 0, len(r.entries))
+	for ref := range r.entries {
+		refs = append(refs, ref)
+	}
+	r.mu.Unlock()
+	for _, ref := range refs {
+		p, key, _ := r.parse(ref)
+		v, err := p.Get(key, ctx)
+		if err != nil {
+			slog.Error("refresh secret failed", "ref", ref, logext.Err(err))
+			continue
+		}
+		r.mu.Lock()
+		e := r.entries[ref]
+		changed := e.value != v
+		e.value = v
+		listeners := e.listeners
+		r.mu.Unlock()
+		if changed {
+			slog.Info("secret changed", "ref", ref)
+			for _, fn := range listeners {
+				fn(v)
+			}
+		}
+	}
+}
+
+// Watch refreshes every interval until ctx is done
+func (r *Resolver) Watch(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			r.Refresh(ctx)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolver(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db_pass")
+	if err := os.WriteFile(path, []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key := make([]byte, 32)
+	b, err := Encrypt(key, []byte(`{"db_pass": "sealed"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := filepath.Join(dir, "secrets.enc")
+	if err := os.WriteFile(enc, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_DB_PASS", "env")
+	r := NewDefaultResolver(key)
+	tests := []struct {
+		name     string
+		ref      string
+		expected string
+		err      error
+	}{
+		{name: "plain", ref: "secret", expected: "secret"},
+		{name: "unknown scheme", ref: "vault://db", expected: "vault://db"},
+		{name: "env", ref: "env://TEST_DB_PASS", expected: "env"},
+		{name: "env unset", ref: "env://TEST_UNSET", err: ErrNotFound},
+		{name: "file", ref: "file://" + path, expected: "old"},
+		{name: "encrypted file", ref: "encfile://" + enc + "#db_pass", expected: "sealed"},
+		{name: "encrypted file missing", ref: "encfile://" + enc + "#other", err: ErrNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := r.Resolve(tc.ref, context.Background())
+			if v != tc.expected || !errors.Is(err, tc.err) {
+				t.Errorf("Expected '%v', but got '%v' '%v'", tc.expected, v, err)
+			}
+		})
+	}
+	// rotation
+	var rotated string
+	r.OnChange("file://"+path, func(v string) { rotated = v })
+	if err := os.WriteFile(path, []byte("new\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r.Refresh(context.Background())
+	if rotated != "new" {
+		t.Errorf("Expected '%v', but got '%v'", "new", rotated)
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	key := make([]byte, 32)
+	b, err := Encrypt(key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)-1] ^= 1
+	if _, err := Decrypt(key, b); err == nil {
+		t.Errorf("Expected '%v', but got '%v'", "error", err)
+	}
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// tracerName is the instrumentation scope of the spans of the app
+const tracerName = "github.com/tanveerprottoy/stdlib-go-template"
+
+// Init sets the global tracer provider exporting to the exporter, otlp
+// over http configured by the standard OTEL_EXPORTER_OTLP_* env or
+// stdout, & the W3C trace context propagator, the spans are no-op with
+// none, it returns the func flushing the spans on shutdown
+func Init(ctx context.Context, exporter, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	var exp sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exp, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("invalid trace exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// OTEL_SERVICE_NAME & OTEL_RESOURCE_ATTRIBUTES take precedence
+	res, err := resource.New(
+		ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// the sampler is set by OTEL_TRACES_SAMPLER, parent based always on by default
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Start starts a span of the app tracer, ex:
+//
+//	ctx, span := tracing.Start(ctx, "S3 PutObject")
+//	defer func() { tracing.End(span, err) }()
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// End records the error if any & ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the trace id of the span of ctx, empty if not traced
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/cryptoext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jsonext"
 )
 
@@ -75,3 +76,9 @@ func NotEmpty(fl validator.FieldLevel) bool {
 		return field.IsValid() && field.Interface() != reflect.Zero(field.Type()).Interface()
 	}
 }
+
+// BcryptMax checks the string fits in the bytes bcrypt hashes, unlike
+// max which counts the runes of a string
+func BcryptMax(fl validator.FieldLevel) bool {
+	return len(fl.Field().String()) <= cryptoext.MaxPasswordBytes
+}
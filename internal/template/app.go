@@ -2,163 +2,197 @@ package template
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
+	"strconv"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/di"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/health"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/middleware"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/purge"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/router"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/s3ext"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/validatorext"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/fileupload"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/secrets"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/tracing"
+	rbacmodule "github.com/tanveerprottoy/stdlib-go-template/internal/template/module/rbac"
 	modulerouter "github.com/tanveerprottoy/stdlib-go-template/internal/template/router"
 	"github.com/tanveerprottoy/stdlib-go-template/pkg/file"
 )
 
 // App struct
 type App struct {
-	Server             *http.Server
-	idleConnsClosed    chan struct{}
-	DBClient           *sqlxext.Client
-	ClientsS3          *s3ext.Clients
-	HTTPClientProvider *httpext.ClientProvider
-	router             *router.Router
-	Middlewares        []any
-	AuthModule         *auth.Module
-	UserModule         *user.Module
-	ContentModule      *content.Module
-	FileUploadModule   *fileupload.Module
-	Validate           *validator.Validate
-}
-
-// NewApp creates App
-func NewApp() *App {
-	a := new(App)
-	a.initComponents()
-	a.initServer()
-	a.configureGracefulShutdown()
+	// Container holds the components, see provide & modulerouter.Modules
+	Container *di.Container
+	Config    *config.Config
+	Server    *http.Server
+	Lifecycle *Lifecycle
+	Health    *health.Registry
+	router    *router.Router
+}
+
+// NewApp creates App, args are the command line flags, ex: -db.host
+func NewApp(args []string) *App {
+	a, err := New(args)
+	if err != nil {
+		logext.Fatal("init app failed", logext.Err(err))
+	}
 	return a
 }
 
-// initDB initializes DB client
-func (a *App) initDB() {
-	a.DBClient = sqlxext.GetInstance()
+// New creates App with a container of its own so several can run side
+// by side, ex: in tests, the modules are installed after the ones of the
+// app so they can add routes or replace its providers, ex: a fake
+func New(args []string, modules ...di.Module) (*App, error) {
+	a := new(App)
+	a.Container = di.New()
+	provide(a.Container, args)
+	err := di.Invoke(a.Container, func(c *di.Container) error {
+		return a.initComponents(c, append(modulerouter.Modules(), modules...))
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.initServer()
+	return a, nil
 }
 
-// // createDir creates uploads directory
-func (a *App) createDir() {
-	file.CreateDirIfNotExists("./uploads")
+// initComponents builds the components in the order their side effects
+// need, the others are built by the modules as they resolve them
+func (a *App) initComponents(c *di.Container, modules []di.Module) error {
+	a.Config = di.MustResolve[*config.Config](c)
+	if err := a.initLogger(); err != nil {
+		return err
+	}
+	a.Lifecycle = di.MustResolve[*Lifecycle](c)
+	if err := a.initTracing(); err != nil {
+		return err
+	}
+	a.initSecretsRefresh(di.MustResolve[*secrets.Resolver](c))
+	a.createDir()
+	a.router = di.MustResolve[*router.Router](c)
+	if err := di.Install(c, modules...); err != nil {
+		return err
+	}
+	a.initHealth(di.MustResolve[*health.Registry](c))
+	a.initRBACListener(di.MustResolve[*rbacmodule.Module](c), di.MustResolve[*sqlx.DB](c))
+	a.Lifecycle.Go("purgeJob", di.MustResolve[*purge.Job](c).Run)
+	// every permission required by the routes has to be granted to a role
+	if err := di.MustResolve[*middleware.RBAC](c).Check(); err != nil {
+		return fmt.Errorf("check rbac permissions: %w", err)
+	}
+	return nil
 }
 
-func (a *App) initRouter() {
-	a.router = router.NewRouter()
-}
-
-// initS3 initializes s3
-func (a *App) initS3() {
-	s3Region := config.GetEnvValue("S3_REGION")
-	s3Endpoint := config.GetEnvValue("S3_ENDPOINT")
-	endpointResolverFunc := s3.EndpointResolverFunc(func(region string, options s3.EndpointResolverOptions) (aws.Endpoint, error) {
-		if s3Endpoint != "" {
-			return aws.Endpoint{
-				PartitionID:   "aws",
-				URL:           s3Endpoint,
-				SigningRegion: s3Region,
-			}, nil
-		}
-		// returning EndpointNotFoundError will allow the service to fallback to it's default resolution
-		return aws.Endpoint{}, &aws.EndpointNotFoundError{}
-	})
-	a.ClientsS3 = s3ext.GetInstance()
-	a.ClientsS3.Init(s3.Options{
-		Region: config.GetEnvValue("S3_REGION"),
-		// Credentials: aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(config.GetEnvValue("S3_ACCESS_KEY"), config.GetEnvValue("S3_SECRET_KEY"), "")),
-		// EndpointResolver: endpointResolverFunc,
-		// UsePathStyle: true,
-	}, func(o *s3.Options) {
-		o.EndpointResolver = endpointResolverFunc
-		o.UsePathStyle = true
-	})
-	// tmp create bucket
-	/* s3ext.CreateBucket(&s3.CreateBucketInput{
-		Bucket: aws.String(config.GetEnvValue("BUCKET_NAME")),
-	}, a.ClientsS3.S3Client, context.Background()) */
+// initLogger sets the default logger by the log format, json
+// or text, & level, debug, info, warn or error
+func (a *App) initLogger() error {
+	if err := logext.Init(a.Config.Log.Format, a.Config.Log.Level); err != nil {
+		return fmt.Errorf("init logger: %w", err)
+	}
+	// the secrets are redacted
+	slog.Info("loaded config", "config", a.Config)
+	return nil
 }
 
-func (a *App) initHTTPClientProvider() {
-	a.HTTPClientProvider = httpext.NewClientProvider(90*time.Second, nil, nil)
+// initTracing sets the tracer provider by the tracing exporter,
+// none (default), otlp or stdout
+func (a *App) initTracing() error {
+	shutdown, err := tracing.Init(context.Background(), a.Config.Tracing.Exporter, "stdlib-go-template")
+	if err != nil {
+		return fmt.Errorf("init tracing: %w", err)
+	}
+	// stopped last to flush the spans of the others
+	a.Lifecycle.Append(Hook{Name: "tracing", Stop: shutdown})
+	return nil
 }
 
-// initValidator initializes validator
-func (a *App) initValidator() {
-	a.Validate = validator.New()
-	validatorext.RegisterTagNameFunc(a.Validate)
-	_ = a.Validate.RegisterValidation("notempty", validatorext.NotEmpty)
+// initSecretsRefresh resolves the secret references again every
+// refresh interval so the rotated ones are picked up
+func (a *App) initSecretsRefresh(r *secrets.Resolver) {
+	interval := a.Config.Secrets.RefreshInterval
+	a.Lifecycle.Go("secretsRefresh", func(ctx context.Context) {
+		r.Watch(ctx, interval)
+	})
 }
 
-// initModules initializes application modules
-func (a *App) initModules() {
-	a.UserModule = user.NewModule(a.DBClient.DB, a.Validate)
-	a.ContentModule = content.NewModule(a.DBClient.DB, a.Validate)
-	a.AuthModule = auth.NewModule(a.UserModule.Service)
-	a.FileUploadModule = fileupload.NewModule(a.ClientsS3)
+// // createDir creates uploads directory
+func (a *App) createDir() {
+	file.CreateDirIfNotExists("./uploads")
 }
 
-// initMiddlewares initializes middlewares
-func (a *App) initMiddlewares() {
-	am := middleware.NewAuth(a.AuthModule.Service)
-	rm := middleware.NewRBAC(a.AuthModule.Service)
-	a.Middlewares = append(a.Middlewares, am)
-	a.Middlewares = append(a.Middlewares, rm)
+// initHealth serves the checks of the dependencies
+func (a *App) initHealth(h *health.Registry) {
+	a.Health = h
+	a.router.Mux.Get(constant.HealthzPattern, a.Health.Liveness)
+	a.router.Mux.Get(constant.ReadyzPattern, a.Health.Readiness)
+	a.router.Mux.Get(constant.HealthPattern, a.Health.Report)
 }
 
-// initModuleRouters initializes module routers and routes
-func (a *App) initModuleRouters() {
-	m := a.Middlewares[0].(*middleware.Auth)
-	r := a.Middlewares[1].(*middleware.RBAC)
-	modulerouter.RegisterUserRoutes(a.router, constant.V1, a.UserModule, m)
-	modulerouter.RegisterContentRoutes(a.router, constant.V1, a.ContentModule, m, r)
-	modulerouter.RegisterFileUploadRoutes(a.router, constant.V1, a.FileUploadModule)
+// initRBACListener reloads the policy of the rbac middleware
+// whenever the rbac tables change, by this app or another
+func (a *App) initRBACListener(m *rbacmodule.Module, db *sqlx.DB) {
+	a.Lifecycle.Go("rbacListener", func(ctx context.Context) {
+		postgres.Listen(ctx, db.DB, rbacmodule.Channel, 5*time.Second, func(string) {
+			if err := m.Service.Reload(ctx); err != nil {
+				slog.Error("reload rbac policy failed", logext.Err(err))
+			}
+		})
+	})
 }
 
 // initServer initializes the server
 func (a *App) initServer() {
 	a.Server = &http.Server{
-		Addr:    ":" + config.GetEnvValue("APP_PORT"),
+		Addr:    net.JoinHostPort(a.Config.Server.Host, strconv.Itoa(a.Config.Server.Port)),
 		Handler: a.router.Mux,
 	}
 }
 
-// configureGracefulShutdown configures graceful shutdown
-func (a *App) configureGracefulShutdown() {
-	// code to support graceful shutdown
-	a.idleConnsClosed = make(chan struct{})
-	go func() {
-		// this func listens for SIGINT and initiates
-		// shutdown when SIGINT is received
-		ch := make(chan os.Signal, 1)
-		signal.Notify(ch, os.Interrupt)
-		<-ch
-		// We received an interrupt signal, shut down.
-		log.Printf("Received an interrupt signal")
-		if err := a.Server.Shutdown(context.Background()); err != nil {
-			// Error from closing listeners, or context timeout:
-			log.Printf("HTTP Server shutdown error: %v", err)
-		}
-		close(a.idleConnsClosed)
-	}()
+// serverHook serves with serve until stopped, the stop fails the
+// readiness probe & waits the drain delay for the load balancer
+// to notice before closing the listener & waiting for the in-flight
+// requests, it's appended last so it's the first to stop, the
+// delay is validated shorter than the shutdown timeout so the
+// shutdown is left the rest of it
+func (a *App) serverHook(serve func() error) Hook {
+	delay := a.Config.Server.DrainDelay
+	return Hook{
+		Name: "server",
+		Start: func(context.Context) error {
+			go func() {
+				// if err == http.ErrServerClosed do nothing
+				if err := serve(); err != http.ErrServerClosed {
+					// Error starting or closing listener:
+					a.Lifecycle.Fail(err)
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			a.Health.Drain()
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			}
+			return a.Server.Shutdown(ctx)
+		},
+	}
+}
+
+// run serves until SIGINT or SIGTERM & stops the components
+func (a *App) run(serve func() error) {
+	a.Lifecycle.Append(a.serverHook(serve))
+	if err := a.Lifecycle.Run(context.Background()); err != nil {
+		logext.Fatal("shutdown failed", logext.Err(err))
+	}
+	slog.Info("server shutdown")
 }
 
 // ShutdownServer shuts down the server
@@ -168,47 +202,26 @@ func (a *App) ShutdownServer(ctx context.Context) {
 	if err := a.Server.Shutdown(ctx); err != nil {
 		panic(err)
 	} else {
-		log.Println("Server shutdown")
+		slog.Info("server shutdown")
 		// add code
 	}
 }
 
-// initComponents initializes application components
-func (a *App) initComponents() {
-	a.initDB()
-	a.createDir()
-	a.initRouter()
-	a.initS3()
-	a.initValidator()
-	a.initModules()
-	a.initMiddlewares()
-	a.initModuleRouters()
-}
-
 // Run runs the server
 func (a *App) Run() {
-	// if err == http.ErrServerClosed do nothing
-	if err := a.Server.ListenAndServe(); err != http.ErrServerClosed {
-		// Error starting or closing listener:
-		log.Fatalf("HTTP Server ListenAndServe: %v", err)
-	}
-	<-a.idleConnsClosed
-	log.Println("Server shutdown")
+	a.run(a.Server.ListenAndServe)
 }
 
 // RunTLS runs the server with TLS
 func (a *App) RunTLS() {
-	// if err == http.ErrServerClosed do nothing
-	if err := a.Server.ListenAndServeTLS("cert.crt", "key.key"); err != http.ErrServerClosed {
-		// Error starting or closing listener:
-		log.Fatalf("HTTPS server ListenAndServe: %v", err)
-	}
-	<-a.idleConnsClosed
+	a.run(func() error {
+		return a.Server.ListenAndServeTLS("cert.crt", "key.key")
+	})
 }
 
 // RunListenAndServe runs the server
 func (a *App) RunListenAndServe() {
-	err := http.ListenAndServe(":"+config.GetEnvValue("APP_PORT"), a.router.Mux)
+	err := http.ListenAndServe(a.Server.Addr, a.router.Mux)
 	if err != nil {
 		panic(err)
 	}
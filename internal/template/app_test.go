@@ -0,0 +1,107 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres/postgrestest"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/di"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/metrics"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/rbac"
+)
+
+// fakeDB replaces the db by a fake one & the policy seeded
+// from it by one granting everything to admin
+type fakeDB struct{}
+
+func (fakeDB) Provide(c *di.Container) {
+	di.Provide(c, di.Singleton, func(*di.Container) (*sqlxext.Client, error) {
+		db, _ := postgrestest.NewDB()
+		return &sqlxext.Client{DB: sqlx.NewDb(db, "pgx")}, nil
+	})
+	di.Provide(c, di.Singleton, func(*di.Container) (*rbac.Policy, error) {
+		return rbac.NewPolicy(map[string]rbac.Role{"admin": {Permissions: []string{rbac.Wildcard}}})
+	})
+}
+
+func (fakeDB) Register(*di.Container) error {
+	return nil
+}
+
+// newTestApp creates an app on a fake db in a temp dir as it creates its uploads dir
+func newTestApp(t *testing.T, args ...string) (*App, error) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	args = append([]string{
+		"-db.user=u",
+		"-db.name=n",
+		"-s3.region=r",
+		"-s3.bucket=b",
+		"-log.format=text",
+		"-log.level=error",
+	}, args...)
+	return New(args, fakeDB{})
+}
+
+func TestNewSideBySide(t *testing.T) {
+	a, err := newTestApp(t, "-auth.issuer=a", "-auth.jwtSecret=secret-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newTestApp(t, "-auth.issuer=b", "-auth.jwtSecret=secret-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sa, sb := di.MustResolve[*jwtext.Signer](a.Container), di.MustResolve[*jwtext.Signer](b.Container)
+	if sa.Issuer != "a" || sb.Issuer != "b" {
+		t.Errorf("Expected '%v' & '%v', but got '%v' & '%v'", "a", "b", sa.Issuer, sb.Issuer)
+	}
+	token, _, err := sa.NewToken(jwtext.Payload{Id: "1"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := di.MustResolve[authn.Verifier](a.Container).Verify(ctx, token); err != nil {
+		t.Errorf("Expected '%v', but got '%v'", nil, err)
+	}
+	// the token of a isn't signed with the secret of b
+	if _, err := di.MustResolve[authn.Verifier](b.Container).Verify(ctx, token); !errors.Is(err, authn.ErrInvalidToken) {
+		t.Errorf("Expected '%v', but got '%v'", authn.ErrInvalidToken, err)
+	}
+	ma, mb := di.MustResolve[*metrics.Registry](a.Container), di.MustResolve[*metrics.Registry](b.Container)
+	if ma == mb || ma == metrics.Default {
+		t.Errorf("Expected a metrics registry per app, but got '%p' & '%p'", ma, mb)
+	}
+	for _, app := range []*App{a, b} {
+		w := httptest.NewRecorder()
+		app.Server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, constant.MetricsPattern, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected '%v', but got '%v'", http.StatusOK, w.Code)
+		}
+	}
+}
+
+func TestNewInvalidDuration(t *testing.T) {
+	t.Setenv("PURGE_INTERVAL", "soon")
+	// the error is returned rather than exiting
+	if _, err := newTestApp(t); err == nil {
+		t.Errorf("Expected an error, but got '%v'", err)
+	}
+}
@@ -0,0 +1,150 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
+)
+
+// Hook is the start & stop of a component, either may be nil
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Lifecycle starts the hooks in the order they were appended & stops
+// them in reverse, so a component is stopped before the ones it uses
+type Lifecycle struct {
+	hooks   []Hook
+	started int
+	timeout time.Duration
+	failed  chan error
+	exit    func(code int)
+}
+
+// NewLifecycle creates a Lifecycle whose stop hooks have
+// up to timeout in total to return
+func NewLifecycle(timeout time.Duration) *Lifecycle {
+	l := new(Lifecycle)
+	l.timeout = timeout
+	l.failed = make(chan error, 1)
+	l.exit = os.Exit
+	return l
+}
+
+// Append adds the hook after the ones already appended
+func (l *Lifecycle) Append(h Hook) {
+	l.hooks = append(l.hooks, h)
+}
+
+// Go appends a hook running fn in a goroutine until it's stopped, the
+// stop cancels the ctx of fn & waits for it to return
+func (l *Lifecycle) Go(name string, fn func(ctx context.Context)) {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+	l.Append(Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				fn(ctx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// Fail makes Run shut down, ex: when the server can't listen
+func (l *Lifecycle) Fail(err error) {
+	select {
+	case l.failed <- err:
+	default:
+	}
+}
+
+// Start runs the start hooks, when one fails the
+// started ones are stopped & the error is returned
+func (l *Lifecycle) Start(ctx context.Context) error {
+	for _, h := range l.hooks {
+		if h.Start != nil {
+			if err := h.Start(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", h.Name, err)
+				return errors.Join(err, l.Stop(ctx))
+			}
+		}
+		l.started++
+	}
+	return nil
+}
+
+// Stop runs the stop hooks of the started ones in reverse, a failing
+// hook doesn't stop the others, the errors are joined
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	var errs []error
+	for ; l.started > 0; l.started-- {
+		h := l.hooks[l.started-1]
+		if h.Stop == nil {
+			continue
+		}
+		slog.Debug("stopping", "component", h.Name)
+		if err := h.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run starts the hooks & stops them on SIGINT or SIGTERM, a
+// second signal while stopping exits right away
+func (l *Lifecycle) Run(ctx context.Context) error {
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(ch)
+	return l.run(ctx, ch)
+}
+
+func (l *Lifecycle) run(ctx context.Context, signals <-chan os.Signal) error {
+	if err := l.Start(ctx); err != nil {
+		return err
+	}
+	var failed error
+	select {
+	case sig := <-signals:
+		slog.Info("received a signal, shutting down", "signal", sig.String())
+	case failed = <-l.failed:
+		slog.Error("component failed, shutting down", logext.Err(failed))
+	case <-ctx.Done():
+	}
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case sig := <-signals:
+			slog.Warn("received a second signal, exiting", "signal", sig.String())
+			l.exit(1)
+		case <-stopped:
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+	return errors.Join(failed, l.Stop(ctx))
+}
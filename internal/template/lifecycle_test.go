@@ -0,0 +1,85 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestLifecycle(t *testing.T) {
+	var calls []string
+	hook := func(name string, err error) Hook {
+		return Hook{
+			Name: name,
+			Start: func(context.Context) error {
+				calls = append(calls, "start "+name)
+				return err
+			},
+			Stop: func(context.Context) error {
+				calls = append(calls, "stop "+name)
+				return nil
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		hooks    []Hook
+		expected []string
+	}{
+		{
+			name:     "reverse order",
+			hooks:    []Hook{hook("db", nil), hook("server", nil)},
+			expected: []string{"start db", "start server", "stop server", "stop db"},
+		},
+		{
+			name:     "start failed",
+			hooks:    []Hook{hook("db", nil), hook("server", errors.New("listen")), hook("job", nil)},
+			expected: []string{"start db", "start server", "stop db"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls = nil
+			l := NewLifecycle(time.Second)
+			for _, h := range tc.hooks {
+				l.Append(h)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_ = l.run(ctx, nil)
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, calls)
+			}
+		})
+	}
+}
+
+func TestLifecycleSecondSignal(t *testing.T) {
+	l := NewLifecycle(time.Minute)
+	exited := make(chan int, 1)
+	l.exit = func(code int) { exited <- code }
+	stopping := make(chan struct{})
+	l.Go("job", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopping)
+		// never returns in time
+		time.Sleep(time.Minute)
+	})
+	signals := make(chan os.Signal, 2)
+	go func() { _ = l.run(context.Background(), signals) }()
+	signals <- syscall.SIGTERM
+	<-stopping
+	signals <- syscall.SIGTERM
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("Expected '%v', but got '%v'", 1, code)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected '%v', but got '%v'", "exit", "none")
+	}
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "github.com/tanveerprottoy/stdlib-go-template/internal/template/module/apikey/entity"
+
+type CreateAPIKeyDTO struct {
+	Name   string   `json:"name" validate:"required,max=128"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,required"`
+	// ExpiresIn is a duration, ex: 720h, the key doesn't expire if empty
+	ExpiresIn string `json:"expiresIn"`
+}
+
+// CreatedAPIKeyDTO is the response of the creation, the
+// only time the key is shown
+type CreatedAPIKeyDTO struct {
+	entity.APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,20 @@
+package entity
+
+import "github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+
+// APIKey is a stored api key, only the hash of the key is stored
+type APIKey struct {
+	ID   string `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+	// Prefix is the public part of the key, ex: sk_1a2b3c4d
+	Prefix  string `db:"prefix" json:"prefix"`
+	KeyHash string `db:"key_hash" json:"-"`
+	// Scopes are the permissions the key is limited to
+	Scopes postgres.StringArray `db:"scopes" json:"scopes"`
+	// UserID is the user the key acts on behalf of
+	UserID     string `db:"user_id" json:"userId"`
+	CreatedAt  int64  `db:"created_at" json:"createdAt"`
+	ExpiresAt  *int64 `db:"expires_at" json:"expiresAt,omitempty"`
+	LastUsedAt *int64 `db:"last_used_at" json:"lastUsedAt,omitempty"`
+	RevokedAt  *int64 `db:"revoked_at" json:"revokedAt,omitempty"`
+}
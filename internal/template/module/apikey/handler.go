@@ -0,0 +1,65 @@
+package apikey
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/contextext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/validatorext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/apikey/dto"
+)
+
+type Handler struct {
+	service  *Service
+	validate *validator.Validate
+}
+
+func NewHandler(s *Service, v *validator.Validate) *Handler {
+	h := new(Handler)
+	h.service = s
+	h.validate = v
+	return h
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var d dto.CreateAPIKeyDTO
+	validationErrs, err := validatorext.ParseValidateRequestBody(r.Body, &d, h.validate)
+	if validationErrs != nil {
+		response.RespondError(http.StatusBadRequest, constant.Errors, validationErrs, w)
+		return
+	}
+	if err != nil {
+		response.RespondError(http.StatusBadRequest, constant.Error, err, w)
+		return
+	}
+	p, _ := contextext.PrincipalFrom(r.Context())
+	e, httpErr := h.service.Create(d, p, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	// the key isn't stored, don't let anything cache it
+	w.Header().Set("Cache-Control", "no-store")
+	response.Respond(http.StatusCreated, e, w)
+}
+
+func (h *Handler) ReadMany(w http.ResponseWriter, r *http.Request) {
+	e, httpErr := h.service.ReadMany(r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, e, w)
+}
+
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	_, httpErr := h.service.Revoke(httpext.GetURLParam(r, constant.KeyId), r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
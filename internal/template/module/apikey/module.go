@@ -0,0 +1,20 @@
+package apikey
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
+)
+
+type Module struct {
+	Handler    *Handler
+	Service    *Service
+	Repository *Repository
+}
+
+func NewModule(db *sqlx.DB, validate *validator.Validate) *Module {
+	m := new(Module)
+	m.Repository = NewRepository(db)
+	m.Service = NewService(m.Repository)
+	m.Handler = NewHandler(m.Service, validate)
+	return m
+}
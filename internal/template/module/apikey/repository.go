@@ -0,0 +1,85 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/apikey/entity"
+)
+
+const tableName = "api_keys"
+
+// columns are the columns mapped to the entity
+var columns = []string{"id", "name", "prefix", "key_hash", "scopes", "user_id", "created_at", "expires_at", "last_used_at", "revoked_at"}
+
+// KeyRepository is the storage of the keys used by the service
+type KeyRepository interface {
+	Create(e entity.APIKey, ctx context.Context) (string, error)
+	ReadMany(ctx context.Context) ([]entity.APIKey, error)
+	ReadOneByPrefix(prefix string, ctx context.Context) (entity.APIKey, error)
+	Revoke(id string, at int64, ctx context.Context) (entity.APIKey, error)
+	Touch(id string, at, interval int64, ctx context.Context) error
+}
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	r := new(Repository)
+	r.db = db
+	return r
+}
+
+func (r *Repository) Create(e entity.APIKey, ctx context.Context) (string, error) {
+	var lastId string
+	q, args := postgres.Insert(tableName).
+		Columns("name", "prefix", "key_hash", "scopes", "user_id", "created_at", "expires_at").
+		Values(e.Name, e.Prefix, e.KeyHash, e.Scopes, e.UserID, e.CreatedAt, e.ExpiresAt).
+		Returning("id").
+		Build()
+	err := sqlxext.Conn(ctx, r.db).QueryRowContext(ctx, q, args...).Scan(&lastId)
+	return lastId, err
+}
+
+func (r *Repository) ReadMany(ctx context.Context) ([]entity.APIKey, error) {
+	d := []entity.APIKey{}
+	q, args := postgres.Select(columns...).From(tableName).OrderBy(postgres.Desc("created_at")).Build()
+	err := sqlxext.Conn(ctx, r.db).SelectContext(ctx, &d, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *Repository) ReadOneByPrefix(prefix string, ctx context.Context) (entity.APIKey, error) {
+	b := entity.APIKey{}
+	q, args := postgres.Select(columns...).From(tableName).Where(postgres.Eq("prefix", prefix)).Build()
+	err := sqlxext.Conn(ctx, r.db).GetContext(ctx, &b, q, args...)
+	return b, err
+}
+
+// Revoke revokes the key if it isn't yet and returns it
+func (r *Repository) Revoke(id string, at int64, ctx context.Context) (entity.APIKey, error) {
+	b := entity.APIKey{}
+	q, args := postgres.Update(tableName).
+		Set("revoked_at", at).
+		Where(postgres.Eq("id", id), postgres.IsNull("revoked_at")).
+		Returning(columns...).
+		Build()
+	err := sqlxext.Conn(ctx, r.db).GetContext(ctx, &b, q, args...)
+	return b, err
+}
+
+// Touch sets the last use of the key, at most once per
+// interval to not write on every request
+func (r *Repository) Touch(id string, at, interval int64, ctx context.Context) error {
+	q, args := postgres.Update(tableName).
+		Set("last_used_at", at).
+		Where(postgres.Eq("id", id), postgres.Or(postgres.IsNull("last_used_at"), postgres.Lt("last_used_at", at-interval))).
+		Build()
+	_, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	return err
+}
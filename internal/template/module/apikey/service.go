@@ -0,0 +1,175 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/cryptoext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/errorext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/rbac"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/apikey/dto"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/apikey/entity"
+	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
+)
+
+// keyPrefix starts every key so leaked keys are easy to scan for
+const keyPrefix = "sk_"
+
+// touchInterval is how often the last use of a key is written at most
+const touchInterval = time.Minute
+
+var (
+	errInvalidExpiresIn = errors.New("expiresIn has to be a positive duration, ex: 720h")
+	errScopeNotGranted  = errors.New("an api key can't create a key with scopes it isn't granted")
+)
+
+type Service struct {
+	repository KeyRepository
+	policy     func() *rbac.Policy
+}
+
+func NewService(r KeyRepository) *Service {
+	s := new(Service)
+	s.repository = r
+	return s
+}
+
+// SetPolicy sets the lookup of the policy in use, the requested
+// scopes have to be defined by it, ex: RBAC.Policy
+func (s *Service) SetPolicy(fn func() *rbac.Policy) {
+	s.policy = fn
+}
+
+// checkScopes rejects the scopes the policy doesn't define & when the
+// principal is an api key the ones its own scopes don't grant, so a key
+// can't mint a broader one
+func (s *Service) checkScopes(scopes []string, p authn.Principal) errorext.HTTPError {
+	for _, scope := range scopes {
+		if s.policy != nil && !s.policy().Names(scope) {
+			return errorext.HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("unknown scope %q", scope)}
+		}
+		if p.Kind == authn.KindAPIKey && !rbac.Grants(p.Scopes, scope) {
+			return errorext.HTTPError{Code: http.StatusForbidden, Err: errScopeNotGranted}
+		}
+	}
+	return errorext.HTTPError{}
+}
+
+// Create creates a key acting on behalf of the principal,
+// the key is in the result only, it can't be read later
+func (s *Service) Create(d dto.CreateAPIKeyDTO, p authn.Principal, ctx context.Context) (dto.CreatedAPIKeyDTO, errorext.HTTPError) {
+	var c dto.CreatedAPIKeyDTO
+	if httpErr := s.checkScopes(d.Scopes, p); httpErr.Err != nil {
+		return c, httpErr
+	}
+	now := timeext.NowUnixMilli()
+	e := entity.APIKey{Name: d.Name, Scopes: d.Scopes, UserID: p.Subject, CreatedAt: now}
+	if d.ExpiresIn != "" {
+		ttl, err := time.ParseDuration(d.ExpiresIn)
+		if err != nil || ttl <= 0 {
+			return c, errorext.HTTPError{Code: http.StatusBadRequest, Err: errInvalidExpiresIn}
+		}
+		expiresAt := now + ttl.Milliseconds()
+		e.ExpiresAt = &expiresAt
+	}
+	key, prefix, err := generateKey()
+	if err != nil {
+		return c, errorext.BuildDBError(err)
+	}
+	e.Prefix = prefix
+	e.KeyHash = cryptoext.HashToken(key)
+	e.ID, err = s.repository.Create(e, ctx)
+	if err != nil {
+		return c, errorext.BuildDBError(err)
+	}
+	return dto.CreatedAPIKeyDTO{APIKey: e, Key: key}, errorext.HTTPError{}
+}
+
+func (s *Service) ReadMany(ctx context.Context) ([]entity.APIKey, errorext.HTTPError) {
+	d, err := s.repository.ReadMany(ctx)
+	if err != nil {
+		return nil, errorext.BuildDBError(err)
+	}
+	return d, errorext.HTTPError{}
+}
+
+// Revoke revokes the key, it is not found if it is revoked already
+func (s *Service) Revoke(id string, ctx context.Context) (entity.APIKey, errorext.HTTPError) {
+	e, err := s.repository.Revoke(id, timeext.NowUnixMilli(), ctx)
+	if err != nil {
+		return e, errorext.BuildDBError(err)
+	}
+	return e, errorext.HTTPError{}
+}
+
+// Verify verifies the key as an authn.Verifier, the principal is the
+// user of the key limited to the scopes of the key
+func (s *Service) Verify(ctx context.Context, key string) (authn.Principal, error) {
+	prefix, ok := parseKey(key)
+	if !ok {
+		return authn.Principal{}, fmt.Errorf("%w: malformed api key", authn.ErrInvalidToken)
+	}
+	e, err := s.repository.ReadOneByPrefix(prefix, ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return authn.Principal{}, fmt.Errorf("%w: unknown api key", authn.ErrInvalidToken)
+		}
+		return authn.Principal{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(e.KeyHash), []byte(cryptoext.HashToken(key))) != 1 {
+		return authn.Principal{}, fmt.Errorf("%w: unknown api key", authn.ErrInvalidToken)
+	}
+	now := timeext.NowUnixMilli()
+	if e.RevokedAt != nil {
+		return authn.Principal{}, fmt.Errorf("%w: the api key is revoked", authn.ErrInvalidToken)
+	}
+	p := authn.Principal{Subject: e.UserID, TokenID: e.ID, Scopes: e.Scopes, Kind: authn.KindAPIKey}
+	if e.ExpiresAt != nil {
+		if *e.ExpiresAt <= now {
+			return authn.Principal{}, authn.ErrTokenExpired
+		}
+		p.ExpiresAt = time.UnixMilli(*e.ExpiresAt)
+	}
+	if err := s.repository.Touch(e.ID, now, touchInterval.Milliseconds(), ctx); err != nil {
+		// the key is valid, a failed bookkeeping doesn't fail the request
+		logext.FromContext(ctx).Error("touch api key failed", logext.Err(err))
+	}
+	return p, nil
+}
+
+// generateKey generates a key as sk_<prefix>_<secret>,
+// the prefix is stored as is to look up the key
+func generateKey() (key, prefix string, err error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	prefix = keyPrefix + hex.EncodeToString(b)
+	secret, err := cryptoext.RandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return prefix + "_" + secret, prefix, nil
+}
+
+// parseKey returns the prefix of the key
+func parseKey(key string) (string, bool) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return "", false
+	}
+	i := strings.IndexByte(key[len(keyPrefix):], '_')
+	if i != 8 || len(key) <= len(keyPrefix)+i+1 {
+		return "", false
+	}
+	return key[:len(keyPrefix)+i], true
+}
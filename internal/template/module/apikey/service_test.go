@@ -0,0 +1,158 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/cryptoext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/rbac"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/apikey/dto"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/apikey/entity"
+	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
+)
+
+type fakeRepository struct {
+	keys map[string]entity.APIKey
+}
+
+func (f *fakeRepository) Create(e entity.APIKey, ctx context.Context) (string, error) {
+	e.ID = e.Prefix
+	f.keys[e.Prefix] = e
+	return e.ID, nil
+}
+
+func (f *fakeRepository) ReadMany(ctx context.Context) ([]entity.APIKey, error) {
+	return nil, nil
+}
+
+func (f *fakeRepository) ReadOneByPrefix(prefix string, ctx context.Context) (entity.APIKey, error) {
+	e, ok := f.keys[prefix]
+	if !ok {
+		return e, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (f *fakeRepository) Revoke(id string, at int64, ctx context.Context) (entity.APIKey, error) {
+	e := f.keys[id]
+	e.RevokedAt = &at
+	f.keys[id] = e
+	return e, nil
+}
+
+func (f *fakeRepository) Touch(id string, at, interval int64, ctx context.Context) error {
+	return nil
+}
+
+func newTestService(t *testing.T) *Service {
+	p, err := rbac.NewPolicy(map[string]rbac.Role{
+		"admin":  {Permissions: []string{"*"}},
+		"user":   {Permissions: []string{"contents:read", "users:read", "apikeys:write"}},
+		"editor": {Permissions: []string{"contents:*"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(&fakeRepository{keys: make(map[string]entity.APIKey)})
+	s.SetPolicy(func() *rbac.Policy { return p })
+	return s
+}
+
+func TestParseKey(t *testing.T) {
+	key, prefix, err := generateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{name: "generated", key: key, expected: prefix},
+		{name: "secret with underscore", key: "sk_0a1b2c3d_a_b", expected: "sk_0a1b2c3d"},
+		{name: "no secret", key: "sk_0a1b2c3d_"},
+		{name: "short prefix", key: "sk_0a1b_secret"},
+		{name: "other", key: "Bearer token"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, _ := parseKey(tc.key); got != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCreateScopes(t *testing.T) {
+	user := authn.Principal{Subject: "1", Roles: []string{"admin"}}
+	key := authn.Principal{Subject: "1", Roles: []string{"admin"}, Kind: authn.KindAPIKey, Scopes: []string{"apikeys:write", "contents:read"}}
+	tests := []struct {
+		name      string
+		principal authn.Principal
+		scopes    []string
+		expected  int
+	}{
+		{name: "user", principal: user, scopes: []string{"*"}},
+		{name: "scope under a wildcard", principal: user, scopes: []string{"contents:restore"}},
+		{name: "undefined scope", principal: user, scopes: []string{"unknown:read"}, expected: http.StatusBadRequest},
+		{name: "key narrower", principal: key, scopes: []string{"contents:read"}},
+		{name: "key escalation", principal: key, scopes: []string{"*"}, expected: http.StatusForbidden},
+		{name: "key other scope", principal: key, scopes: []string{"contents:read", "users:read"}, expected: http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestService(t)
+			_, httpErr := s.Create(dto.CreateAPIKeyDTO{Name: "ci", Scopes: tc.scopes}, tc.principal, context.Background())
+			if httpErr.Code != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, httpErr.Code)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	created, httpErr := s.Create(dto.CreateAPIKeyDTO{Name: "ci", Scopes: []string{"contents:read"}}, authn.Principal{Subject: "1"}, ctx)
+	if httpErr.Err != nil {
+		t.Fatal(httpErr.Err)
+	}
+	p, err := s.Verify(ctx, created.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Subject != "1" || p.Kind != authn.KindAPIKey || len(p.Scopes) != 1 {
+		t.Errorf("Expected the principal of the key, but got '%v'", p)
+	}
+	repo := s.repository.(*fakeRepository)
+	expired := created.APIKey
+	expired.Prefix = "sk_0a1b2c3d"
+	expired.KeyHash = cryptoext.HashToken(expired.Prefix + "_secret")
+	at := timeext.NowUnixMilli() - 1
+	expired.ExpiresAt = &at
+	repo.keys[expired.Prefix] = expired
+	tests := []struct {
+		name     string
+		key      func() string
+		expected error
+	}{
+		{name: "wrong secret", key: func() string { return created.APIKey.Prefix + "_wrong" }, expected: authn.ErrInvalidToken},
+		{name: "unknown", key: func() string { return "sk_ffffffff_secret" }, expected: authn.ErrInvalidToken},
+		{name: "expired", key: func() string { return expired.Prefix + "_secret" }, expected: authn.ErrTokenExpired},
+		{name: "revoked", key: func() string {
+			s.Revoke(created.ID, ctx)
+			return created.Key
+		}, expected: authn.ErrInvalidToken},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := s.Verify(ctx, tc.key()); !errors.Is(err, tc.expected) {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, err)
+			}
+		})
+	}
+}
@@ -4,3 +4,39 @@ type AuthUserDTO struct {
 	Id    string `json:"id"`
 	Email string `json:"email"`
 }
+
+type RegisterDTO struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+	Phone string `json:"phone" validate:"required"`
+	Age   uint8  `json:"age" validate:"gte=0,lte=130"`
+	// bcrypt hashes 72 bytes at most, not runes
+	Password string `json:"password" validate:"required,min=8,bcryptmax"`
+}
+
+type LoginDTO struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// TokenDTO is the response of register & login
+type TokenDTO struct {
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+	// ExpiresIn is in seconds
+	ExpiresIn int64 `json:"expiresIn"`
+	// RefreshToken is opaque, it is rotated on every refresh
+	RefreshToken     string `json:"refreshToken"`
+	RefreshExpiresIn int64  `json:"refreshExpiresIn"`
+	UserID           string `json:"userId"`
+}
+
+type RefreshDTO struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// LogoutDTO is optional, the refresh token
+// family is revoked too when it is given
+type LogoutDTO struct {
+	RefreshToken string `json:"refreshToken"`
+}
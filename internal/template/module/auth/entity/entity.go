@@ -0,0 +1,19 @@
+package entity
+
+// RefreshToken is a stored refresh token, the token
+// itself is never stored only its hash
+type RefreshToken struct {
+	ID     string `db:"id" json:"id"`
+	UserID string `db:"user_id" json:"userId"`
+	// FamilyID is shared by the tokens rotated from the same login
+	FamilyID  string `db:"family_id" json:"familyId"`
+	TokenHash string `db:"token_hash" json:"-"`
+	// AccessJTI is the jti of the access token issued with it
+	AccessJTI       string `db:"access_jti" json:"-"`
+	AccessExpiresAt int64  `db:"access_expires_at" json:"-"`
+	ExpiresAt       int64  `db:"expires_at" json:"expiresAt"`
+	CreatedAt       int64  `db:"created_at" json:"createdAt"`
+	// UsedAt is set when it is rotated, using it again is a reuse
+	UsedAt    *int64 `db:"used_at" json:"usedAt,omitempty"`
+	RevokedAt *int64 `db:"revoked_at" json:"revokedAt,omitempty"`
+}
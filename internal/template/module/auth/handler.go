@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/contextext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/validatorext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth/dto"
+)
+
+type Handler struct {
+	service  *Service
+	validate *validator.Validate
+}
+
+func NewHandler(s *Service, v *validator.Validate) *Handler {
+	h := new(Handler)
+	h.service = s
+	h.validate = v
+	return h
+}
+
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	var d dto.RegisterDTO
+	validationErrs, err := validatorext.ParseValidateRequestBody(r.Body, &d, h.validate)
+	if validationErrs != nil {
+		response.RespondError(http.StatusBadRequest, constant.Errors, validationErrs, w)
+		return
+	}
+	if err != nil {
+		response.RespondError(http.StatusBadRequest, constant.Error, err, w)
+		return
+	}
+	t, httpErr := h.service.Register(d, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusCreated, t, w)
+}
+
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var d dto.LoginDTO
+	validationErrs, err := validatorext.ParseValidateRequestBody(r.Body, &d, h.validate)
+	if validationErrs != nil {
+		response.RespondError(http.StatusBadRequest, constant.Errors, validationErrs, w)
+		return
+	}
+	if err != nil {
+		response.RespondError(http.StatusBadRequest, constant.Error, err, w)
+		return
+	}
+	t, httpErr := h.service.Login(d, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, t, w)
+}
+
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var d dto.RefreshDTO
+	validationErrs, err := validatorext.ParseValidateRequestBody(r.Body, &d, h.validate)
+	if validationErrs != nil {
+		response.RespondError(http.StatusBadRequest, constant.Errors, validationErrs, w)
+		return
+	}
+	if err != nil {
+		response.RespondError(http.StatusBadRequest, constant.Error, err, w)
+		return
+	}
+	t, httpErr := h.service.Refresh(d, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, t, w)
+}
+
+// Logout revokes the access token of the request, the body
+// with the refresh token is optional
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	p, ok := contextext.PrincipalFrom(r.Context())
+	if !ok {
+		response.RespondError(http.StatusUnauthorized, constant.Error, constant.Unauthorized, w)
+		return
+	}
+	var d dto.LogoutDTO
+	if r.ContentLength != 0 {
+		err := httpext.ParseRequestBody(r.Body, &d)
+		if err != nil {
+			response.RespondError(http.StatusBadRequest, constant.Error, constant.InvalidRequestBody, w)
+			return
+		}
+	}
+	httpErr := h.service.Logout(p, d, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll revokes every token of the user of the request
+func (h *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	p, ok := contextext.PrincipalFrom(r.Context())
+	if !ok {
+		response.RespondError(http.StatusUnauthorized, constant.Error, constant.Unauthorized, w)
+		return
+	}
+	httpErr := h.service.LogoutAll(p, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// JWKS publishes the public signing keys so other services can
+// verify the tokens, it is empty while signing with HS256
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	d := h.service.JWKS()
+	// the keys are published ahead of signing so a short cache is fine
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	response.Respond(http.StatusOK, d, w)
+}
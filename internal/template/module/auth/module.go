@@ -1,13 +1,26 @@
 package auth
 
-import "github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user"
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user"
+)
 
 type Module struct {
-	Service *Service
+	Handler    *Handler
+	Service    *Service
+	Repository *Repository
 }
 
-func NewModule(s *user.Service) *Module {
+func NewModule(db *sqlx.DB, tm *postgres.TxManager, s *user.Service, v authn.Verifier, signer *jwtext.Signer, accessTTL, refreshTTL time.Duration, validate *validator.Validate) *Module {
 	m := new(Module)
-	m.Service = NewService(s)
+	m.Repository = NewRepository(db)
+	m.Service = NewService(s, m.Repository, tm, v, signer, accessTTL, refreshTTL)
+	m.Handler = NewHandler(m.Service, validate)
 	return m
 }
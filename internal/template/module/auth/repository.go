@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth/entity"
+)
+
+const (
+	refreshTokensTable = "refresh_tokens"
+	revokedTokensTable = "revoked_tokens"
+)
+
+// refreshTokenColumns are the columns mapped to entity.RefreshToken
+var refreshTokenColumns = []string{"id", "user_id", "family_id", "token_hash", "access_jti", "access_expires_at", "expires_at", "created_at", "used_at", "revoked_at"}
+
+// TokenRepository is the storage of the tokens used by the service
+type TokenRepository interface {
+	CreateRefreshToken(e entity.RefreshToken, ctx context.Context) (string, error)
+	ReadRefreshTokenForUpdate(hash string, ctx context.Context) (entity.RefreshToken, error)
+	MarkRefreshTokenUsed(id string, at int64, ctx context.Context) (int64, error)
+	RevokeFamily(familyID string, at int64, ctx context.Context) ([]entity.RefreshToken, error)
+	RevokeUser(userID string, at int64, ctx context.Context) ([]entity.RefreshToken, error)
+	Deny(jti string, expiresAt int64, ctx context.Context) error
+	IsDenied(jti string, ctx context.Context) (bool, error)
+}
+
+// Repository stores the refresh tokens & the
+// jti denylist of the revoked access tokens
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	r := new(Repository)
+	r.db = db
+	return r
+}
+
+func (r *Repository) CreateRefreshToken(e entity.RefreshToken, ctx context.Context) (string, error) {
+	var lastId string
+	q, args := postgres.Insert(refreshTokensTable).
+		Columns("user_id", "family_id", "token_hash", "access_jti", "access_expires_at", "expires_at", "created_at").
+		Values(e.UserID, e.FamilyID, e.TokenHash, e.AccessJTI, e.AccessExpiresAt, e.ExpiresAt, e.CreatedAt).
+		Returning("id").
+		Build()
+	err := sqlxext.Conn(ctx, r.db).QueryRowContext(ctx, q, args...).Scan(&lastId)
+	return lastId, err
+}
+
+// ReadRefreshTokenForUpdate reads & locks the token of the hash
+// so concurrent refreshes of the same token are serialized
+func (r *Repository) ReadRefreshTokenForUpdate(hash string, ctx context.Context) (entity.RefreshToken, error) {
+	b := entity.RefreshToken{}
+	q, args := postgres.Select(refreshTokenColumns...).
+		From(refreshTokensTable).
+		Where(postgres.Eq("token_hash", hash)).
+		ForUpdate().
+		Build()
+	err := sqlxext.Conn(ctx, r.db).GetContext(ctx, &b, q, args...)
+	return b, err
+}
+
+// MarkRefreshTokenUsed marks the token as rotated
+func (r *Repository) MarkRefreshTokenUsed(id string, at int64, ctx context.Context) (int64, error) {
+	q, args := postgres.Update(refreshTokensTable).
+		Set("used_at", at).
+		Where(postgres.Eq("id", id), postgres.IsNull("used_at")).
+		Build()
+	res, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	if err != nil {
+		return -1, err
+	}
+	return sqlxext.GetRowsAffected(res), nil
+}
+
+// RevokeFamily revokes the tokens of the family which are not
+// revoked yet and returns them
+func (r *Repository) RevokeFamily(familyID string, at int64, ctx context.Context) ([]entity.RefreshToken, error) {
+	return r.revoke(postgres.Eq("family_id", familyID), at, ctx)
+}
+
+// RevokeUser revokes every token of the user which is
+// not revoked yet and returns them
+func (r *Repository) RevokeUser(userID string, at int64, ctx context.Context) ([]entity.RefreshToken, error) {
+	return r.revoke(postgres.Eq("user_id", userID), at, ctx)
+}
+
+func (r *Repository) revoke(where postgres.Expr, at int64, ctx context.Context) ([]entity.RefreshToken, error) {
+	d := []entity.RefreshToken{}
+	q, args := postgres.Update(refreshTokensTable).
+		Set("revoked_at", at).
+		Where(where, postgres.IsNull("revoked_at")).
+		Returning(refreshTokenColumns...).
+		Build()
+	err := sqlxext.Conn(ctx, r.db).SelectContext(ctx, &d, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Deny adds the jti to the denylist until it expires
+func (r *Repository) Deny(jti string, expiresAt int64, ctx context.Context) error {
+	q, args := postgres.Insert(revokedTokensTable).
+		Columns("jti", "expires_at").
+		Values(jti, expiresAt).
+		OnConflict("jti").
+		DoNothing().
+		Build()
+	_, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	return err
+}
+
+// IsDenied reports whether the jti is on the denylist
+func (r *Repository) IsDenied(jti string, ctx context.Context) (bool, error) {
+	var ok bool
+	q, args := postgres.Select().
+		Column(postgres.Exists(postgres.Select("jti").From(revokedTokensTable).Where(postgres.Eq("jti", jti)))).
+		Build()
+	err := sqlxext.Conn(ctx, r.db).QueryRowContext(ctx, q, args...).Scan(&ok)
+	return ok, err
+}
+
+// Purge permanently deletes at most limit refresh tokens
+// & denylist entries each, which expired before the time
+func (r *Repository) Purge(before int64, limit int, ctx context.Context) (int64, error) {
+	var total int64
+	tables := []struct{ name, key string }{{refreshTokensTable, "id"}, {revokedTokensTable, "jti"}}
+	for _, t := range tables {
+		q, args := postgres.Delete(t.name).Where(postgres.In(t.key, postgres.Select(t.key).
+			From(t.name).
+			Where(postgres.Lt("expires_at", before)).
+			Limit(limit))).
+			Build()
+		res, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+		if err != nil {
+			return total, err
+		}
+		total += sqlxext.GetRowsAffected(res)
+	}
+	return total, nil
+}
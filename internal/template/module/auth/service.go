@@ -2,58 +2,380 @@ package auth
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user/entity"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/cryptoext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/errorext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth/dto"
+	authentity "github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth/entity"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user/entity"
+	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// tokenType is the type of the issued access tokens
+const tokenType = "Bearer"
+
+// refreshTokenBytes is the entropy of the refresh tokens
+const refreshTokenBytes = 32
+
+var (
+	errInvalidCredentials  = errors.New("invalid email or password")
+	errAccountInactive     = errors.New("the account is not active")
+	errAccountExists       = errors.New("the email or phone is already registered")
+	errInvalidRefreshToken = errors.New("invalid refresh token")
+	errRefreshTokenReused  = errors.New("the refresh token was already used, log in again")
+	errTokenRevoked        = errors.New("the token is revoked")
+	errPasswordTooLong     = errors.New("the password is longer than 72 bytes")
+)
+
+// dummyHash is compared against when the user does not exist
+// so login takes the same time either way
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
 )
 
 type Service struct {
 	userService *user.Service
+	repository  TokenRepository
+	txManager   *postgres.TxManager
+	verifier    authn.Verifier
+	signer      *jwtext.Signer
+	apiKeys     authn.Verifier
+	userRoles   func(userID string) []string
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
 }
 
-func NewService(userService *user.Service) *Service {
+func NewService(userService *user.Service, r TokenRepository, tm *postgres.TxManager, v authn.Verifier, signer *jwtext.Signer, accessTTL, refreshTTL time.Duration) *Service {
 	s := new(Service)
 	s.userService = userService
+	s.repository = r
+	s.txManager = tm
+	s.verifier = v
+	s.signer = signer
+	s.accessTTL = accessTTL
+	s.refreshTTL = refreshTTL
 	return s
 }
 
-func (s *Service) Authorize(r *http.Request) (entity.User, error) {
-	var e entity.User
-	splits, err := httpext.ParseAuthToken(r)
+// Register creates an active user with the password
+// and logs it in, the email has to be verified later
+func (s *Service) Register(d dto.RegisterDTO, ctx context.Context) (dto.TokenDTO, errorext.HTTPError) {
+	var t dto.TokenDTO
+	h, err := cryptoext.GenerateHashFromPassword(d.Password)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return t, errorext.HTTPError{Code: http.StatusBadRequest, Err: errPasswordTooLong}
+	}
 	if err != nil {
-		return e, err
+		return t, errorext.HTTPError{Code: http.StatusInternalServerError, Err: errors.New(constant.InternalServerError)}
 	}
-	tokenBody := splits[1]
-	claims, err := jwtext.VerifyToken1(tokenBody)
+	age := int16(d.Age)
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		e, err := s.userService.CreateInternal(entity.User{
+			Name:         d.Name,
+			Email:        &d.Email,
+			Phone:        &d.Phone,
+			Age:          &age,
+			PasswordHash: &h,
+		}, ctx)
+		if err != nil {
+			return err
+		}
+		t, err = s.issueTokens(e, "", ctx)
+		return err
+	})
 	if err != nil {
-		return e, err
+		httpErr := errorext.BuildDBError(err)
+		if httpErr.Code == http.StatusConflict {
+			httpErr.Err = errAccountExists
+		}
+		return t, httpErr
+	}
+	return t, errorext.HTTPError{}
+}
+
+// Login verifies the credentials and issues a token pair, the
+// error doesn't tell whether the email or the password was wrong
+func (s *Service) Login(d dto.LoginDTO, ctx context.Context) (dto.TokenDTO, errorext.HTTPError) {
+	var t dto.TokenDTO
+	e, err := s.userService.ReadOneByEmailInternal(d.Email, ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return t, errorext.BuildDBError(err)
+	}
+	if err != nil || e.PasswordHash == nil {
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = cryptoext.GenerateHashFromPassword("dummy password")
+		})
+		cryptoext.CompareHashAndPassword(dummyHash, d.Password)
+		return t, errorext.HTTPError{Code: http.StatusUnauthorized, Err: errInvalidCredentials}
 	}
-	// find user
-	e, err = s.userService.ReadOneInternal(claims.Payload.Id, r.Context())
+	if !cryptoext.CompareHashAndPassword(*e.PasswordHash, d.Password) {
+		return t, errorext.HTTPError{Code: http.StatusUnauthorized, Err: errInvalidCredentials}
+	}
+	if e.Status != constant.UserStatusActive {
+		return t, errorext.HTTPError{Code: http.StatusForbidden, Err: errAccountInactive}
+	}
+	t, err = s.issueTokens(e, "", ctx)
+	if err != nil {
+		return t, errorext.BuildDBError(err)
+	}
+	return t, errorext.HTTPError{}
+}
+
+// Refresh rotates the refresh token, presenting a rotated token
+// again revokes its whole family as it may have been stolen
+func (s *Service) Refresh(d dto.RefreshDTO, ctx context.Context) (dto.TokenDTO, errorext.HTTPError) {
+	var t dto.TokenDTO
+	reused := false
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		rt, err := s.repository.ReadRefreshTokenForUpdate(cryptoext.HashToken(d.RefreshToken), ctx)
+		if err != nil {
+			return err
+		}
+		now := timeext.NowUnixMilli()
+		if rt.UsedAt != nil {
+			// the revocation must commit so no error is returned
+			reused = true
+			revoked, err := s.repository.RevokeFamily(rt.FamilyID, now, ctx)
+			if err != nil {
+				return err
+			}
+			return s.denyAccessTokens(revoked, now, ctx)
+		}
+		if rt.RevokedAt != nil || rt.ExpiresAt <= now {
+			return errInvalidRefreshToken
+		}
+		e, err := s.userService.ReadOneInternal(rt.UserID, ctx)
+		if err != nil {
+			return err
+		}
+		if e.Status != constant.UserStatusActive {
+			return errAccountInactive
+		}
+		if _, err := s.repository.MarkRefreshTokenUsed(rt.ID, now, ctx); err != nil {
+			return err
+		}
+		t, err = s.issueTokens(e, rt.FamilyID, ctx)
+		return err
+	})
+	switch {
+	case err == nil && reused:
+		return t, errorext.HTTPError{Code: http.StatusUnauthorized, Err: errRefreshTokenReused}
+	case err == nil:
+		return t, errorext.HTTPError{}
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, errInvalidRefreshToken):
+		return t, errorext.HTTPError{Code: http.StatusUnauthorized, Err: errInvalidRefreshToken}
+	case errors.Is(err, errAccountInactive):
+		return t, errorext.HTTPError{Code: http.StatusForbidden, Err: errAccountInactive}
+	}
+	return t, errorext.BuildDBError(err)
+}
+
+// Logout revokes the access token of the principal and the
+// family of the refresh token if given, it is idempotent
+func (s *Service) Logout(p authn.Principal, d dto.LogoutDTO, ctx context.Context) errorext.HTTPError {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.denyPrincipal(p, ctx); err != nil {
+			return err
+		}
+		if d.RefreshToken == "" {
+			return nil
+		}
+		rt, err := s.repository.ReadRefreshTokenForUpdate(cryptoext.HashToken(d.RefreshToken), ctx)
+		if err == sql.ErrNoRows || (err == nil && rt.UserID != p.Subject) {
+			// nothing to revoke for this user
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		now := timeext.NowUnixMilli()
+		revoked, err := s.repository.RevokeFamily(rt.FamilyID, now, ctx)
+		if err != nil {
+			return err
+		}
+		return s.denyAccessTokens(revoked, now, ctx)
+	})
 	if err != nil {
-		return e, err
+		return errorext.BuildDBError(err)
 	}
-	ctx := context.WithValue(r.Context(), constant.KeyAuthUser, e)
-	_ = r.WithContext(ctx)
-	return e, nil
+	return errorext.HTTPError{}
 }
 
-func (s *Service) AuthorizeForRoleBasic(r *http.Request) (entity.User, error) {
+// LogoutAll revokes every token of the user of the principal
+func (s *Service) LogoutAll(p authn.Principal, ctx context.Context) errorext.HTTPError {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.denyPrincipal(p, ctx); err != nil {
+			return err
+		}
+		now := timeext.NowUnixMilli()
+		revoked, err := s.repository.RevokeUser(p.Subject, now, ctx)
+		if err != nil {
+			return err
+		}
+		return s.denyAccessTokens(revoked, now, ctx)
+	})
+	if err != nil {
+		return errorext.BuildDBError(err)
+	}
+	return errorext.HTTPError{}
+}
+
+// issueTokens issues an access token and a refresh token in the
+// family, a new family is started when familyID is empty
+func (s *Service) issueTokens(e entity.User, familyID string, ctx context.Context) (dto.TokenDTO, error) {
+	var t dto.TokenDTO
+	access, claims, err := s.signer.NewToken(jwtext.Payload{Id: e.ID}, s.accessTTL)
+	if err != nil {
+		return t, err
+	}
+	refresh, err := cryptoext.RandomToken(refreshTokenBytes)
+	if err != nil {
+		return t, err
+	}
+	if familyID == "" {
+		familyID = uuid.NewString()
+	}
+	n := timeext.NowUnixMilli()
+	_, err = s.repository.CreateRefreshToken(authentity.RefreshToken{
+		UserID:          e.ID,
+		FamilyID:        familyID,
+		TokenHash:       cryptoext.HashToken(refresh),
+		AccessJTI:       claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.UnixMilli(),
+		ExpiresAt:       n + s.refreshTTL.Milliseconds(),
+		CreatedAt:       n,
+	}, ctx)
+	if err != nil {
+		return t, err
+	}
+	t.AccessToken = access
+	t.TokenType = tokenType
+	t.ExpiresIn = int64(s.accessTTL.Seconds())
+	t.RefreshToken = refresh
+	t.RefreshExpiresIn = int64(s.refreshTTL.Seconds())
+	t.UserID = e.ID
+	return t, nil
+}
+
+// denyAccessTokens denylists the access tokens issued
+// with the refresh tokens which have not expired yet
+func (s *Service) denyAccessTokens(tokens []authentity.RefreshToken, now int64, ctx context.Context) error {
+	for _, rt := range tokens {
+		if rt.AccessExpiresAt <= now {
+			continue
+		}
+		if err := s.repository.Deny(rt.AccessJTI, rt.AccessExpiresAt, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) denyPrincipal(p authn.Principal, ctx context.Context) error {
+	if p.TokenID == "" {
+		// tokens without jti can't be revoked
+		return nil
+	}
+	return s.repository.Deny(p.TokenID, p.ExpiresAt.UnixMilli(), ctx)
+}
+
+// Authenticate verifies the bearer token of the request with the
+// verifier & the denylist, or its api key, and returns its active user
+// & principal, the subject of a token of this app has to be the id of
+// a local user, the tokens of another issuer have their own roles
+func (s *Service) Authenticate(r *http.Request) (entity.User, authn.Principal, error) {
 	var e entity.User
-	role := r.Header.Get("role")
-	if role == "" {
-		// role is missing
-		return e, errors.New("role is missing")
+	if key := r.Header.Get(constant.HeaderAPIKey); key != "" && s.apiKeys != nil {
+		p, err := s.apiKeys.Verify(r.Context(), key)
+		if err != nil {
+			return e, p, err
+		}
+		return s.authenticateUser(p, r.Context())
+	}
+	splits, err := httpext.ParseAuthToken(r)
+	if err != nil {
+		return e, authn.Principal{}, err
+	}
+	p, err := s.verifier.Verify(r.Context(), splits[1])
+	if err != nil {
+		return e, p, err
+	}
+	if p.TokenID != "" {
+		denied, err := s.repository.IsDenied(p.TokenID, r.Context())
+		if err != nil {
+			return e, p, err
+		}
+		if denied {
+			return e, p, errTokenRevoked
+		}
 	}
-	// find user
-	e, err := s.userService.ReadOneInternal(r.Header.Get("id"), r.Context())
+	if _, ok := s.verifier.(*authn.LocalVerifier); !ok {
+		e, p = s.externalUser(p)
+		return e, p, nil
+	}
+	return s.authenticateUser(p, r.Context())
+}
+
+// externalUser is the user of a principal verified by the jwks or the
+// introspection verifier, it has no local user so the roles of the
+// claims are kept along the ones assigned to the subject by the policy
+func (s *Service) externalUser(p authn.Principal) (entity.User, authn.Principal) {
+	if s.userRoles != nil && p.Subject != "" {
+		p.Roles = append(append([]string{}, p.Roles...), s.userRoles(p.Subject)...)
+	}
+	return entity.User{ID: p.Subject, Status: constant.UserStatusActive}, p
+}
+
+// authenticateUser finds the active user of the principal
+// & sets the roles of the principal from it
+func (s *Service) authenticateUser(p authn.Principal, ctx context.Context) (entity.User, authn.Principal, error) {
+	e, err := s.userService.ReadOneInternal(p.Subject, ctx)
 	if err != nil {
-		return e, err
+		return e, p, err
+	}
+	if e.Status != constant.UserStatusActive {
+		return e, p, errAccountInactive
 	}
-	return e, nil
+	// the roles of the local user are authoritative
+	p.Roles = []string{e.Role}
+	if s.userRoles != nil {
+		p.Roles = append(p.Roles, s.userRoles(e.ID)...)
+	}
+	return e, p, nil
+}
+
+// SetAPIKeys sets the verifier of the X-API-Key header,
+// the header is ignored when it isn't set
+func (s *Service) SetAPIKeys(v authn.Verifier) {
+	s.apiKeys = v
+}
+
+// JWKS returns the public keys the access tokens are signed with
+func (s *Service) JWKS() jwtext.JWKS {
+	return s.signer.JWKS()
+}
+
+// SetUserRoles sets the source of the roles assigned
+// to the users on top of their own role
+func (s *Service) SetUserRoles(fn func(userID string) []string) {
+	s.userRoles = fn
+}
+
+func (s *Service) Authorize(r *http.Request) (entity.User, error) {
+	e, _, err := s.Authenticate(r)
+	return e, err
 }
@@ -7,20 +7,25 @@ import (
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth/dto"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
 )
 
 type ServiceRemote struct {
 	ClientProvider *httpext.ClientProvider
+	// BaseURL is the url of the user service, see config.Services
+	BaseURL string
 }
 
-func NewServiceRemote(c *httpext.ClientProvider) *ServiceRemote {
+func NewServiceRemote(c *httpext.ClientProvider, baseURL string) *ServiceRemote {
 	s := new(ServiceRemote)
 	s.ClientProvider = c
+	s.BaseURL = baseURL
 	return s
 }
 
+// Authorize asks the remote auth service for the user of the token
+//
+// Deprecated: use authn.IntrospectionVerifier, AUTH_VERIFIER=introspection
 func (s *ServiceRemote) Authorize(w http.ResponseWriter, r *http.Request) any {
 	_, err := httpext.ParseAuthToken(r)
 	if err != nil {
@@ -29,10 +34,11 @@ func (s *ServiceRemote) Authorize(w http.ResponseWriter, r *http.Request) any {
 	}
 	u, httpErr, err := httpext.Request[dto.AuthUserDTO](
 		http.MethodPost,
-		fmt.Sprintf("%s%s", config.GetEnvValue("USER_SERVICE_BASE_URL"), constant.UserServiceAuthEndpoint),
+		fmt.Sprintf("%s%s", s.BaseURL, constant.UserServiceAuthEndpoint),
 		r.Header,
 		nil,
 		s.ClientProvider,
+		r.Context(),
 	)
 	if err != nil {
 		response.RespondError(http.StatusForbidden, constant.Error, err, w)
@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/cryptoext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres/postgrestest"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/validatorext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth/dto"
+	authentity "github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth/entity"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user/entity"
+)
+
+// fakeTokenRepository keeps the refresh tokens & the denylist in memory
+type fakeTokenRepository struct {
+	tokens map[string]authentity.RefreshToken
+	denied map[string]int64
+}
+
+func (f *fakeTokenRepository) CreateRefreshToken(e authentity.RefreshToken, ctx context.Context) (string, error) {
+	e.ID = strconv.Itoa(len(f.tokens) + 1)
+	f.tokens[e.ID] = e
+	return e.ID, nil
+}
+
+func (f *fakeTokenRepository) ReadRefreshTokenForUpdate(hash string, ctx context.Context) (authentity.RefreshToken, error) {
+	for _, e := range f.tokens {
+		if e.TokenHash == hash {
+			return e, nil
+		}
+	}
+	return authentity.RefreshToken{}, sql.ErrNoRows
+}
+
+func (f *fakeTokenRepository) MarkRefreshTokenUsed(id string, at int64, ctx context.Context) (int64, error) {
+	e := f.tokens[id]
+	if e.UsedAt != nil {
+		return 0, nil
+	}
+	e.UsedAt = &at
+	f.tokens[id] = e
+	return 1, nil
+}
+
+func (f *fakeTokenRepository) RevokeFamily(familyID string, at int64, ctx context.Context) ([]authentity.RefreshToken, error) {
+	return f.revoke(func(e authentity.RefreshToken) bool { return e.FamilyID == familyID }, at), nil
+}
+
+func (f *fakeTokenRepository) RevokeUser(userID string, at int64, ctx context.Context) ([]authentity.RefreshToken, error) {
+	return f.revoke(func(e authentity.RefreshToken) bool { return e.UserID == userID }, at), nil
+}
+
+func (f *fakeTokenRepository) revoke(match func(e authentity.RefreshToken) bool, at int64) []authentity.RefreshToken {
+	var d []authentity.RefreshToken
+	for id, e := range f.tokens {
+		if !match(e) || e.RevokedAt != nil {
+			continue
+		}
+		e.RevokedAt = &at
+		f.tokens[id] = e
+		d = append(d, e)
+	}
+	return d
+}
+
+func (f *fakeTokenRepository) Deny(jti string, expiresAt int64, ctx context.Context) error {
+	f.denied[jti] = expiresAt
+	return nil
+}
+
+func (f *fakeTokenRepository) IsDenied(jti string, ctx context.Context) (bool, error) {
+	_, ok := f.denied[jti]
+	return ok, nil
+}
+
+// fakeAccountRepository reads the users from memory, the
+// methods the service tests don't use aren't implemented
+type fakeAccountRepository struct {
+	user.AccountRepository
+	users map[string]entity.User
+}
+
+func (f *fakeAccountRepository) ReadOne(id string, ctx context.Context) (entity.User, error) {
+	e, ok := f.users[id]
+	if !ok {
+		return e, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func newTestService() (*Service, *fakeTokenRepository) {
+	db, _ := postgrestest.NewDB()
+	tm := postgres.NewTxManager(db)
+	users := &fakeAccountRepository{users: map[string]entity.User{
+		"1": {ID: "1", Role: "user", Status: constant.UserStatusActive},
+	}}
+	r := &fakeTokenRepository{tokens: map[string]authentity.RefreshToken{}, denied: map[string]int64{}}
+	signer := jwtext.NewSigner("iss", "")
+	signer.UseSecret("secret")
+	v := authn.NewLocalVerifier(signer, authn.Rules{Issuer: "iss"})
+	return NewService(user.NewService(users, tm, nil), r, tm, v, signer, time.Minute, time.Hour), r
+}
+
+// login issues a token pair for the user in a new family
+func login(t *testing.T, s *Service) dto.TokenDTO {
+	t.Helper()
+	tk, err := s.issueTokens(entity.User{ID: "1"}, "", context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tk
+}
+
+func authenticate(s *Service, token string) (authn.Principal, error) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", tokenType+" "+token)
+	_, p, err := s.Authenticate(r)
+	return p, err
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	s, r := newTestService()
+	first := login(t, s)
+	// rotation
+	second, httpErr := s.Refresh(dto.RefreshDTO{RefreshToken: first.RefreshToken}, ctx)
+	if httpErr.Err != nil {
+		t.Fatal(httpErr.Err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Errorf("Expected a new token pair, but got '%v'", second)
+	}
+	old, _ := r.ReadRefreshTokenForUpdate(cryptoext.HashToken(first.RefreshToken), ctx)
+	rotated, _ := r.ReadRefreshTokenForUpdate(cryptoext.HashToken(second.RefreshToken), ctx)
+	if old.UsedAt == nil {
+		t.Errorf("Expected the rotated token used, but got '%v'", old.UsedAt)
+	}
+	if rotated.FamilyID != old.FamilyID {
+		t.Errorf("Expected '%v', but got '%v'", old.FamilyID, rotated.FamilyID)
+	}
+	if _, err := authenticate(s, second.AccessToken); err != nil {
+		t.Errorf("Expected '%v', but got '%v'", nil, err)
+	}
+	// reuse of the rotated token revokes the whole family
+	_, httpErr = s.Refresh(dto.RefreshDTO{RefreshToken: first.RefreshToken}, ctx)
+	if httpErr.Code != http.StatusUnauthorized || !errors.Is(httpErr.Err, errRefreshTokenReused) {
+		t.Errorf("Expected '%v', but got '%v' '%v'", errRefreshTokenReused, httpErr.Code, httpErr.Err)
+	}
+	for _, e := range r.tokens {
+		if e.RevokedAt == nil {
+			t.Errorf("Expected the token '%v' revoked, but got '%v'", e.ID, e.RevokedAt)
+		}
+	}
+	if _, ok := r.denied[rotated.AccessJTI]; !ok {
+		t.Errorf("Expected '%v' denied, but got '%v'", rotated.AccessJTI, r.denied)
+	}
+	if _, err := authenticate(s, second.AccessToken); !errors.Is(err, errTokenRevoked) {
+		t.Errorf("Expected '%v', but got '%v'", errTokenRevoked, err)
+	}
+	_, httpErr = s.Refresh(dto.RefreshDTO{RefreshToken: second.RefreshToken}, ctx)
+	if httpErr.Code != http.StatusUnauthorized || !errors.Is(httpErr.Err, errInvalidRefreshToken) {
+		t.Errorf("Expected '%v', but got '%v' '%v'", errInvalidRefreshToken, httpErr.Code, httpErr.Err)
+	}
+	_, httpErr = s.Refresh(dto.RefreshDTO{RefreshToken: "unknown"}, ctx)
+	if httpErr.Code != http.StatusUnauthorized || !errors.Is(httpErr.Err, errInvalidRefreshToken) {
+		t.Errorf("Expected '%v', but got '%v' '%v'", errInvalidRefreshToken, httpErr.Code, httpErr.Err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+	s, r := newTestService()
+	tk := login(t, s)
+	p, err := authenticate(s, tk.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if httpErr := s.Logout(p, dto.LogoutDTO{RefreshToken: tk.RefreshToken}, ctx); httpErr.Err != nil {
+		t.Fatal(httpErr.Err)
+	}
+	// the denied jti is rejected
+	if _, err := authenticate(s, tk.AccessToken); !errors.Is(err, errTokenRevoked) {
+		t.Errorf("Expected '%v', but got '%v'", errTokenRevoked, err)
+	}
+	_, httpErr := s.Refresh(dto.RefreshDTO{RefreshToken: tk.RefreshToken}, ctx)
+	if !errors.Is(httpErr.Err, errInvalidRefreshToken) {
+		t.Errorf("Expected '%v', but got '%v'", errInvalidRefreshToken, httpErr.Err)
+	}
+	// logging out again is a no op
+	if httpErr := s.Logout(p, dto.LogoutDTO{RefreshToken: tk.RefreshToken}, ctx); httpErr.Err != nil {
+		t.Errorf("Expected '%v', but got '%v'", nil, httpErr.Err)
+	}
+	if len(r.denied) != 1 {
+		t.Errorf("Expected '%v', but got '%v'", 1, len(r.denied))
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	ctx := context.Background()
+	s, r := newTestService()
+	a, b := login(t, s), login(t, s)
+	p, err := authenticate(s, a.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if httpErr := s.LogoutAll(p, ctx); httpErr.Err != nil {
+		t.Fatal(httpErr.Err)
+	}
+	for _, tk := range []dto.TokenDTO{a, b} {
+		if _, err := authenticate(s, tk.AccessToken); !errors.Is(err, errTokenRevoked) {
+			t.Errorf("Expected '%v', but got '%v'", errTokenRevoked, err)
+		}
+	}
+	for _, e := range r.tokens {
+		if e.RevokedAt == nil {
+			t.Errorf("Expected the token '%v' revoked, but got '%v'", e.ID, e.RevokedAt)
+		}
+	}
+}
+
+func TestRegisterPasswordBytes(t *testing.T) {
+	v := validator.New()
+	_ = v.RegisterValidation("bcryptmax", validatorext.BcryptMax)
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{name: "ascii", password: strings.Repeat("a", 72), valid: true},
+		{name: "ascii too long", password: strings.Repeat("a", 73)},
+		// 40 runes but 80 bytes
+		{name: "multibyte too long", password: strings.Repeat("é", 40)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := dto.RegisterDTO{Name: "a", Email: "a@b.c", Phone: "1", Password: tc.password}
+			if err := v.Struct(d); (err == nil) != tc.valid {
+				t.Errorf("Expected '%v', but got '%v'", tc.valid, err)
+			}
+		})
+	}
+	// the service doesn't rely on the validation
+	s, _ := newTestService()
+	_, httpErr := s.Register(dto.RegisterDTO{Password: strings.Repeat("é", 40)}, context.Background())
+	if httpErr.Code != http.StatusBadRequest || !errors.Is(httpErr.Err, errPasswordTooLong) {
+		t.Errorf("Expected '%v', but got '%v' '%v'", errPasswordTooLong, httpErr.Code, httpErr.Err)
+	}
+}
@@ -2,4 +2,6 @@ package dto
 
 type CreateUpdateContentDTO struct {
 	Name string `json:"name" validate:"required"`
+	// Published is kept as is by an update when not set
+	Published *bool `json:"published"`
 }
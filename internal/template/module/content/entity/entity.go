@@ -1,18 +1,25 @@
 package entity
 
-import "database/sql"
+import "strconv"
 
 type Content struct {
 	ID        string `db:"id" json:"id"`
 	Name      string `db:"name" json:"name"`
 	CreatedAt int64  `db:"created_at" json:"createdAt"`
 	UpdatedAt int64  `db:"updated_at" json:"updatedAt"`
+	// UserID is the owner, nil for the contents created before
+	// owners were recorded or whose owner was deleted
+	UserID *string `db:"user_id" json:"userId,omitempty"`
+	// Published contents are readable by anyone
+	Published bool `db:"published" json:"published"`
+	// Version is incremented on every update
+	Version int64 `db:"version" json:"version"`
+	// DeletedAt & DeletedBy are set on soft delete
+	DeletedAt *int64  `db:"deleted_at" json:"deletedAt,omitempty"`
+	DeletedBy *string `db:"deleted_by" json:"deletedBy,omitempty"`
 }
 
-func (c *Content) ScanRows(rows *sql.Rows) error {
-	return nil
+// ETag is the strong entity tag of the current version
+func (e Content) ETag() string {
+	return strconv.Quote(strconv.FormatInt(e.Version, 10))
 }
-
-func (c *Content) ScanRow(row *sql.Row) error {
-	return nil
-}
\ No newline at end of file
@@ -1,18 +1,25 @@
 package content
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/contextext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/errorext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/validatorext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content/dto"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/adapter"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content/entity"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
 )
 
+// listSchema is the whitelist of the filter, sort & fields query params
+var listSchema = httpext.NewSchema(entity.Content{})
+
 // Hanlder is responsible for extracting data
 // from request body and building and seding response
 type Handler struct {
@@ -38,7 +45,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		response.RespondError(http.StatusBadRequest, constant.Error, err, w)
 		return
 	}
-	e, httpErr := h.service.Create(d, r.Context())
+	e, httpErr := h.service.Create(d, principal(r), r.Context())
 	if httpErr.Err != nil {
 		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
 		return
@@ -48,42 +55,46 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) ReadMany(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	fmt.Println("ReadMany.context.RBAC: ", ctx.Value(constant.KeyRBAC))
-	fmt.Println("ReadMany.context.AuthUser: ", ctx.Value(constant.KeyAuthUser))
-	limit := 10
-	page := 1
-	var err error
-	limitStr := httpext.GetQueryParam(r, constant.KeyLimit)
-	if limitStr != "" {
-		limit, err = adapter.StringToInt(limitStr)
-		if err != nil {
-			response.RespondError(http.StatusBadRequest, constant.Error, err, w)
-			return
-		}
+	logext.FromContext(ctx).Debug("read contents", "query", r.URL.RawQuery)
+	p, err := pagination.ParseParams(r)
+	if err != nil {
+		response.RespondError(http.StatusBadRequest, constant.Error, err.Error(), w)
+		return
 	}
-	pageStr := httpext.GetQueryParam(r, constant.KeyPage)
-	if pageStr != "" {
-		page, err = adapter.StringToInt(pageStr)
-		if err != nil {
-			response.RespondError(http.StatusBadRequest, constant.Error, err, w)
-			return
-		}
+	q, err := httpext.ParseListQuery(r, listSchema)
+	if err != nil {
+		response.RespondError(http.StatusBadRequest, constant.Error, err.Error(), w)
+		return
 	}
-	e, httpErr := h.service.ReadMany(limit, page, nil)
+	e, httpErr := h.service.ReadMany(p, q.Options(), principal(r), ctx)
 	if httpErr.Err != nil {
 		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
 		return
 	}
+	if len(q.Fields) > 0 {
+		res, err := response.SelectFields(e, q.Fields)
+		if err != nil {
+			response.RespondError(http.StatusInternalServerError, constant.Error, err.Error(), w)
+			return
+		}
+		response.Respond(http.StatusOK, res, w)
+		return
+	}
 	response.Respond(http.StatusOK, e, w)
 }
 
 func (h *Handler) ReadOne(w http.ResponseWriter, r *http.Request) {
 	id := httpext.GetURLParam(r, constant.KeyId)
-	e, httpErr := h.service.ReadOne(id, nil)
+	e, httpErr := h.service.ReadOne(id, principal(r), r.Context())
 	if httpErr.Err != nil {
 		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
 		return
 	}
+	// the client already has this version
+	if httpext.IfNoneMatch(r).MatchesWeak(e.ETag()) {
+		response.RespondNotModified(e.ETag(), w)
+		return
+	}
 	response.Respond(http.StatusOK, e, w)
 }
 
@@ -99,7 +110,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		response.RespondError(http.StatusBadRequest, constant.Error, err, w)
 		return
 	}
-	e, httpErr := h.service.Update(id, d, r.Context())
+	e, httpErr := h.service.Update(id, d, principal(r), httpext.IfMatch(r), r.Context())
 	if httpErr.Err != nil {
 		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
 		return
@@ -107,9 +118,32 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	response.Respond(http.StatusOK, e, w)
 }
 
+// Delete soft deletes the content, ?hard=true deletes it permanently
+// which the router only allows for admins
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := httpext.GetURLParam(r, constant.KeyId)
-	e, httpErr := h.service.Delete(id, nil)
+	hard, err := httpext.GetQueryFlag(r, constant.KeyHard)
+	if err != nil {
+		response.RespondError(http.StatusBadRequest, constant.Error, err.Error(), w)
+		return
+	}
+	var e entity.Content
+	var httpErr errorext.HTTPError
+	if hard {
+		e, httpErr = h.service.DeleteHard(id, httpext.IfMatch(r), r.Context())
+	} else {
+		e, httpErr = h.service.Delete(id, principal(r), httpext.IfMatch(r), r.Context())
+	}
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, e, w)
+}
+
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := httpext.GetURLParam(r, constant.KeyId)
+	e, httpErr := h.service.Restore(id, r.Context())
 	if httpErr.Err != nil {
 		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
 		return
@@ -120,3 +154,10 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) Public(w http.ResponseWriter, r *http.Request) {
 	response.Respond(http.StatusOK, map[string]string{"message": "public api"}, w)
 }
+
+// principal returns the principal of the request,
+// the zero value if it wasn't authenticated
+func principal(r *http.Request) authn.Principal {
+	p, _ := contextext.PrincipalFrom(r.Context())
+	return p
+}
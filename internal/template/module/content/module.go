@@ -3,7 +3,9 @@ package content
 import (
 	"github.com/go-playground/validator/v10"
 	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content/entity"
 )
 
@@ -13,11 +15,11 @@ type Module struct {
 	Repository sqlxext.Repository[entity.Content]
 }
 
-func NewModule(db *sqlx.DB, v *validator.Validate) *Module {
+func NewModule(db *sqlx.DB, tm *postgres.TxManager, c *pagination.Codec, v *validator.Validate) *Module {
 	// init order is reversed of the field decleration
 	// as the dependency is served this way
-	r := NewRepository(db)
-	s := NewService(r)
+	r := NewRepository(db, tm)
+	s := NewService(r, tm, c)
 	h := NewHandler(s, v)
 	return &Module{Handler: h, Service: s, Repository: r}
 }
@@ -0,0 +1,42 @@
+package content
+
+import (
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/abac"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content/entity"
+)
+
+// policy is evaluated by the service on top of the permissions of the
+// routes, published contents are readable by anyone and the owner or
+// an admin may update & delete
+var policy = abac.NewPolicy[entity.Content]().
+	Allow(abac.Read, published, owner, admin).
+	Allow(abac.Update, owner, admin).
+	Allow(abac.Delete, owner, admin)
+
+var (
+	owner = abac.Owner(func(e entity.Content) string {
+		if e.UserID == nil {
+			return ""
+		}
+		return *e.UserID
+	})
+	admin = abac.Role[entity.Content](constant.RoleAdmin)
+)
+
+func published(_ authn.Principal, e entity.Content) bool {
+	return e.Published
+}
+
+// visibleTo is the read rule of the policy as a filter of the lists
+func visibleTo(p authn.Principal) []postgres.Expr {
+	if p.HasRole(constant.RoleAdmin) {
+		return nil
+	}
+	if p.Subject == "" {
+		return []postgres.Expr{postgres.Eq("published", true)}
+	}
+	return []postgres.Expr{postgres.Or(postgres.Eq("published", true), postgres.Eq("user_id", p.Subject))}
+}
@@ -2,12 +2,16 @@ package content
 
 import (
 	"context"
-	"errors"
 	"net/http"
 
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/abac"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/errorext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content/dto"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content/entity"
 	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
@@ -15,11 +19,15 @@ import (
 
 type Service struct {
 	repository sqlxext.Repository[entity.Content]
+	txManager  *postgres.TxManager
+	cursors    *pagination.Codec
 }
 
-func NewService(r sqlxext.Repository[entity.Content]) *Service {
+func NewService(r sqlxext.Repository[entity.Content], tm *postgres.TxManager, c *pagination.Codec) *Service {
 	s := new(Service)
 	s.repository = r
+	s.txManager = tm
+	s.cursors = c
 	return s
 }
 
@@ -27,70 +35,186 @@ func (s *Service) ReadOneInternal(id string, ctx context.Context) (entity.Conten
 	return s.repository.ReadOne(id, ctx)
 }
 
-func (s *Service) Create(d dto.CreateUpdateContentDTO, ctx context.Context) (entity.Content, errorext.HTTPError) {
+// Create creates the content owned by the principal
+func (s *Service) Create(d dto.CreateUpdateContentDTO, p authn.Principal, ctx context.Context) (entity.Content, errorext.HTTPError) {
 	// convert dto to entity
 	b := entity.Content{}
 	b.Name = d.Name
+	if d.Published != nil {
+		b.Published = *d.Published
+	}
+	if p.Subject != "" {
+		b.UserID = &p.Subject
+	}
 	n := timeext.NowUnixMilli()
 	b.CreatedAt = n
 	b.UpdatedAt = n
-	err := s.repository.Create(b, ctx)
+	// the column default
+	b.Version = 1
+	id, err := s.repository.Create(b, ctx)
 	if err != nil {
 		return b, errorext.BuildDBError(err)
 	}
+	b.ID = id
 	return b, errorext.HTTPError{}
 }
 
-func (s *Service) ReadMany(limit, page int, ctx context.Context) (map[string]any, errorext.HTTPError) {
-	m := make(map[string]any)
-	m["items"] = make([]entity.Content, 0)
-	m["limit"] = limit
-	m["page"] = page
-	offset := limit * (page - 1)
-	d, err := s.repository.ReadMany(limit, offset, ctx)
-	if err != nil {
-		return m, errorext.BuildDBError(err)
+// ReadMany lists the contents visible to the principal
+func (s *Service) ReadMany(p pagination.Params, opts postgres.ListOptions, principal authn.Principal, ctx context.Context) (response.ReadManyResponse[entity.Content], errorext.HTTPError) {
+	opts.Where = append(visibleTo(principal), opts.Where...)
+	res := response.ReadManyResponse[entity.Content]{Items: []entity.Content{}, Limit: p.Limit, Page: p.Page}
+	if p.CursorMode {
+		if len(opts.OrderBy) > 0 {
+			return res, errorext.HTTPError{Code: http.StatusBadRequest, Err: pagination.ErrSortWithCursor}
+		}
+		c, err := s.cursors.Decode(p.Cursor)
+		if err != nil {
+			return res, errorext.HTTPError{Code: http.StatusBadRequest, Err: err}
+		}
+		// one extra row tells if there is a next page
+		d, err := s.repository.ReadManySeek(p.Limit+1, c, opts, ctx)
+		if err != nil {
+			return res, errorext.BuildDBError(err)
+		}
+		res.Items, res.NextCursor, res.PrevCursor = pagination.Cursors(d, p.Limit, c, cursorKey, s.cursors)
+	} else {
+		d, err := s.repository.ReadMany(p.Limit, p.Offset(), opts, ctx)
+		if err != nil {
+			return res, errorext.BuildDBError(err)
+		}
+		res.Items = d
 	}
-	m["items"] = d
-	return m, errorext.HTTPError{}
+	if p.Total != pagination.TotalNone {
+		n, err := s.repository.Count(p.Total, opts, ctx)
+		if err != nil {
+			return res, errorext.BuildDBError(err)
+		}
+		res.Total = &n
+	}
+	return res, errorext.HTTPError{}
 }
 
-func (s *Service) ReadOne(id string, ctx context.Context) (entity.Content, errorext.HTTPError) {
+// ReadOne reads the content, the ones the principal
+// can't see are not found
+func (s *Service) ReadOne(id string, p authn.Principal, ctx context.Context) (entity.Content, errorext.HTTPError) {
 	b, err := s.ReadOneInternal(id, ctx)
+	if err == nil {
+		err = policy.Authorize(p, abac.Read, b)
+	}
 	if err != nil {
-		return b, errorext.BuildDBError(err)
+		return entity.Content{}, errorext.BuildDBError(err)
 	}
 	return b, errorext.HTTPError{}
 }
 
-func (s *Service) Update(id string, d dto.CreateUpdateContentDTO, ctx context.Context) (entity.Content, errorext.HTTPError) {
-	b, err := s.ReadOneInternal(id, ctx)
+// Update updates the content if the principal may and it matches
+// ifMatch, the precondition is skipped when ifMatch is empty
+func (s *Service) Update(id string, d dto.CreateUpdateContentDTO, p authn.Principal, ifMatch httpext.ETagList, ctx context.Context) (entity.Content, errorext.HTTPError) {
+	var b entity.Content
+	// read & write in one tx
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		b, err = s.ReadOneInternal(id, ctx)
+		if err != nil {
+			return err
+		}
+		if err := policy.Authorize(p, abac.Update, b); err != nil {
+			return err
+		}
+		if ifMatch != "" && !ifMatch.Matches(b.ETag()) {
+			return errorext.ErrPreconditionFailed
+		}
+		b.Name = d.Name
+		if d.Published != nil {
+			b.Published = *d.Published
+		}
+		b.UpdatedAt = timeext.NowUnixMilli()
+		rows, err := s.repository.Update(id, b, ctx)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			// modified since it was read
+			return errorext.ErrPreconditionFailed
+		}
+		b.Version++
+		return nil
+	})
 	if err != nil {
 		return b, errorext.BuildDBError(err)
 	}
-	b.Name = d.Name
-	b.UpdatedAt = timeext.NowUnixMilli()
-	rows, err := s.repository.Update(id, b, ctx)
+	return b, errorext.HTTPError{}
+}
+
+// Delete soft deletes the content if the principal may
+// and it matches ifMatch
+func (s *Service) Delete(id string, p authn.Principal, ifMatch httpext.ETagList, ctx context.Context) (entity.Content, errorext.HTTPError) {
+	var b entity.Content
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		b, err = s.ReadOneInternal(id, ctx)
+		if err != nil {
+			return err
+		}
+		if err := policy.Authorize(p, abac.Delete, b); err != nil {
+			return err
+		}
+		if ifMatch != "" && !ifMatch.Matches(b.ETag()) {
+			return errorext.ErrPreconditionFailed
+		}
+		now := timeext.NowUnixMilli()
+		rows, err := s.repository.Delete(id, p.Subject, b.Version, ctx)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return errorext.ErrPreconditionFailed
+		}
+		// as written so the response has the etag of the deleted version
+		b.Version++
+		b.DeletedAt = &now
+		if p.Subject != "" {
+			b.DeletedBy = &p.Subject
+		}
+		return nil
+	})
 	if err != nil {
 		return b, errorext.BuildDBError(err)
 	}
-	if rows > 0 {
-		return b, errorext.HTTPError{}
-	}
-	return b, errorext.HTTPError{Code: http.StatusBadRequest, Err: errors.New(constant.OperationNotSuccess)}
+	return b, errorext.HTTPError{}
 }
 
-func (s *Service) Delete(id string, ctx context.Context) (entity.Content, errorext.HTTPError) {
-	b, err := s.ReadOneInternal(id, ctx)
+// Restore undoes the soft delete of the content
+func (s *Service) Restore(id string, ctx context.Context) (entity.Content, errorext.HTTPError) {
+	b, err := s.repository.Restore(id, ctx)
 	if err != nil {
 		return b, errorext.BuildDBError(err)
 	}
-	rows, err := s.repository.Delete(id, ctx)
+	return b, errorext.HTTPError{}
+}
+
+// DeleteHard permanently deletes the content if it matches ifMatch
+func (s *Service) DeleteHard(id string, ifMatch httpext.ETagList, ctx context.Context) (entity.Content, errorext.HTTPError) {
+	var b entity.Content
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		b, err = s.repository.DeleteHard(id, ctx)
+		if err != nil {
+			return err
+		}
+		// the delete is rolled back on mismatch
+		if ifMatch != "" && !ifMatch.Matches(b.ETag()) {
+			return errorext.ErrPreconditionFailed
+		}
+		return nil
+	})
 	if err != nil {
 		return b, errorext.BuildDBError(err)
 	}
-	if rows > 0 {
-		return b, errorext.HTTPError{}
-	}
-	return b, errorext.HTTPError{Code: http.StatusBadRequest, Err: errors.New(constant.OperationNotSuccess)}
+	return b, errorext.HTTPError{}
+}
+
+// cursorKey is the keyset of the entity used by the cursors
+func cursorKey(e entity.Content) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: e.CreatedAt, ID: e.ID}
 }
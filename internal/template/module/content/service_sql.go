@@ -16,23 +16,20 @@ import (
 // ServiceSQL contains the business logic as well as calls to the
 // repository to perform db operations
 type ServiceSQL struct {
-	repository postgres.Repository[entity.Content]
+	repository postgres.Repository2[entity.Content]
 }
 
 // NewService initializes a new ServiceSQL
-func NewServiceSQL(r postgres.Repository[entity.Content]) *ServiceSQL {
+func NewServiceSQL(r postgres.Repository2[entity.Content]) *ServiceSQL {
 	return &ServiceSQL{repository: r}
 }
 
 func (s *ServiceSQL) readOneInternal(id string, ctx context.Context) (entity.Content, errorext.HTTPError) {
-	var e entity.Content
-	row := s.repository.ReadOne(ctx, id)
-	err := row.Err()
+	e, err := s.repository.ReadOne(ctx, id)
 	if err != nil {
-		return e, errorext.HTTPError{Code: http.StatusInternalServerError, Err: err}
+		return e, errorext.BuildDBError(err)
 	}
-	httpErr := postgres.ScanRow[entity.Content](row, &e, &e.ID, &e.Name, &e.CreatedAt, &e.UpdatedAt)
-	return e, httpErr
+	return e, errorext.HTTPError{}
 }
 
 // Create defines the business logic for create post request
@@ -58,16 +55,10 @@ func (s *ServiceSQL) ReadMany(limit, page int, ctx context.Context) (map[string]
 	m["limit"] = limit
 	m["page"] = page
 	offset := limit * (page - 1)
-	rows, err := s.repository.ReadMany(ctx, limit, offset, false)
+	d, err := s.repository.ReadMany(ctx, limit, offset)
 	if err != nil {
 		return m, errorext.BuildDBError(err)
 	}
-	defer rows.Close()
-	var e entity.Content
-	d, httpErr := postgres.ScanRows(rows, &e, &e.ID, &e.Name, &e.CreatedAt, &e.UpdatedAt)
-	if httpErr.Err != nil {
-		return m, errorext.BuildDBError(err)
-	}
 	m["items"] = d
 	return m, errorext.HTTPError{}
 }
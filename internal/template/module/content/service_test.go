@@ -1,7 +1,156 @@
 package content
 
-import "testing"
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres/postgrestest"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content/dto"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content/entity"
+)
+
+// fakeRepository keeps the contents in memory, the
+// methods the service tests don't use aren't implemented
+type fakeRepository struct {
+	contents map[string]entity.Content
+}
+
+func (f *fakeRepository) Create(e entity.Content, ctx context.Context) (string, error) {
+	e.ID = "1"
+	f.contents[e.ID] = e
+	return e.ID, nil
+}
+
+func (f *fakeRepository) ReadMany(limit, offset int, opts postgres.ListOptions, ctx context.Context) ([]entity.Content, error) {
+	return nil, nil
+}
+
+func (f *fakeRepository) ReadManySeek(limit int, cursor *pagination.Cursor, opts postgres.ListOptions, ctx context.Context) ([]entity.Content, error) {
+	return nil, nil
+}
+
+func (f *fakeRepository) Count(mode pagination.TotalMode, opts postgres.ListOptions, ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeRepository) ReadOne(id string, ctx context.Context) (entity.Content, error) {
+	e, ok := f.contents[id]
+	if !ok {
+		return e, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (f *fakeRepository) Update(id string, e entity.Content, ctx context.Context) (int64, error) {
+	if f.contents[id].Version != e.Version {
+		return 0, nil
+	}
+	e.Version++
+	f.contents[id] = e
+	return 1, nil
+}
+
+func (f *fakeRepository) Delete(id, by string, version int64, ctx context.Context) (int64, error) {
+	e := f.contents[id]
+	if e.Version != version {
+		return 0, nil
+	}
+	e.Version++
+	f.contents[id] = e
+	return 1, nil
+}
+
+func (f *fakeRepository) Restore(id string, ctx context.Context) (entity.Content, error) {
+	return entity.Content{}, nil
+}
+
+func (f *fakeRepository) DeleteHard(id string, ctx context.Context) (entity.Content, error) {
+	return entity.Content{}, nil
+}
+
+func (f *fakeRepository) Purge(before int64, limit int, ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeRepository) DB() *sqlx.DB {
+	return nil
+}
 
 func TestCreate(t *testing.T) {
+	db, _ := postgrestest.NewDB()
+	defer db.Close()
+	s := NewService(&fakeRepository{contents: make(map[string]entity.Content)}, postgres.NewTxManager(db), nil)
+	e, httpErr := s.Create(dto.CreateUpdateContentDTO{Name: "draft"}, authn.Principal{Subject: "1"}, context.Background())
+	if httpErr.Err != nil {
+		t.Fatal(httpErr.Err)
+	}
+	if e.Published || e.UserID == nil || *e.UserID != "1" {
+		t.Errorf("Expected an unpublished content of '1', but got '%v'", e)
+	}
+}
+
+func TestUpdatePublished(t *testing.T) {
+	yes, no := true, false
+	owner := "1"
+	tests := []struct {
+		name      string
+		published *bool
+		principal authn.Principal
+		expected  bool
+		code      int
+	}{
+		{name: "omitted keeps it", principal: authn.Principal{Subject: owner}, expected: true},
+		{name: "unpublish", published: &no, principal: authn.Principal{Subject: owner}},
+		{name: "publish", published: &yes, principal: authn.Principal{Subject: owner}, expected: true},
+		{name: "not the owner", published: &no, principal: authn.Principal{Subject: "2"}, expected: true, code: http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, _ := postgrestest.NewDB()
+			defer db.Close()
+			r := &fakeRepository{contents: map[string]entity.Content{
+				"1": {ID: "1", Name: "post", UserID: &owner, Published: true, Version: 1},
+			}}
+			s := NewService(r, postgres.NewTxManager(db), nil)
+			d := dto.CreateUpdateContentDTO{Name: "renamed", Published: tc.published}
+			_, httpErr := s.Update("1", d, tc.principal, "", context.Background())
+			if httpErr.Code != tc.code {
+				t.Errorf("Expected '%v', but got '%v'", tc.code, httpErr.Code)
+			}
+			if got := r.contents["1"].Published; got != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, got)
+			}
+		})
+	}
+}
 
+func TestDelete(t *testing.T) {
+	db, _ := postgrestest.NewDB()
+	defer db.Close()
+	owner := "1"
+	r := &fakeRepository{contents: map[string]entity.Content{
+		"1": {ID: "1", Name: "post", UserID: &owner, Version: 3},
+	}}
+	s := NewService(r, postgres.NewTxManager(db), nil)
+	e, httpErr := s.Delete("1", authn.Principal{Subject: owner}, `"3"`, context.Background())
+	if httpErr.Err != nil {
+		t.Fatal(httpErr.Err)
+	}
+	// the etag of the deleted version, not the one before
+	if expected := r.contents["1"].ETag(); e.ETag() != expected {
+		t.Errorf("Expected '%v', but got '%v'", expected, e.ETag())
+	}
+	if e.DeletedAt == nil || e.DeletedBy == nil || *e.DeletedBy != owner {
+		t.Errorf("Expected the deletion of '%v', but got '%v'", owner, e)
+	}
+	_, httpErr = s.Delete("1", authn.Principal{Subject: owner}, `"3"`, context.Background())
+	if httpErr.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected '%v', but got '%v'", http.StatusPreconditionFailed, httpErr.Code)
+	}
 }
@@ -1,17 +1,20 @@
 package fileupload
 
-import "github.com/tanveerprottoy/stdlib-go-template/internal/pkg/s3ext"
+import (
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/s3ext"
+)
 
 type Module struct {
 	Handler *Handler
 	Service *Service
 }
 
-func NewModule(clientsS3 *s3ext.Clients) *Module {
+func NewModule(clientsS3 *s3ext.Clients, c config.S3) *Module {
 	m := new(Module)
 	// init order is reversed of the field decleration
 	// as the dependency is served this way
-	m.Service = NewService(clientsS3)
+	m.Service = NewService(clientsS3, c)
 	m.Handler = NewHandler(m.Service)
 	return m
 }
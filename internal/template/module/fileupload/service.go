@@ -2,7 +2,6 @@ package fileupload
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"path/filepath"
 	"time"
@@ -11,17 +10,20 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/google/uuid"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/multipart"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/s3ext"
 )
 
 type Service struct {
 	clientsS3 *s3ext.Clients
+	config    config.S3
 }
 
-func NewService(clientsS3 *s3ext.Clients) *Service {
+func NewService(clientsS3 *s3ext.Clients, c config.S3) *Service {
 	s := new(Service)
 	s.clientsS3 = clientsS3
+	s.config = c
 	return s
 }
 
@@ -60,7 +62,7 @@ func (s *Service) UploadOne(r *http.Request) (map[string]string, error) {
 	}
 	o, err := s3ext.PutObject(
 		&s3.PutObjectInput{
-			Bucket: aws.String(config.GetEnvValue("BUCKET_NAME")),
+			Bucket: aws.String(s.config.Bucket),
 			Key:    aws.String("my-folder/" + h.Filename),
 			Body:   f,
 		},
@@ -70,9 +72,9 @@ func (s *Service) UploadOne(r *http.Request) (map[string]string, error) {
 	if err != nil {
 		return m, err
 	}
-	fmt.Println(o)
+	logext.FromContext(r.Context()).Debug("put object", "key", "my-folder/"+h.Filename, "etag", aws.ToString(o.ETag))
 	// fetch url
-	m["path"] = s3ext.BuildObjectURLPathStyle(config.GetEnvValue("S3_REGION"), config.GetEnvValue("BUCKET_NAME"), h.Filename)
+	m["path"] = s3ext.BuildObjectURLPathStyle(s.config.Region, s.config.Bucket, h.Filename)
 	return m, nil
 }
 
@@ -125,7 +127,7 @@ func (s *Service) GetPresignedURLForOne(key string, ctx context.Context) (map[st
 	m := map[string]string{"url": ""}
 	o, err := s3ext.GetObjectPresigned(
 		&s3.GetObjectInput{
-			Bucket: aws.String(config.GetEnvValue("BUCKET_NAME")),
+			Bucket: aws.String(s.config.Bucket),
 			Key:    aws.String(key),
 		},
 		s.clientsS3.PresignClient,
@@ -144,7 +146,7 @@ func (s *Service) GetPresignedURLForOne(key string, ctx context.Context) (map[st
 func (s *Service) PutPresignedURLForOne(key string, ctx context.Context) (map[string]string, error) {
 	m := map[string]string{"url": ""}
 	o, err := s3ext.PutObjectPresigned(&s3.PutObjectInput{
-		Bucket: aws.String(config.GetEnvValue("BUCKET_NAME")),
+		Bucket: aws.String(s.config.Bucket),
 		Key:    aws.String(key),
 	}, s.clientsS3.PresignClient, ctx, func(o *s3.PresignOptions) {
 		o.Expires = time.Duration(2 * time.Minute)
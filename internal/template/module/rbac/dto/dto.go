@@ -0,0 +1,21 @@
+package dto
+
+type CreateRoleDTO struct {
+	Name        string   `json:"name" validate:"required,max=64"`
+	Inherits    []string `json:"inherits" validate:"dive,required"`
+	Permissions []string `json:"permissions" validate:"dive,required"`
+}
+
+type UpdateRoleDTO struct {
+	Inherits    []string `json:"inherits" validate:"dive,required"`
+	Permissions []string `json:"permissions" validate:"dive,required"`
+}
+
+type CreatePermissionDTO struct {
+	Name        string `json:"name" validate:"required,max=128"`
+	Description string `json:"description"`
+}
+
+type UserRolesDTO struct {
+	Roles []string `json:"roles" validate:"dive,required"`
+}
@@ -0,0 +1,29 @@
+package entity
+
+import "github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+
+type Role struct {
+	Name string `db:"name" json:"name"`
+	// Inherits are the roles whose permissions the role has too
+	Inherits  postgres.StringArray `db:"inherits" json:"inherits"`
+	CreatedAt int64                `db:"created_at" json:"createdAt"`
+	UpdatedAt int64                `db:"updated_at" json:"updatedAt"`
+	// Permissions are the own permissions of the role
+	Permissions []string `db:"-" json:"permissions"`
+}
+
+type Permission struct {
+	Name        string `db:"name" json:"name"`
+	Description string `db:"description" json:"description"`
+	CreatedAt   int64  `db:"created_at" json:"createdAt"`
+}
+
+type RolePermission struct {
+	Role       string `db:"role" json:"role"`
+	Permission string `db:"permission" json:"permission"`
+}
+
+type UserRole struct {
+	UserID string `db:"user_id" json:"userId"`
+	Role   string `db:"role" json:"role"`
+}
@@ -0,0 +1,172 @@
+package rbac
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
+	rbacpkg "github.com/tanveerprottoy/stdlib-go-template/internal/pkg/rbac"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/validatorext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/rbac/dto"
+)
+
+const keyName = "name"
+
+type Handler struct {
+	service  *Service
+	validate *validator.Validate
+}
+
+func NewHandler(s *Service, v *validator.Validate) *Handler {
+	h := new(Handler)
+	h.service = s
+	h.validate = v
+	return h
+}
+
+// parse parses & validates the request body to v,
+// it responds the error itself and returns false on failure
+func (h *Handler) parse(w http.ResponseWriter, r *http.Request, v any) bool {
+	validationErrs, err := validatorext.ParseValidateRequestBody(r.Body, v, h.validate)
+	if validationErrs != nil {
+		response.RespondError(http.StatusBadRequest, constant.Errors, validationErrs, w)
+		return false
+	}
+	if err != nil {
+		response.RespondError(http.StatusBadRequest, constant.Error, err, w)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) ReadRoles(w http.ResponseWriter, r *http.Request) {
+	e, httpErr := h.service.ReadRoles(r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, e, w)
+}
+
+func (h *Handler) ReadRole(w http.ResponseWriter, r *http.Request) {
+	e, httpErr := h.service.ReadRole(httpext.GetURLParam(r, keyName), r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, e, w)
+}
+
+func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var d dto.CreateRoleDTO
+	if !h.parse(w, r, &d) {
+		return
+	}
+	e, httpErr := h.service.CreateRole(d, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusCreated, e, w)
+}
+
+func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	var d dto.UpdateRoleDTO
+	if !h.parse(w, r, &d) {
+		return
+	}
+	e, httpErr := h.service.UpdateRole(httpext.GetURLParam(r, keyName), d, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, e, w)
+}
+
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	httpErr := h.service.DeleteRole(httpext.GetURLParam(r, keyName), r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ReadPermissions(w http.ResponseWriter, r *http.Request) {
+	e, httpErr := h.service.ReadPermissions(r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, e, w)
+}
+
+func (h *Handler) CreatePermission(w http.ResponseWriter, r *http.Request) {
+	var d dto.CreatePermissionDTO
+	if !h.parse(w, r, &d) {
+		return
+	}
+	e, httpErr := h.service.CreatePermission(d, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusCreated, e, w)
+}
+
+func (h *Handler) DeletePermission(w http.ResponseWriter, r *http.Request) {
+	httpErr := h.service.DeletePermission(httpext.GetURLParam(r, keyName), r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ReadUserRoles(w http.ResponseWriter, r *http.Request) {
+	e, httpErr := h.service.ReadUserRoles(httpext.GetURLParam(r, constant.KeyId), r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, e, w)
+}
+
+func (h *Handler) SetUserRoles(w http.ResponseWriter, r *http.Request) {
+	var d dto.UserRolesDTO
+	if !h.parse(w, r, &d) {
+		return
+	}
+	e, httpErr := h.service.SetUserRoles(httpext.GetURLParam(r, constant.KeyId), d, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, e, w)
+}
+
+// Export responds the roles in the format of config/rbac.json
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	c, httpErr := h.service.Export(r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	response.Respond(http.StatusOK, c, w)
+}
+
+// Import replaces the roles by the ones of the config/rbac.json formatted body
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	var c rbacpkg.Config
+	if !h.parse(w, r, &c) {
+		return
+	}
+	httpErr := h.service.Import(c, r.Context())
+	if httpErr.Err != nil {
+		response.RespondError(httpErr.Code, constant.Error, httpErr.Err.Error(), w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
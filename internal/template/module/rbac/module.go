@@ -0,0 +1,21 @@
+package rbac
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+)
+
+type Module struct {
+	Handler    *Handler
+	Service    *Service
+	Repository *Repository
+}
+
+func NewModule(db *sqlx.DB, tm *postgres.TxManager, validate *validator.Validate) *Module {
+	m := new(Module)
+	m.Repository = NewRepository(db)
+	m.Service = NewService(m.Repository, tm)
+	m.Handler = NewHandler(m.Service, validate)
+	return m
+}
@@ -0,0 +1,258 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/rbac/entity"
+)
+
+const (
+	rolesTable           = "roles"
+	permissionsTable     = "permissions"
+	rolePermissionsTable = "role_permissions"
+	userRolesTable       = "user_roles"
+)
+
+var (
+	roleColumns       = []string{"name", "inherits", "created_at", "updated_at"}
+	permissionColumns = []string{"name", "description", "created_at"}
+)
+
+// Repository stores the roles, the permissions & their
+// assignments, every write notifies the listening apps
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	r := new(Repository)
+	r.db = db
+	return r
+}
+
+func (r *Repository) ReadRoles(ctx context.Context) ([]entity.Role, error) {
+	d := []entity.Role{}
+	q, args := postgres.Select(roleColumns...).From(rolesTable).OrderBy(postgres.Asc("name")).Build()
+	err := sqlxext.Conn(ctx, r.db).SelectContext(ctx, &d, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *Repository) ReadRole(name string, ctx context.Context) (entity.Role, error) {
+	b := entity.Role{}
+	q, args := postgres.Select(roleColumns...).From(rolesTable).Where(postgres.Eq("name", name)).Build()
+	err := sqlxext.Conn(ctx, r.db).GetContext(ctx, &b, q, args...)
+	return b, err
+}
+
+func (r *Repository) CountRoles(ctx context.Context) (int64, error) {
+	return postgres.Count(ctx, sqlxext.Conn(ctx, r.db), rolesTable)
+}
+
+func (r *Repository) CreateRole(e entity.Role, ctx context.Context) error {
+	q, args := postgres.Insert(rolesTable).
+		Columns("name", "inherits", "created_at", "updated_at").
+		Values(e.Name, e.Inherits, e.CreatedAt, e.UpdatedAt).
+		Build()
+	_, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	return err
+}
+
+// UpsertRole creates the role or updates its inherits
+func (r *Repository) UpsertRole(e entity.Role, ctx context.Context) error {
+	q, args := postgres.Insert(rolesTable).
+		Columns("name", "inherits", "created_at", "updated_at").
+		Values(e.Name, e.Inherits, e.CreatedAt, e.UpdatedAt).
+		OnConflict("name").
+		DoUpdate("inherits", "updated_at").
+		Build()
+	_, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	return err
+}
+
+func (r *Repository) UpdateRole(e entity.Role, ctx context.Context) (int64, error) {
+	q, args := postgres.Update(rolesTable).
+		Set("inherits", e.Inherits).
+		Set("updated_at", e.UpdatedAt).
+		Where(postgres.Eq("name", e.Name)).
+		Build()
+	res, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	if err != nil {
+		return -1, err
+	}
+	return sqlxext.GetRowsAffected(res), nil
+}
+
+// DeleteRole deletes the role with its permissions & assignments
+func (r *Repository) DeleteRole(name string, ctx context.Context) (int64, error) {
+	q, args := postgres.Delete(rolesTable).Where(postgres.Eq("name", name)).Build()
+	res, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	if err != nil {
+		return -1, err
+	}
+	return sqlxext.GetRowsAffected(res), nil
+}
+
+// DeleteRolesExcept deletes the roles not in names
+func (r *Repository) DeleteRolesExcept(names []string, ctx context.Context) error {
+	b := postgres.Delete(rolesTable)
+	if len(names) > 0 {
+		b.Where(postgres.NotIn("name", toAny(names)...))
+	}
+	q, args := b.Build()
+	_, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	return err
+}
+
+func (r *Repository) ReadPermissions(ctx context.Context) ([]entity.Permission, error) {
+	d := []entity.Permission{}
+	q, args := postgres.Select(permissionColumns...).From(permissionsTable).OrderBy(postgres.Asc("name")).Build()
+	err := sqlxext.Conn(ctx, r.db).SelectContext(ctx, &d, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *Repository) CreatePermission(e entity.Permission, ctx context.Context) error {
+	q, args := postgres.Insert(permissionsTable).
+		Columns("name", "description", "created_at").
+		Values(e.Name, e.Description, e.CreatedAt).
+		Build()
+	_, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	return err
+}
+
+// EnsurePermissions creates the permissions which don't exist yet
+func (r *Repository) EnsurePermissions(names []string, at int64, ctx context.Context) error {
+	for _, n := range names {
+		q, args := postgres.Insert(permissionsTable).
+			Columns("name", "created_at").
+			Values(n, at).
+			OnConflict("name").
+			DoNothing().
+			Build()
+		if _, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeletePermission deletes the permission from the roles too
+func (r *Repository) DeletePermission(name string, ctx context.Context) (int64, error) {
+	q, args := postgres.Delete(permissionsTable).Where(postgres.Eq("name", name)).Build()
+	res, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	if err != nil {
+		return -1, err
+	}
+	return sqlxext.GetRowsAffected(res), nil
+}
+
+// ReadRolePermissions reads the permissions of every role
+func (r *Repository) ReadRolePermissions(ctx context.Context) ([]entity.RolePermission, error) {
+	d := []entity.RolePermission{}
+	q, args := postgres.Select("role", "permission").
+		From(rolePermissionsTable).
+		OrderBy(postgres.Asc("role"), postgres.Asc("permission")).
+		Build()
+	err := sqlxext.Conn(ctx, r.db).SelectContext(ctx, &d, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SetRolePermissions replaces the permissions of the role,
+// they have to exist
+func (r *Repository) SetRolePermissions(role string, permissions []string, ctx context.Context) error {
+	conn := sqlxext.Conn(ctx, r.db)
+	q, args := postgres.Delete(rolePermissionsTable).Where(postgres.Eq("role", role)).Build()
+	if _, err := conn.ExecContext(ctx, q, args...); err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		q, args := postgres.Insert(rolePermissionsTable).
+			Columns("role", "permission").
+			Values(role, p).
+			OnConflict("role", "permission").
+			DoNothing().
+			Build()
+		if _, err := conn.ExecContext(ctx, q, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GrantPermission adds the permission to the role, it has to exist
+func (r *Repository) GrantPermission(role, permission string, ctx context.Context) error {
+	q, args := postgres.Insert(rolePermissionsTable).
+		Columns("role", "permission").
+		Values(role, permission).
+		OnConflict("role", "permission").
+		DoNothing().
+		Build()
+	_, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
+	return err
+}
+
+// ReadUserRoles reads the roles assigned to every user
+func (r *Repository) ReadUserRoles(ctx context.Context) ([]entity.UserRole, error) {
+	d := []entity.UserRole{}
+	q, args := postgres.Select("user_id", "role").From(userRolesTable).Build()
+	err := sqlxext.Conn(ctx, r.db).SelectContext(ctx, &d, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ReadRolesOfUser reads the roles assigned to the user
+func (r *Repository) ReadRolesOfUser(userID string, ctx context.Context) ([]string, error) {
+	d := []string{}
+	q, args := postgres.Select("role").
+		From(userRolesTable).
+		Where(postgres.Eq("user_id", userID)).
+		OrderBy(postgres.Asc("role")).
+		Build()
+	err := sqlxext.Conn(ctx, r.db).SelectContext(ctx, &d, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SetUserRoles replaces the roles assigned to the user
+func (r *Repository) SetUserRoles(userID string, roles []string, ctx context.Context) error {
+	conn := sqlxext.Conn(ctx, r.db)
+	q, args := postgres.Delete(userRolesTable).Where(postgres.Eq("user_id", userID)).Build()
+	if _, err := conn.ExecContext(ctx, q, args...); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		q, args := postgres.Insert(userRolesTable).
+			Columns("user_id", "role").
+			Values(userID, role).
+			OnConflict("user_id", "role").
+			DoNothing().
+			Build()
+		if _, err := conn.ExecContext(ctx, q, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toAny(s []string) []any {
+	d := make([]any, len(s))
+	for i, v := range s {
+		d[i] = v
+	}
+	return d
+}
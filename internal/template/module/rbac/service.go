@@ -0,0 +1,404 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/errorext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/logext"
+	rbacpkg "github.com/tanveerprottoy/stdlib-go-template/internal/pkg/rbac"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/rbac/dto"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/rbac/entity"
+	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
+)
+
+const (
+	// Channel is notified by the triggers of the rbac tables
+	Channel = "rbac_changed"
+	// WritePermission is required by the writes, a write leaving no
+	// role with it is rolled back as nobody could undo it
+	WritePermission = "rbac:write"
+)
+
+type Service struct {
+	repository *Repository
+	txManager  *postgres.TxManager
+	mu         sync.Mutex
+	onChange   []func(*rbacpkg.Policy)
+	check      func(*rbacpkg.Policy) error
+}
+
+func NewService(r *Repository, tm *postgres.TxManager) *Service {
+	s := new(Service)
+	s.repository = r
+	s.txManager = tm
+	return s
+}
+
+// OnChange registers fn to be called with the policy on every reload
+func (s *Service) OnChange(fn func(*rbacpkg.Policy)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = append(s.onChange, fn)
+}
+
+// SetCheck sets the check of the policy written, ex: the permissions
+// required by the routes are still granted, a failing write is rolled back
+func (s *Service) SetCheck(fn func(*rbacpkg.Policy) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.check = fn
+}
+
+// Load reads the policy from the tables
+func (s *Service) Load(ctx context.Context) (*rbacpkg.Policy, error) {
+	roles, err := s.repository.ReadRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rolePerms, err := s.repository.ReadRolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userRoles, err := s.repository.ReadUserRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defs := make(map[string]rbacpkg.Role, len(roles))
+	for _, e := range roles {
+		defs[e.Name] = rbacpkg.Role{Inherits: e.Inherits}
+	}
+	for _, e := range rolePerms {
+		d := defs[e.Role]
+		d.Permissions = append(d.Permissions, e.Permission)
+		defs[e.Role] = d
+	}
+	p, err := rbacpkg.NewPolicy(defs)
+	if err != nil {
+		return nil, err
+	}
+	users := make(map[string][]string)
+	for _, e := range userRoles {
+		users[e.UserID] = append(users[e.UserID], e.Role)
+	}
+	return p.WithUserRoles(users), nil
+}
+
+// Reload loads the policy and passes it to the OnChange funcs, it is
+// called on every notification of Channel
+func (s *Service) Reload(ctx context.Context) error {
+	p, err := s.Load(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fn := range s.onChange {
+		fn(p)
+	}
+	return nil
+}
+
+// Seed imports the config if there are no roles yet, otherwise the
+// permissions of the config new to the tables are granted to the
+// existing roles as in the config, the other edits are kept
+func (s *Service) Seed(c rbacpkg.Config, ctx context.Context) error {
+	n, err := s.repository.CountRoles(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return s.seedPermissions(c, ctx)
+	}
+	logext.FromContext(ctx).Info("seeding the rbac tables")
+	return s.write(ctx, func(ctx context.Context) error {
+		return s.importConfig(c, ctx)
+	})
+}
+
+func (s *Service) seedPermissions(c rbacpkg.Config, ctx context.Context) error {
+	perms, err := s.repository.ReadPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]struct{}, len(perms))
+	for _, e := range perms {
+		known[e.Name] = struct{}{}
+	}
+	roles, err := s.repository.ReadRoles(ctx)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]struct{}, len(roles))
+	for _, e := range roles {
+		existing[e.Name] = struct{}{}
+	}
+	grants := make(map[string][]string)
+	for role, d := range c.Roles {
+		if _, ok := existing[role]; !ok {
+			continue
+		}
+		for _, perm := range d.Permissions {
+			if _, ok := known[perm]; !ok {
+				grants[perm] = append(grants[perm], role)
+			}
+		}
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+	logext.FromContext(ctx).Info("seeding new rbac permissions", "permissions", len(grants))
+	now := timeext.NowUnixMilli()
+	return s.write(ctx, func(ctx context.Context) error {
+		for perm, roles := range grants {
+			if err := s.repository.EnsurePermissions([]string{perm}, now, ctx); err != nil {
+				return err
+			}
+			for _, role := range roles {
+				if err := s.repository.GrantPermission(role, perm, ctx); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Import replaces the roles & their permissions by the ones of the
+// config, the roles assigned to the users are kept if the role is
+func (s *Service) Import(c rbacpkg.Config, ctx context.Context) errorext.HTTPError {
+	return s.buildError(s.write(ctx, func(ctx context.Context) error {
+		return s.importConfig(c, ctx)
+	}))
+}
+
+func (s *Service) importConfig(c rbacpkg.Config, ctx context.Context) error {
+	now := timeext.NowUnixMilli()
+	names := make([]string, 0, len(c.Roles))
+	for name := range c.Roles {
+		names = append(names, name)
+	}
+	if err := s.repository.DeleteRolesExcept(names, ctx); err != nil {
+		return err
+	}
+	for name, d := range c.Roles {
+		e := entity.Role{Name: name, Inherits: nonNil(d.Inherits), CreatedAt: now, UpdatedAt: now}
+		if err := s.repository.UpsertRole(e, ctx); err != nil {
+			return err
+		}
+		if err := s.setPermissions(name, d.Permissions, now, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export returns the roles & their permissions in the format of config/rbac.json
+func (s *Service) Export(ctx context.Context) (rbacpkg.Config, errorext.HTTPError) {
+	c := rbacpkg.Config{Roles: map[string]rbacpkg.Role{}}
+	roles, httpErr := s.ReadRoles(ctx)
+	if httpErr.Err != nil {
+		return c, httpErr
+	}
+	for _, e := range roles {
+		c.Roles[e.Name] = rbacpkg.Role{Inherits: e.Inherits, Permissions: e.Permissions}
+	}
+	return c, errorext.HTTPError{}
+}
+
+// ReadRoles reads the roles with their own permissions
+func (s *Service) ReadRoles(ctx context.Context) ([]entity.Role, errorext.HTTPError) {
+	roles, err := s.repository.ReadRoles(ctx)
+	if err != nil {
+		return nil, errorext.BuildDBError(err)
+	}
+	rolePerms, err := s.repository.ReadRolePermissions(ctx)
+	if err != nil {
+		return nil, errorext.BuildDBError(err)
+	}
+	perms := make(map[string][]string)
+	for _, e := range rolePerms {
+		perms[e.Role] = append(perms[e.Role], e.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = nonNil(perms[roles[i].Name])
+	}
+	return roles, errorext.HTTPError{}
+}
+
+func (s *Service) ReadRole(name string, ctx context.Context) (entity.Role, errorext.HTTPError) {
+	roles, httpErr := s.ReadRoles(ctx)
+	if httpErr.Err != nil {
+		return entity.Role{}, httpErr
+	}
+	for _, e := range roles {
+		if e.Name == name {
+			return e, errorext.HTTPError{}
+		}
+	}
+	return entity.Role{}, errorext.BuildDBError(sql.ErrNoRows)
+}
+
+func (s *Service) CreateRole(d dto.CreateRoleDTO, ctx context.Context) (entity.Role, errorext.HTTPError) {
+	now := timeext.NowUnixMilli()
+	e := entity.Role{Name: d.Name, Inherits: nonNil(d.Inherits), CreatedAt: now, UpdatedAt: now, Permissions: nonNil(d.Permissions)}
+	err := s.write(ctx, func(ctx context.Context) error {
+		if err := s.repository.CreateRole(e, ctx); err != nil {
+			return err
+		}
+		return s.setPermissions(e.Name, e.Permissions, now, ctx)
+	})
+	return e, s.buildError(err)
+}
+
+// UpdateRole replaces the inherits & the permissions of the role
+func (s *Service) UpdateRole(name string, d dto.UpdateRoleDTO, ctx context.Context) (entity.Role, errorext.HTTPError) {
+	now := timeext.NowUnixMilli()
+	var e entity.Role
+	err := s.write(ctx, func(ctx context.Context) error {
+		var err error
+		e, err = s.repository.ReadRole(name, ctx)
+		if err != nil {
+			return err
+		}
+		e.Inherits = nonNil(d.Inherits)
+		e.Permissions = nonNil(d.Permissions)
+		e.UpdatedAt = now
+		if _, err := s.repository.UpdateRole(e, ctx); err != nil {
+			return err
+		}
+		return s.setPermissions(name, e.Permissions, now, ctx)
+	})
+	return e, s.buildError(err)
+}
+
+func (s *Service) DeleteRole(name string, ctx context.Context) errorext.HTTPError {
+	err := s.write(ctx, func(ctx context.Context) error {
+		rows, err := s.repository.DeleteRole(name, ctx)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+	return s.buildError(err)
+}
+
+func (s *Service) ReadPermissions(ctx context.Context) ([]entity.Permission, errorext.HTTPError) {
+	d, err := s.repository.ReadPermissions(ctx)
+	if err != nil {
+		return nil, errorext.BuildDBError(err)
+	}
+	return d, errorext.HTTPError{}
+}
+
+func (s *Service) CreatePermission(d dto.CreatePermissionDTO, ctx context.Context) (entity.Permission, errorext.HTTPError) {
+	e := entity.Permission{Name: d.Name, Description: d.Description, CreatedAt: timeext.NowUnixMilli()}
+	err := s.write(ctx, func(ctx context.Context) error {
+		return s.repository.CreatePermission(e, ctx)
+	})
+	return e, s.buildError(err)
+}
+
+// DeletePermission deletes the permission, the roles lose it too
+func (s *Service) DeletePermission(name string, ctx context.Context) errorext.HTTPError {
+	err := s.write(ctx, func(ctx context.Context) error {
+		rows, err := s.repository.DeletePermission(name, ctx)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+	return s.buildError(err)
+}
+
+func (s *Service) ReadUserRoles(userID string, ctx context.Context) ([]string, errorext.HTTPError) {
+	d, err := s.repository.ReadRolesOfUser(userID, ctx)
+	if err != nil {
+		return nil, errorext.BuildDBError(err)
+	}
+	return d, errorext.HTTPError{}
+}
+
+// SetUserRoles replaces the roles assigned to the user
+func (s *Service) SetUserRoles(userID string, d dto.UserRolesDTO, ctx context.Context) ([]string, errorext.HTTPError) {
+	roles := nonNil(d.Roles)
+	err := s.write(ctx, func(ctx context.Context) error {
+		return s.repository.SetUserRoles(userID, roles, ctx)
+	})
+	return roles, s.buildError(err)
+}
+
+func (s *Service) setPermissions(role string, permissions []string, at int64, ctx context.Context) error {
+	if err := s.repository.EnsurePermissions(permissions, at, ctx); err != nil {
+		return err
+	}
+	return s.repository.SetRolePermissions(role, permissions, ctx)
+}
+
+// write runs fn in a tx which is rolled back if the tables don't
+// resolve to a valid policy anymore, no role has WritePermission or
+// the check fails, the policy is reloaded after right away, the other
+// apps reload it on notification
+func (s *Service) write(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		p, err := s.Load(ctx)
+		if err != nil {
+			return err
+		}
+		return s.checkPolicy(p)
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.Reload(ctx); err != nil {
+		logext.FromContext(ctx).Error("reload rbac policy failed", logext.Err(err))
+	}
+	return nil
+}
+
+func (s *Service) checkPolicy(p *rbacpkg.Policy) error {
+	if !p.Defines(WritePermission) {
+		return fmt.Errorf("%w: no role has the permission %s", rbacpkg.ErrInvalidPolicy, WritePermission)
+	}
+	s.mu.Lock()
+	check := s.check
+	s.mu.Unlock()
+	if check == nil {
+		return nil
+	}
+	if err := check(p); err != nil {
+		return fmt.Errorf("%w: %v", rbacpkg.ErrInvalidPolicy, err)
+	}
+	return nil
+}
+
+func (s *Service) buildError(err error) errorext.HTTPError {
+	if err == nil {
+		return errorext.HTTPError{}
+	}
+	if errors.Is(err, rbacpkg.ErrInvalidPolicy) {
+		return errorext.HTTPError{Code: http.StatusBadRequest, Err: err}
+	}
+	return errorext.BuildDBError(err)
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
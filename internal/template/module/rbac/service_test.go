@@ -0,0 +1,111 @@
+package rbac
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres/postgrestest"
+	rbacpkg "github.com/tanveerprottoy/stdlib-go-template/internal/pkg/rbac"
+)
+
+// newTestService returns a service on a fake db whose tables read
+// as the permissions of the roles
+func newTestService(rolePerms map[string][]string) (*Service, *postgrestest.Recorder) {
+	db, r := postgrestest.NewDB()
+	r.Rows = func(stmt string) ([]string, [][]driver.Value) {
+		var values [][]driver.Value
+		switch {
+		case strings.Contains(stmt, rolePermissionsTable):
+			for role, perms := range rolePerms {
+				for _, perm := range perms {
+					values = append(values, []driver.Value{role, perm})
+				}
+			}
+			return []string{"role", "permission"}, values
+		case strings.Contains(stmt, userRolesTable):
+			return []string{"user_id", "role"}, nil
+		case strings.Contains(stmt, rolesTable):
+			for role := range rolePerms {
+				values = append(values, []driver.Value{role, "{}", int64(1), int64(1)})
+			}
+			return roleColumns, values
+		}
+		return nil, nil
+	}
+	return NewService(NewRepository(sqlx.NewDb(db, "pgx")), postgres.NewTxManager(db)), r
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		rolePerms map[string][]string
+		check     error
+		wantCode  int
+	}{
+		{
+			name:      "valid",
+			rolePerms: map[string][]string{"admin": {"rbac:*"}},
+		},
+		{
+			name:      "wildcard",
+			rolePerms: map[string][]string{"admin": {"*"}},
+		},
+		{
+			name:      "no rbac write",
+			rolePerms: map[string][]string{"admin": {"rbac:read"}},
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "check failed",
+			rolePerms: map[string][]string{"admin": {"rbac:*"}},
+			check:     errors.New("rbac: no role has the permissions contents:read"),
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, r := newTestService(tc.rolePerms)
+			var checked, reloaded *rbacpkg.Policy
+			s.SetCheck(func(p *rbacpkg.Policy) error {
+				checked = p
+				return tc.check
+			})
+			s.OnChange(func(p *rbacpkg.Policy) {
+				reloaded = p
+			})
+			httpErr := s.DeleteRole("editor", context.Background())
+			if httpErr.Code != tc.wantCode {
+				t.Errorf("Expected '%v', but got '%v' (%v)", tc.wantCode, httpErr.Code, httpErr.Err)
+			}
+			log := r.Log()
+			end := "COMMIT"
+			if tc.wantCode != 0 {
+				end = "ROLLBACK"
+			}
+			if i := indexOf(log, end); i < 0 {
+				t.Errorf("Expected '%v', but got '%v'", end, log)
+			}
+			if tc.wantCode == 0 && (checked == nil || reloaded == nil) {
+				t.Errorf("Expected the policy checked & reloaded, but got '%v' & '%v'", checked, reloaded)
+			}
+			if tc.wantCode != 0 && reloaded != nil {
+				t.Errorf("Expected no reload, but got '%v'", reloaded)
+			}
+		})
+	}
+}
+
+func indexOf(log []string, stmt string) int {
+	for i, s := range log {
+		if s == stmt {
+			return i
+		}
+	}
+	return -1
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user/entity"
+
 type CreateUpdateUserDTO struct {
 	Name           string            `json:"name" validate:"required"`
 	// Role           string            `json:"role" validate:"required"`
@@ -15,3 +17,32 @@ type UserAddressDTO struct {
 	Street string `json:"street" validate:"required"`
 	City   string `json:"city" validate:"required"`
 }
+
+// PublicUserDTO is a user as listed, without the personal data
+type PublicUserDTO struct {
+	ID            string  `db:"id" json:"id"`
+	Name          string  `db:"name" json:"name"`
+	Role          string  `db:"role" json:"role"`
+	EmailVerified bool    `db:"email_verified" json:"emailVerified"`
+	Status        string  `db:"status" json:"status"`
+	CreatedAt     int64   `db:"created_at" json:"createdAt"`
+	UpdatedAt     int64   `db:"updated_at" json:"updatedAt"`
+	Version       int64   `db:"version" json:"version"`
+	DeletedAt     *int64  `db:"deleted_at" json:"deletedAt,omitempty"`
+	DeletedBy     *string `db:"deleted_by" json:"deletedBy,omitempty"`
+}
+
+func NewPublicUserDTO(e entity.User) PublicUserDTO {
+	return PublicUserDTO{
+		ID:            e.ID,
+		Name:          e.Name,
+		Role:          e.Role,
+		EmailVerified: e.EmailVerified,
+		Status:        e.Status,
+		CreatedAt:     e.CreatedAt,
+		UpdatedAt:     e.UpdatedAt,
+		Version:       e.Version,
+		DeletedAt:     e.DeletedAt,
+		DeletedBy:     e.DeletedBy,
+	}
+}
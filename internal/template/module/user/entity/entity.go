@@ -1,18 +1,33 @@
 package entity
 
-import "database/sql"
+import "strconv"
 
 type User struct {
 	ID   string `db:"id" json:"id"`
 	Name string `db:"name" json:"name"`
 	Role string `db:"role" json:"role"`
-	/* Email         string            `db:"email" json:"email"`
-	Age           uint8             `db:"age" json:"age"`
-	Phone         string            `db:"phone" json:"phone"`
-	FavoriteColor string            `db:"favorite_color" json:"favoriteColor"`
+	// Email, Phone & Age are null for the users created before they were added
+	Email *string `db:"email" json:"email,omitempty"`
+	Phone *string `db:"phone" json:"phone,omitempty"`
+	Age   *int16  `db:"age" json:"age,omitempty"`
+	// PasswordHash is the bcrypt hash, null when the user can't log in
+	PasswordHash  *string `db:"password_hash" json:"-"`
+	EmailVerified bool    `db:"email_verified" json:"emailVerified"`
+	Status        string  `db:"status" json:"status"`
+	/* FavoriteColor string            `db:"favorite_color" json:"favoriteColor"`
 	Addresses     []*UserAddressDTO `db:"addresses" json:"addresses"` */
 	CreatedAt int64 `db:"created_at" json:"createdAt"`
 	UpdatedAt int64 `db:"updated_at" json:"updatedAt"`
+	// Version is incremented on every update
+	Version int64 `db:"version" json:"version"`
+	// DeletedAt & DeletedBy are set on soft delete
+	DeletedAt *int64  `db:"deleted_at" json:"deletedAt,omitempty"`
+	DeletedBy *string `db:"deleted_by" json:"deletedBy,omitempty"`
+}
+
+// ETag is the strong entity tag of the current version
+func (e User) ETag() string {
+	return strconv.Quote(strconv.FormatInt(e.Version, 10))
 }
 
 // Address houses a users address information
@@ -20,11 +35,3 @@ type UserAddressDTO struct {
 	Street string `db:"street" json:"street"`
 	City   string `db:"city" json:"city"`
 }
-
-func (c *User) ScanRows(rows *sql.Rows) error {
-	return nil
-}
-
-func (c *User) ScanRow(row *sql.Row) error {
-	return nil
-}
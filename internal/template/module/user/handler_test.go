@@ -0,0 +1,55 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user/entity"
+)
+
+// fakeAccountRepository lists the users from memory, the
+// methods the handler tests don't use aren't implemented
+type fakeAccountRepository struct {
+	AccountRepository
+	users []entity.User
+}
+
+func (f *fakeAccountRepository) ReadMany(limit, offset int, opts postgres.ListOptions, ctx context.Context) ([]entity.User, error) {
+	return f.users, nil
+}
+
+func TestReadManyPersonalData(t *testing.T) {
+	email, phone, age := "a@b.c", "+8801700000000", int16(42)
+	r := &fakeAccountRepository{users: []entity.User{{ID: "1", Name: "a", Email: &email, Phone: &phone, Age: &age}}}
+	h := NewHandler(NewService(r, nil, nil), validator.New())
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{name: "list", query: "", expected: http.StatusOK},
+		{name: "fields", query: "?fields=id,name", expected: http.StatusOK},
+		{name: "email field", query: "?fields=id,email", expected: http.StatusBadRequest},
+		{name: "phone filter", query: "?filter[phone]=" + phone, expected: http.StatusBadRequest},
+		{name: "age sort", query: "?sort=age", expected: http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ReadMany(w, httptest.NewRequest(http.MethodGet, "/users"+tc.query, nil))
+			if w.Code != tc.expected {
+				t.Errorf("Expected '%v', but got '%v'", tc.expected, w.Code)
+			}
+			for _, s := range []string{email, phone, "42"} {
+				if strings.Contains(w.Body.String(), s) {
+					t.Errorf("Expected no '%v', but got '%v'", s, w.Body.String())
+				}
+			}
+		})
+	}
+}
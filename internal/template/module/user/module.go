@@ -3,22 +3,22 @@ package user
 import (
 	"github.com/go-playground/validator/v10"
 	"github.com/jmoiron/sqlx"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user/entity"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
 )
 
 type Module struct {
 	Handler    *Handler
 	Service    *Service
-	Repository sqlxext.Repository[entity.User]
+	Repository AccountRepository
 }
 
-func NewModule(db *sqlx.DB, validate *validator.Validate) *Module {
+func NewModule(db *sqlx.DB, tm *postgres.TxManager, c *pagination.Codec, validate *validator.Validate) *Module {
 	m := new(Module)
 	// init order is reversed of the field decleration
 	// as the dependency is served this way
-	m.Repository = NewRepository(db)
-	m.Service = NewService(m.Repository)
+	m.Repository = NewRepository(db, tm)
+	m.Service = NewService(m.Repository, tm, c)
 	m.Handler = NewHandler(m.Service, validate)
 	return m
 }
@@ -7,62 +7,170 @@ import (
 	"github.com/lib/pq"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user/entity"
+	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
 )
 
+// AccountRepository is the repository with the account lookups
+type AccountRepository interface {
+	sqlxext.Repository[entity.User]
+
+	// ReadOneByEmail reads the user of the lower cased email
+	ReadOneByEmail(email string, ctx context.Context) (entity.User, error)
+}
+
 type Repository[T entity.User] struct {
-	db *sqlx.DB
+	db        *sqlx.DB
+	txManager *postgres.TxManager
 }
 
-func NewRepository(db *sqlx.DB) *Repository[entity.User] {
+func NewRepository(db *sqlx.DB, tm *postgres.TxManager) *Repository[entity.User] {
 	r := new(Repository[entity.User])
 	r.db = db
+	r.txManager = tm
 	return r
 }
 
-func (r *Repository[T]) Create(e entity.User, ctx context.Context) error {
+func (r *Repository[T]) Create(e entity.User, ctx context.Context) (string, error) {
 	var lastId string
-	q := postgres.BuildInsertQuery(tableName, []string{"name", "created_at", "updated_at"}, "RETURNING id")
-	err := r.db.QueryRow(q, e.Name, e.Role, e.CreatedAt, e.UpdatedAt).Scan(&lastId)
+	q, args := postgres.Insert(tableName).
+		Columns("name", "role", "email", "phone", "age", "password_hash", "email_verified", "status", "created_at", "updated_at").
+		Values(e.Name, e.Role, e.Email, e.Phone, e.Age, e.PasswordHash, e.EmailVerified, e.Status, e.CreatedAt, e.UpdatedAt).
+		Returning("id").
+		Build()
+	err := sqlxext.Conn(ctx, r.db).QueryRowContext(ctx, q, args...).Scan(&lastId)
+	return lastId, err
+}
+
+func (r *Repository[T]) ReadMany(limit, offset int, opts postgres.ListOptions, ctx context.Context) ([]entity.User, error) {
+	d := []entity.User{}
+	b := postgres.Select(opts.Projection(columns)...).
+		From(tableName).
+		Where(postgres.NotDeleted()).
+		Where(opts.Where...).
+		OrderBy(opts.Order()...).
+		Limit(limit).
+		Offset(offset)
+	q, args := b.Build()
+	err := sqlxext.Conn(ctx, r.db).SelectContext(ctx, &d, q, args...)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	e.ID = lastId
-	return nil
+	return d, nil
 }
 
-func (r *Repository[T]) ReadMany(limit, offset int, ctx context.Context) ([]entity.User, error) {
+func (r *Repository[T]) ReadManySeek(limit int, cursor *pagination.Cursor, opts postgres.ListOptions, ctx context.Context) ([]entity.User, error) {
 	d := []entity.User{}
-	q := postgres.BuildSelectQuery(tableName, []string{}, []string{"is_deleted"}, "LIMIT $2 OFFSET $3")
-	err := r.db.Select(&d, q, limit, offset)
+	b := postgres.Select(opts.Projection(columns)...).
+		From(tableName).
+		Where(postgres.NotDeleted()).
+		Where(opts.Where...).
+		Limit(limit)
+	q, args := pagination.Seek(b, cursor).Build()
+	err := sqlxext.Conn(ctx, r.db).SelectContext(ctx, &d, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	return d, nil
 }
 
+func (r *Repository[T]) Count(mode pagination.TotalMode, opts postgres.ListOptions, ctx context.Context) (int64, error) {
+	conn := sqlxext.Conn(ctx, r.db)
+	// the estimate can't account for the filters
+	if mode == pagination.TotalEstimated && len(opts.Where) == 0 {
+		n, err := postgres.EstimateCount(ctx, conn, tableName)
+		// the table has not been analyzed yet
+		if err != nil || n >= 0 {
+			return n, err
+		}
+	}
+	where := append([]postgres.Expr{postgres.NotDeleted()}, opts.Where...)
+	return postgres.Count(ctx, conn, tableName, where...)
+}
+
 func (r *Repository[T]) ReadOne(id string, ctx context.Context) (entity.User, error) {
 	b := entity.User{}
-	q := postgres.BuildSelectQuery(tableName, []string{}, []string{"id"}, "LIMIT $2")
-	err := r.db.Get(&b, q, id)
+	q, args := postgres.Select(columns...).
+		From(tableName).
+		Where(postgres.Eq("id", id), postgres.NotDeleted()).
+		Limit(1).
+		Build()
+	err := sqlxext.Conn(ctx, r.db).GetContext(ctx, &b, q, args...)
+	return b, err
+}
+
+func (r *Repository[T]) ReadOneByEmail(email string, ctx context.Context) (entity.User, error) {
+	b := entity.User{}
+	q, args := postgres.Select(columns...).
+		From(tableName).
+		Where(postgres.Eq("email", email), postgres.NotDeleted()).
+		Limit(1).
+		Build()
+	err := sqlxext.Conn(ctx, r.db).GetContext(ctx, &b, q, args...)
+	return b, err
+}
+
+// Update writes the entity if its version is still e.Version,
+// 0 rows are affected when it was modified concurrently
+func (r *Repository[T]) Update(id string, e entity.User, ctx context.Context) (int64, error) {
+	q, args := postgres.Update(tableName).
+		Set("name", e.Name).
+		Set("email", e.Email).
+		Set("phone", e.Phone).
+		Set("age", e.Age).
+		Set("email_verified", e.EmailVerified).
+		Set("updated_at", e.UpdatedAt).
+		Set("version", postgres.Raw("version + 1")).
+		Where(postgres.Eq("id", id), postgres.Eq("version", e.Version), postgres.NotDeleted()).
+		Build()
+	res, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
 	if err != nil {
-		return b, err
+		return -1, err
 	}
-	return b, nil
+	return sqlxext.GetRowsAffected(res), nil
 }
 
-func (r *Repository[T]) Update(id string, e entity.User, ctx context.Context) (int64, error) {
-	q := postgres.BuildUpdateQuery(tableName, []string{"name", "updated_at"}, []string{"id"}, "")
-	res, err := r.db.Exec(q, e.Name, e.UpdatedAt, id)
+// Delete soft deletes the row if its version is still version,
+// by is the id of the deleting user
+func (r *Repository[T]) Delete(id, by string, version int64, ctx context.Context) (int64, error) {
+	q, args := postgres.SoftDelete(tableName, id, by, timeext.NowUnixMilli()).
+		Set("version", postgres.Raw("version + 1")).
+		Where(postgres.Eq("version", version)).
+		Build()
+	res, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
 	if err != nil {
 		return -1, err
 	}
 	return sqlxext.GetRowsAffected(res), nil
 }
 
-func (r *Repository[T]) Delete(id string, ctx context.Context) (int64, error) {
-	q := postgres.BuildDeleteQuery(tableName, []string{"id"}, "")
-	res, err := r.db.Exec(q, id)
+// Restore undoes the soft delete of the row
+func (r *Repository[T]) Restore(id string, ctx context.Context) (entity.User, error) {
+	b := entity.User{}
+	q, args := postgres.Restore(tableName, id, timeext.NowUnixMilli()).
+		Set("version", postgres.Raw("version + 1")).
+		Returning(columns...).
+		Build()
+	err := sqlxext.Conn(ctx, r.db).GetContext(ctx, &b, q, args...)
+	return b, err
+}
+
+// DeleteHard permanently deletes the row, deleted or not
+func (r *Repository[T]) DeleteHard(id string, ctx context.Context) (entity.User, error) {
+	b := entity.User{}
+	q, args := postgres.Delete(tableName).Where(postgres.Eq("id", id)).Returning(columns...).Build()
+	err := sqlxext.Conn(ctx, r.db).GetContext(ctx, &b, q, args...)
+	return b, err
+}
+
+// Purge permanently deletes at most limit rows soft deleted before the
+// time, the users still owning contents are kept so their contents
+// keep their owner, they are purged once their contents are
+func (r *Repository[T]) Purge(before int64, limit int, ctx context.Context) (int64, error) {
+	owns := postgres.Select("id").From("contents").Where(postgres.EqCol("contents.user_id", tableName+".id"))
+	q, args := postgres.Purge(tableName, before, limit, postgres.Not(postgres.Exists(owns))).Build()
+	res, err := sqlxext.Conn(ctx, r.db).ExecContext(ctx, q, args...)
 	if err != nil {
 		return -1, err
 	}
@@ -74,30 +182,22 @@ func (r *Repository[T]) DB() *sqlx.DB {
 }
 
 func (r *Repository[T]) createMany(entities []entity.User, ctx context.Context) error {
-	txn, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	stmt, err := txn.Prepare(pq.CopyIn(tableName, "name", "created_at", "updated_at"))
-	if err != nil {
-		return (err)
-	}
-	// close the statement when done
-	defer stmt.Close()
-	for _, e := range entities {
-		_, err := stmt.Exec(e.Name, e.CreatedAt, e.UpdatedAt)
+	return r.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		tx, _ := postgres.TxFromContext(ctx)
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn(tableName, "name", "created_at", "updated_at"))
 		if err != nil {
-			txn.Rollback()
 			return err
 		}
-	}
-	_, err = stmt.Exec()
-	if err != nil {
-		return err
-	}
-	err = txn.Commit()
-	if err != nil {
+		// close the statement when done
+		defer stmt.Close()
+		for _, e := range entities {
+			_, err := stmt.ExecContext(ctx, e.Name, e.CreatedAt, e.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		// flush the buffered rows
+		_, err = stmt.ExecContext(ctx)
 		return err
-	}
-	return nil
+	})
 }
@@ -11,10 +11,14 @@ import (
 	pgxstdlib "github.com/jackc/pgx/v5/stdlib"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user/entity"
+	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
 )
 
 const tableName = "users"
 
+// columns are the columns mapped to the entity
+var columns = []string{"id", "name", "role", "email", "phone", "age", "password_hash", "email_verified", "status", "created_at", "updated_at", "version", "deleted_at", "deleted_by"}
+
 type RepositorySQL[T entity.User] struct {
 	db *sql.DB
 }
@@ -25,40 +29,73 @@ func NewRepositorySQL(db *sql.DB) *RepositorySQL[entity.User] {
 
 func (r *RepositorySQL[T]) Create(ctx context.Context, e entity.User, args ...any) (string, error) {
 	var lastID string
-	q := postgres.BuildInsertQuery(tableName, []string{"name", "created_at", "updated_at"}, "RETURNING id")
-	err := r.db.QueryRowContext(ctx, q, e.Name, e.CreatedAt, e.UpdatedAt).Scan(&lastID)
+	q, qArgs := postgres.Insert(tableName).
+		Columns("name", "role", "email", "phone", "age", "password_hash", "email_verified", "status", "created_at", "updated_at").
+		Values(e.Name, e.Role, e.Email, e.Phone, e.Age, e.PasswordHash, e.EmailVerified, e.Status, e.CreatedAt, e.UpdatedAt).
+		Returning("id").
+		Build()
+	err := postgres.Conn(ctx, r.db).QueryRowContext(ctx, q, qArgs...).Scan(&lastID)
 	if err != nil {
 		return lastID, err
 	}
 	return lastID, nil
 }
 
-func (r *RepositorySQL[T]) ReadMany(ctx context.Context, limit, offset int, args ...any) (*sql.Rows, error) {
-	q := postgres.BuildSelectQuery(tableName, []string{}, []string{"is_deleted", "name"}, "LIMIT $2 OFFSET $3", "OR")
-	rows, err := r.db.QueryContext(ctx, q, args[0].(bool), limit, offset)
+func (r *RepositorySQL[T]) ReadMany(ctx context.Context, limit, offset int, args ...any) ([]entity.User, error) {
+	q, qArgs := postgres.Select(columns...).
+		From(tableName).
+		Where(postgres.NotDeleted()).
+		OrderBy(postgres.Desc("created_at"), postgres.Desc("id")).
+		Limit(limit).
+		Offset(offset).
+		Build()
+	rows, err := postgres.Conn(ctx, r.db).QueryContext(ctx, q, qArgs...)
 	if err != nil {
 		return nil, err
 	}
-	return rows, nil
+	return postgres.ScanAll[entity.User](rows)
 }
 
-func (r *RepositorySQL[T]) ReadOne(ctx context.Context, id string, args ...any) *sql.Row {
-	q := postgres.BuildSelectQuery(tableName, []string{}, []string{"id"}, "LIMIT $2")
-	return r.db.QueryRow(q, id, 1)
+func (r *RepositorySQL[T]) ReadOne(ctx context.Context, id string, args ...any) (entity.User, error) {
+	q, qArgs := postgres.Select(columns...).
+		From(tableName).
+		Where(postgres.Eq("id", id), postgres.NotDeleted()).
+		Limit(1).
+		Build()
+	rows, err := postgres.Conn(ctx, r.db).QueryContext(ctx, q, qArgs...)
+	if err != nil {
+		return entity.User{}, err
+	}
+	return postgres.ScanOne[entity.User](rows)
 }
 
 func (r *RepositorySQL[T]) Update(ctx context.Context, id string, e entity.User, args ...any) (int64, error) {
-	q := postgres.BuildUpdateQuery(tableName, []string{"name", "updated_at"}, []string{"id"}, "")
-	res, err := r.db.Exec(q, e.Name, e.UpdatedAt, id)
+	q, qArgs := postgres.Update(tableName).
+		Set("name", e.Name).
+		Set("email", e.Email).
+		Set("phone", e.Phone).
+		Set("age", e.Age).
+		Set("email_verified", e.EmailVerified).
+		Set("updated_at", e.UpdatedAt).
+		Set("version", postgres.Raw("version + 1")).
+		Where(postgres.Eq("id", id), postgres.Eq("version", e.Version), postgres.NotDeleted()).
+		Build()
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, q, qArgs...)
 	if err != nil {
 		return -1, err
 	}
 	return postgres.GetRowsAffected(res), nil
 }
 
+// Delete soft deletes the row, the optional
+// first arg is the id of the deleting user
 func (r *RepositorySQL[T]) Delete(ctx context.Context, id string, args ...any) (int64, error) {
-	q := postgres.BuildUpdateQuery(tableName, []string{"is_archived", "updated_at"}, []string{"id"}, "")
-	res, err := r.db.Exec(q, true, args[0].(int64), id)
+	var by string
+	if len(args) > 0 {
+		by, _ = args[0].(string)
+	}
+	q, qArgs := postgres.SoftDelete(tableName, id, by, timeext.NowUnixMilli()).Build()
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, q, qArgs...)
 	if err != nil {
 		return -1, err
 	}
@@ -66,8 +103,8 @@ func (r *RepositorySQL[T]) Delete(ctx context.Context, id string, args ...any) (
 }
 
 func (r *RepositorySQL[T]) DeleteHard(ctx context.Context, id string, args ...any) (int64, error) {
-	q := postgres.BuildDeleteQuery(tableName, []string{"id"}, "")
-	res, err := r.db.Exec(q, id)
+	q, qArgs := postgres.Delete(tableName).Where(postgres.Eq("id", id)).Build()
+	res, err := postgres.Conn(ctx, r.db).ExecContext(ctx, q, qArgs...)
 	if err != nil {
 		return -1, err
 	}
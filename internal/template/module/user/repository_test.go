@@ -0,0 +1,23 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres/postgrestest"
+)
+
+func TestPurge(t *testing.T) {
+	db, rec := postgrestest.NewDB()
+	r := NewRepository(sqlx.NewDb(db, "pgx"), postgres.NewTxManager(db))
+	if _, err := r.Purge(10, 100, context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the users still owning contents are skipped rather than failing the batch
+	expected := `DELETE FROM "users" WHERE "id" IN (SELECT "id" FROM "users" WHERE "deleted_at" < $1 AND NOT (EXISTS (SELECT "id" FROM "contents" WHERE "contents"."user_id" = "users"."id")) LIMIT $2)`
+	if log := rec.Log(); len(log) != 1 || log[0] != expected {
+		t.Errorf("Expected '%v', but got '%v'", expected, log)
+	}
+}
@@ -2,24 +2,32 @@ package user
 
 import (
 	"context"
-	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
-	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/errorext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/response"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user/dto"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user/entity"
 	"github.com/tanveerprottoy/stdlib-go-template/pkg/timeext"
 )
 
 type Service struct {
-	repository sqlxext.Repository[entity.User]
+	repository AccountRepository
+	txManager  *postgres.TxManager
+	cursors    *pagination.Codec
 }
 
-func NewService(r sqlxext.Repository[entity.User]) *Service {
+func NewService(r AccountRepository, tm *postgres.TxManager, c *pagination.Codec) *Service {
 	s := new(Service)
 	s.repository = r
+	s.txManager = tm
+	s.cursors = c
 	return s
 }
 
@@ -27,33 +35,83 @@ func (s *Service) ReadOneInternal(id string, ctx context.Context) (entity.User,
 	return s.repository.ReadOne(id, ctx)
 }
 
+// ReadOneByEmailInternal reads the user of the email, case insensitive
+func (s *Service) ReadOneByEmailInternal(email string, ctx context.Context) (entity.User, error) {
+	return s.repository.ReadOneByEmail(NormalizeEmail(email), ctx)
+}
+
+// CreateInternal inserts the user filling in the defaults,
+// the email is normalized and the password hash is kept as is
+func (s *Service) CreateInternal(e entity.User, ctx context.Context) (entity.User, error) {
+	if e.Email != nil {
+		email := NormalizeEmail(*e.Email)
+		e.Email = &email
+	}
+	if e.Role == "" {
+		e.Role = constant.RoleUser
+	}
+	if e.Status == "" {
+		e.Status = constant.UserStatusActive
+	}
+	n := timeext.NowUnixMilli()
+	e.CreatedAt = n
+	e.UpdatedAt = n
+	// the column default
+	e.Version = 1
+	id, err := s.repository.Create(e, ctx)
+	if err != nil {
+		return e, err
+	}
+	e.ID = id
+	return e, nil
+}
+
 func (s *Service) Create(d *dto.CreateUpdateUserDTO, ctx context.Context) (entity.User, errorext.HTTPError) {
 	// convert dto to entity
 	e := entity.User{}
 	e.Name = d.Name
-	// b.Role = d.Role
-	n := timeext.NowUnixMilli()
-	e.CreatedAt = n
-	e.UpdatedAt = n
-	err := s.repository.Create(e, ctx)
+	e.Email = &d.Email
+	e.Phone = &d.Phone
+	age := int16(d.Age)
+	e.Age = &age
+	e, err := s.CreateInternal(e, ctx)
 	if err != nil {
 		return e, errorext.BuildDBError(err)
 	}
 	return e, errorext.HTTPError{}
 }
 
-func (s *Service) ReadMany(limit, page int, ctx context.Context) (map[string]any, errorext.HTTPError) {
-	m := make(map[string]any)
-	m["items"] = make([]entity.User, 0)
-	m["limit"] = limit
-	m["page"] = page
-	offset := limit * (page - 1)
-	d, err := s.repository.ReadMany(limit, offset, ctx)
-	if err != nil {
-		return m, errorext.BuildDBError(err)
+func (s *Service) ReadMany(p pagination.Params, opts postgres.ListOptions, ctx context.Context) (response.ReadManyResponse[entity.User], errorext.HTTPError) {
+	res := response.ReadManyResponse[entity.User]{Items: []entity.User{}, Limit: p.Limit, Page: p.Page}
+	if p.CursorMode {
+		if len(opts.OrderBy) > 0 {
+			return res, errorext.HTTPError{Code: http.StatusBadRequest, Err: pagination.ErrSortWithCursor}
+		}
+		c, err := s.cursors.Decode(p.Cursor)
+		if err != nil {
+			return res, errorext.HTTPError{Code: http.StatusBadRequest, Err: err}
+		}
+		// one extra row tells if there is a next page
+		d, err := s.repository.ReadManySeek(p.Limit+1, c, opts, ctx)
+		if err != nil {
+			return res, errorext.BuildDBError(err)
+		}
+		res.Items, res.NextCursor, res.PrevCursor = pagination.Cursors(d, p.Limit, c, cursorKey, s.cursors)
+	} else {
+		d, err := s.repository.ReadMany(p.Limit, p.Offset(), opts, ctx)
+		if err != nil {
+			return res, errorext.BuildDBError(err)
+		}
+		res.Items = d
+	}
+	if p.Total != pagination.TotalNone {
+		n, err := s.repository.Count(p.Total, opts, ctx)
+		if err != nil {
+			return res, errorext.BuildDBError(err)
+		}
+		res.Total = &n
 	}
-	m["items"] = d
-	return m, errorext.HTTPError{}
+	return res, errorext.HTTPError{}
 }
 
 func (s *Service) ReadOne(id string, ctx context.Context) (entity.User, errorext.HTTPError) {
@@ -64,34 +122,119 @@ func (s *Service) ReadOne(id string, ctx context.Context) (entity.User, errorext
 	return b, errorext.HTTPError{}
 }
 
-func (s *Service) Update(id string, d *dto.CreateUpdateUserDTO, ctx context.Context) (entity.User, errorext.HTTPError) {
-	b, err := s.ReadOneInternal(id, ctx)
+// Update updates the user if it matches ifMatch, the
+// precondition is skipped when ifMatch is empty
+func (s *Service) Update(id string, d *dto.CreateUpdateUserDTO, ifMatch httpext.ETagList, ctx context.Context) (entity.User, errorext.HTTPError) {
+	var b entity.User
+	// read & write in one tx
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		b, err = s.ReadOneInternal(id, ctx)
+		if err != nil {
+			return err
+		}
+		if ifMatch != "" && !ifMatch.Matches(b.ETag()) {
+			return errorext.ErrPreconditionFailed
+		}
+		email := NormalizeEmail(d.Email)
+		// the new email has to be verified again
+		if b.Email == nil || *b.Email != email {
+			b.EmailVerified = false
+		}
+		age := int16(d.Age)
+		b.Name = d.Name
+		b.Email = &email
+		b.Phone = &d.Phone
+		b.Age = &age
+		b.UpdatedAt = timeext.NowUnixMilli()
+		rows, err := s.repository.Update(id, b, ctx)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			// modified since it was read
+			return errorext.ErrPreconditionFailed
+		}
+		b.Version++
+		return nil
+	})
 	if err != nil {
 		return b, errorext.BuildDBError(err)
 	}
-	b.Name = d.Name
-	b.UpdatedAt = timeext.NowUnixMilli()
-	rows, err := s.repository.Update(id, b, ctx)
+	return b, errorext.HTTPError{}
+}
+
+// Delete soft deletes the user if it matches ifMatch,
+// p is the deleting principal
+func (s *Service) Delete(id string, p authn.Principal, ifMatch httpext.ETagList, ctx context.Context) (entity.User, errorext.HTTPError) {
+	var b entity.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		b, err = s.ReadOneInternal(id, ctx)
+		if err != nil {
+			return err
+		}
+		if ifMatch != "" && !ifMatch.Matches(b.ETag()) {
+			return errorext.ErrPreconditionFailed
+		}
+		now := timeext.NowUnixMilli()
+		rows, err := s.repository.Delete(id, p.Subject, b.Version, ctx)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return errorext.ErrPreconditionFailed
+		}
+		// as written so the response has the etag of the deleted version
+		b.Version++
+		b.DeletedAt = &now
+		if p.Subject != "" {
+			b.DeletedBy = &p.Subject
+		}
+		return nil
+	})
 	if err != nil {
 		return b, errorext.BuildDBError(err)
 	}
-	if rows > 0 {
-		return b, errorext.HTTPError{}
-	}
-	return b, errorext.HTTPError{Code: http.StatusBadRequest, Err: errors.New(constant.OperationNotSuccess)}
+	return b, errorext.HTTPError{}
 }
 
-func (s *Service) Delete(id string, ctx context.Context) (entity.User, errorext.HTTPError) {
-	b, err := s.ReadOneInternal(id, ctx)
+// Restore undoes the soft delete of the user
+func (s *Service) Restore(id string, ctx context.Context) (entity.User, errorext.HTTPError) {
+	b, err := s.repository.Restore(id, ctx)
 	if err != nil {
 		return b, errorext.BuildDBError(err)
 	}
-	rows, err := s.repository.Delete(id, ctx)
+	return b, errorext.HTTPError{}
+}
+
+// DeleteHard permanently deletes the user if it matches ifMatch
+func (s *Service) DeleteHard(id string, ifMatch httpext.ETagList, ctx context.Context) (entity.User, errorext.HTTPError) {
+	var b entity.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		b, err = s.repository.DeleteHard(id, ctx)
+		if err != nil {
+			return err
+		}
+		// the delete is rolled back on mismatch
+		if ifMatch != "" && !ifMatch.Matches(b.ETag()) {
+			return errorext.ErrPreconditionFailed
+		}
+		return nil
+	})
 	if err != nil {
 		return b, errorext.BuildDBError(err)
 	}
-	if rows > 0 {
-		return b, errorext.HTTPError{}
-	}
-	return b, errorext.HTTPError{Code: http.StatusBadRequest, Err: errors.New(constant.OperationNotSuccess)}
+	return b, errorext.HTTPError{}
+}
+
+// cursorKey is the keyset of the entity used by the cursors
+func cursorKey(e entity.User) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: e.CreatedAt, ID: e.ID}
+}
+
+// NormalizeEmail lower cases & trims the email, emails are stored normalized
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
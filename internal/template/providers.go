@@ -0,0 +1,379 @@
+package template
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/migrate"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/sqlxext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/di"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/health"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/httpext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/metrics"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/purge"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/router"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/s3ext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/secrets"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/validatorext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user"
+	"github.com/tanveerprottoy/stdlib-go-template/migrations"
+)
+
+// provide registers the constructors of the components shared by the
+// modules, a component is built when first resolved, after the ones it
+// uses, so the hooks it appends are stopped before theirs
+func provide(c *di.Container, args []string) {
+	di.Provide(c, di.Singleton, newValidator)
+	di.Provide(c, di.Singleton, func(*di.Container) (*loadedConfig, error) {
+		cfg, err := config.Load(args)
+		if err != nil {
+			return nil, err
+		}
+		return &loadedConfig{cfg}, nil
+	})
+	di.Provide(c, di.Singleton, newSecrets)
+	di.Provide(c, di.Singleton, newConfig)
+	di.Provide(c, di.Singleton, func(c *di.Container) (*Lifecycle, error) {
+		return NewLifecycle(di.MustResolve[*config.Config](c).Server.ShutdownTimeout), nil
+	})
+	di.Provide(c, di.Singleton, func(*di.Container) (*metrics.Registry, error) {
+		return metrics.NewRegistry(), nil
+	})
+	di.Provide(c, di.Singleton, newDBClient)
+	di.Provide(c, di.Singleton, func(c *di.Container) (*sqlx.DB, error) {
+		return di.MustResolve[*sqlxext.Client](c).DB, nil
+	})
+	di.Provide(c, di.Singleton, func(c *di.Container) (*postgres.TxManager, error) {
+		return postgres.NewTxManager(di.MustResolve[*sqlx.DB](c).DB), nil
+	})
+	di.Provide(c, di.Singleton, newRouter)
+	di.Provide(c, di.Singleton, newS3)
+	di.Provide(c, di.Singleton, newHTTPClientProvider)
+	di.Provide(c, di.Singleton, newHealth)
+	di.Provide(c, di.Singleton, newCursors)
+	di.Provide(c, di.Singleton, newJWTKeys)
+	di.Provide(c, di.Singleton, newSigner)
+	di.Provide(c, di.Singleton, newVerifier)
+	di.Provide(c, di.Singleton, newPurgeJob)
+}
+
+// newValidator creates the validator naming the fields by their json tag
+func newValidator(*di.Container) (*validator.Validate, error) {
+	v := validator.New()
+	validatorext.RegisterTagNameFunc(v)
+	_ = v.RegisterValidation("notempty", validatorext.NotEmpty)
+	_ = v.RegisterValidation("bcryptmax", validatorext.BcryptMax)
+	return v, nil
+}
+
+// loadedConfig is the config before its secret references are
+// resolved, the resolver needs its master key
+type loadedConfig struct {
+	*config.Config
+}
+
+// newSecrets creates the resolver of the secret references, the encfile
+// ones are decrypted with the master key of the config
+func newSecrets(c *di.Container) (*secrets.Resolver, error) {
+	key, err := secrets.MasterKey(di.MustResolve[*loadedConfig](c).Secrets.MasterKey, context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("decode secrets.masterKey: %w", err)
+	}
+	return secrets.NewDefaultResolver(key), nil
+}
+
+// newConfig resolves the secret references of the loaded
+// config & validates it, see config.Load
+func newConfig(c *di.Container) (*config.Config, error) {
+	cfg := di.MustResolve[*loadedConfig](c).Config
+	if err := cfg.ResolveSecrets(di.MustResolve[*secrets.Resolver](c), context.Background()); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(di.MustResolve[*validator.Validate](c)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// onSecretChange calls fn with the new secret of the config path, ex:
+// db.pass, when it was a reference & it's rotated
+func onSecretChange(c *di.Container, path string, fn func(string)) {
+	if ref, ok := di.MustResolve[*config.Config](c).SecretRef(path); ok {
+		di.MustResolve[*secrets.Resolver](c).OnChange(ref, fn)
+	}
+}
+
+// newDBClient connects to the db & checks its migrations
+func newDBClient(c *di.Container) (*sqlxext.Client, error) {
+	cfg := di.MustResolve[*config.Config](c).DB
+	l := di.MustResolve[*Lifecycle](c)
+	client, err := sqlxext.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect db: %w", err)
+	}
+	onSecretChange(c, "db.pass", client.SetPassword)
+	di.MustResolve[*metrics.Registry](c).RegisterDB("sqlxext", client.DB.DB)
+	l.Append(Hook{Name: "db", Stop: func(context.Context) error {
+		return client.DB.Close()
+	}})
+	if err := checkMigrations(client, cfg.AutoMigrate); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// checkMigrations refuses to start the app when there are pending
+// migrations, unless auto migrate is enabled in which case they are applied
+func checkMigrations(client *sqlxext.Client, autoMigrate bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	m, err := migrate.NewMigrator(client.DB.DB, migrations.FS)
+	if err != nil {
+		return fmt.Errorf("load migrations: %w", err)
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return fmt.Errorf("check migrations: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if !autoMigrate {
+		return fmt.Errorf("%d pending migrations, run `migrate up` or set DB_AUTO_MIGRATE=true", len(pending))
+	}
+	applied, err := m.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("auto migrate: %w", err)
+	}
+	slog.Info("applied migrations", "applied", len(applied))
+	return nil
+}
+
+// newRouter creates the router serving the metrics of the
+// app along the ones of the packages
+func newRouter(c *di.Container) (*router.Router, error) {
+	r := router.NewRouter()
+	r.Mux.Method(http.MethodGet, constant.MetricsPattern, metrics.Handler(di.MustResolve[*metrics.Registry](c), metrics.Default))
+	return r, nil
+}
+
+// newS3 initializes the s3 clients
+func newS3(c *di.Container) (*s3ext.Clients, error) {
+	cfg := di.MustResolve[*config.Config](c).S3
+	l := di.MustResolve[*Lifecycle](c)
+	endpointResolverFunc := s3.EndpointResolverFunc(func(region string, options s3.EndpointResolverOptions) (aws.Endpoint, error) {
+		if cfg.Endpoint != "" {
+			return aws.Endpoint{
+				PartitionID:   "aws",
+				URL:           cfg.Endpoint,
+				SigningRegion: cfg.Region,
+			}, nil
+		}
+		// returning EndpointNotFoundError will allow the service to fallback to it's default resolution
+		return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+	})
+	var creds aws.CredentialsProvider
+	if cfg.AccessKey != "" {
+		creds = s3Credentials(c, cfg)
+	}
+	clients := s3ext.NewClients()
+	clients.Init(s3.Options{
+		Region: cfg.Region,
+	}, func(o *s3.Options) {
+		o.EndpointResolver = endpointResolverFunc
+		o.UsePathStyle = true
+		// frozen so its idle connections can be closed
+		o.HTTPClient = awshttp.NewBuildableClient().Freeze()
+		if creds != nil {
+			o.Credentials = creds
+		}
+	})
+	l.Append(Hook{Name: "s3", Stop: func(context.Context) error {
+		clients.Close()
+		return nil
+	}})
+	return clients, nil
+}
+
+// s3Credentials provides the access keys of the config,
+// kept up to date with their rotations
+func s3Credentials(c *di.Container, cfg config.S3) aws.CredentialsProvider {
+	var creds atomic.Pointer[aws.Credentials]
+	creds.Store(&aws.Credentials{AccessKeyID: cfg.AccessKey, SecretAccessKey: cfg.SecretKey})
+	onSecretChange(c, "s3.accessKey", func(v string) {
+		c := *creds.Load()
+		c.AccessKeyID = v
+		creds.Store(&c)
+	})
+	onSecretChange(c, "s3.secretKey", func(v string) {
+		c := *creds.Load()
+		c.SecretAccessKey = v
+		creds.Store(&c)
+	})
+	return aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return *creds.Load(), nil
+	})
+}
+
+func newHTTPClientProvider(c *di.Container) (*httpext.ClientProvider, error) {
+	p := httpext.NewClientProvider(90*time.Second, nil, nil)
+	di.MustResolve[*Lifecycle](c).Append(Hook{Name: "httpClient", Stop: func(context.Context) error {
+		p.HTTPClient.CloseIdleConnections()
+		return nil
+	}})
+	return p, nil
+}
+
+// newHealth registers the checks of the dependencies served by
+// /readyz & /health, /healthz checks none
+func newHealth(c *di.Container) (*health.Registry, error) {
+	cfg := di.MustResolve[*config.Config](c)
+	h := health.NewRegistry(cfg.Health.CacheTTL)
+	timeout := cfg.Health.CheckTimeout
+	h.Register("db", timeout, health.DB(di.MustResolve[*sqlx.DB](c).DB))
+	bucket := cfg.S3.Bucket
+	clients := di.MustResolve[*s3ext.Clients](c)
+	h.Register("s3", timeout, health.CheckerFunc(func(ctx context.Context) error {
+		_, err := s3ext.GetBucket(bucket, clients.S3Client, ctx)
+		return err
+	}))
+	if url := cfg.Services.UserBaseURL; url != "" {
+		h.Register("userService", timeout, health.HTTP(di.MustResolve[*httpext.ClientProvider](c).HTTPClient, url))
+	}
+	return h, nil
+}
+
+// newCursors creates the codec of the pagination cursors
+func newCursors(c *di.Container) (*pagination.Codec, error) {
+	secret := []byte(di.MustResolve[*config.Config](c).Auth.CursorSecret)
+	if len(secret) == 0 {
+		// cursors won't survive a restart nor work across replicas
+		slog.Warn("CURSOR_SECRET is not set, using a random secret")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generate cursor secret: %w", err)
+		}
+	}
+	codec := pagination.NewCodec(secret)
+	onSecretChange(c, "auth.cursorSecret", func(secret string) {
+		codec.SetSecret([]byte(secret))
+	})
+	return codec, nil
+}
+
+// newSigner creates the signer of the tokens issued by the app, with
+// the asymmetric keys of the key set or with the jwt secret (HS256)
+// when there is none
+func newSigner(c *di.Container) (*jwtext.Signer, error) {
+	cfg := di.MustResolve[*config.Config](c).Auth
+	s := jwtext.NewSigner(cfg.Issuer, cfg.Audience)
+	s.UseSecret(cfg.JWTSecret)
+	onSecretChange(c, "auth.jwtSecret", s.UseSecret)
+	if keys := di.MustResolve[*jwtext.KeySet](c); keys != nil {
+		s.UseKeySet(keys)
+	}
+	return s, nil
+}
+
+// newJWTKeys loads the asymmetric keys of the auth keys, a pem file or
+// a directory of them which is watched for rotations, the key set is
+// nil when it is not set
+func newJWTKeys(c *di.Container) (*jwtext.KeySet, error) {
+	cfg := di.MustResolve[*config.Config](c).Auth
+	path := cfg.Keys
+	if path == "" {
+		return nil, nil
+	}
+	keys := jwtext.NewKeySet(cfg.KeyGrace, cfg.KeyActivationDelay)
+	if err := keys.Load(path); err != nil {
+		return nil, fmt.Errorf("load jwt keys: %w", err)
+	}
+	interval := cfg.KeyReloadInterval
+	di.MustResolve[*Lifecycle](c).Go("jwtKeyWatch", func(ctx context.Context) {
+		keys.Watch(ctx, path, interval)
+	})
+	return keys, nil
+}
+
+// newVerifier creates the verifier of the bearer tokens selected by
+// the auth verifier, local (default) verifies the tokens issued by this
+// app, jwks the ones of another issuer with its jwks url & introspection
+// asks the introspection url of an OAuth 2.0 server (RFC 7662)
+func newVerifier(c *di.Container) (authn.Verifier, error) {
+	cfg := di.MustResolve[*config.Config](c).Auth
+	rules := authn.Rules{
+		Issuer:   cfg.ExpectedIssuer,
+		Audience: cfg.Audience,
+		Leeway:   cfg.Leeway,
+	}
+	switch kind := cfg.Verifier; kind {
+	case "", "local":
+		if rules.Issuer == "" {
+			rules.Issuer = cfg.Issuer
+		}
+		return authn.NewLocalVerifier(di.MustResolve[*jwtext.Signer](c), rules), nil
+	case "jwks":
+		ctx, cancel := context.WithCancel(context.Background())
+		v, err := authn.NewJWKSVerifier(
+			ctx,
+			cfg.JWKSURL,
+			cfg.JWKSRefreshInterval,
+			rules,
+		)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("load jwks: %w", err)
+		}
+		di.MustResolve[*Lifecycle](c).Append(Hook{Name: "jwks", Stop: func(context.Context) error {
+			cancel()
+			return nil
+		}})
+		return v, nil
+	case "introspection":
+		v := authn.NewIntrospectionVerifier(
+			cfg.IntrospectionURL,
+			cfg.IntrospectionID,
+			cfg.IntrospectionSecret,
+			httpext.NewClientProvider(10*time.Second, nil, nil).HTTPClient,
+			rules,
+		)
+		onSecretChange(c, "auth.introspectionClientSecret", v.SetClientSecret)
+		return v, nil
+	default:
+		return nil, errors.New("invalid auth verifier " + kind)
+	}
+}
+
+// newPurgeJob creates the job permanently deleting the rows
+// soft deleted longer than the purge retention ago
+func newPurgeJob(c *di.Container) (*purge.Job, error) {
+	cfg := di.MustResolve[*config.Config](c).Purge
+	// contents reference users so they are purged first, a user
+	// still owning some is skipped, the expired auth tokens are purged along
+	return purge.NewJob(
+		cfg.Retention,
+		cfg.Interval,
+		di.MustResolve[*auth.Module](c).Repository,
+		di.MustResolve[*content.Module](c).Repository,
+		di.MustResolve[*user.Module](c).Repository,
+	), nil
+}
@@ -0,0 +1,50 @@
+package router
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/di"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/middleware"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/router"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/apikey"
+
+	"github.com/go-chi/chi"
+)
+
+func RegisterAPIKeyRoutes(router *router.Router, version string, module *apikey.Module, rbacMiddleWare *middleware.RBAC) {
+	router.Mux.Route(
+		constant.ApiPattern+version+constant.APIKeysPattern,
+		func(r chi.Router) {
+			// protected routes
+			r.With(rbacMiddleWare.Require("apikeys:read")).Get(constant.RootPattern, module.Handler.ReadMany)
+			r.With(rbacMiddleWare.Require("apikeys:write")).Post(constant.RootPattern, module.Handler.Create)
+			r.With(rbacMiddleWare.Require("apikeys:write")).Delete(constant.RootPattern+"{id}", module.Handler.Revoke)
+		},
+	)
+}
+
+type APIKeyModule struct{}
+
+func init() {
+	register(APIKeyModule{})
+}
+
+func (APIKeyModule) Provide(c *di.Container) {
+	di.Provide(c, di.Singleton, func(c *di.Container) (*apikey.Module, error) {
+		return apikey.NewModule(
+			di.MustResolve[*sqlx.DB](c),
+			di.MustResolve[*validator.Validate](c),
+		), nil
+	})
+}
+
+func (APIKeyModule) Register(c *di.Container) error {
+	RegisterAPIKeyRoutes(
+		di.MustResolve[*router.Router](c),
+		constant.V1,
+		di.MustResolve[*apikey.Module](c),
+		di.MustResolve[*middleware.RBAC](c),
+	)
+	return nil
+}
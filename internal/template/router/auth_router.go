@@ -0,0 +1,86 @@
+package router
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/authn"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/di"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/jwtext"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/middleware"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/rbac"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/router"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/apikey"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/auth"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user"
+
+	"github.com/go-chi/chi"
+)
+
+func RegisterAuthRoutes(router *router.Router, version string, module *auth.Module, authMiddleWare *middleware.Auth) {
+	// public keys of the token signatures
+	router.Mux.Get(constant.JWKSPattern, module.Handler.JWKS)
+	router.Mux.Route(
+		constant.ApiPattern+version+constant.AuthPattern,
+		func(r chi.Router) {
+			// public routes
+			r.Post(constant.RootPattern+"register", module.Handler.Register)
+			r.Post(constant.RootPattern+"login", module.Handler.Login)
+			r.Post(constant.RootPattern+"refresh", module.Handler.Refresh)
+			r.Group(func(r chi.Router) {
+				// protected routes
+				r.Use(authMiddleWare.AuthUser)
+				r.Post(constant.RootPattern+"logout", module.Handler.Logout)
+				r.Post(constant.RootPattern+"logout-all", module.Handler.LogoutAll)
+			})
+		},
+	)
+}
+
+// AuthModule provides the auth & rbac middlewares along,
+// they are built on its service
+type AuthModule struct{}
+
+func init() {
+	register(AuthModule{})
+}
+
+func (AuthModule) Provide(c *di.Container) {
+	di.Provide(c, di.Singleton, func(c *di.Container) (*auth.Module, error) {
+		cfg := di.MustResolve[*config.Config](c).Auth
+		m := auth.NewModule(
+			di.MustResolve[*sqlx.DB](c),
+			di.MustResolve[*postgres.TxManager](c),
+			di.MustResolve[*user.Module](c).Service,
+			di.MustResolve[authn.Verifier](c),
+			di.MustResolve[*jwtext.Signer](c),
+			cfg.AccessTTL,
+			cfg.RefreshTTL,
+			di.MustResolve[*validator.Validate](c),
+		)
+		m.Service.SetAPIKeys(di.MustResolve[*apikey.Module](c).Service)
+		return m, nil
+	})
+	di.Provide(c, di.Singleton, func(c *di.Container) (*middleware.Auth, error) {
+		return middleware.NewAuth(di.MustResolve[*auth.Module](c).Service), nil
+	})
+	di.Provide(c, di.Singleton, func(c *di.Container) (*middleware.RBAC, error) {
+		s := di.MustResolve[*auth.Module](c).Service
+		m := middleware.NewRBAC(s, di.MustResolve[*rbac.Policy](c))
+		s.SetUserRoles(m.UserRoles)
+		di.MustResolve[*apikey.Module](c).Service.SetPolicy(m.Policy)
+		return m, nil
+	})
+}
+
+func (AuthModule) Register(c *di.Container) error {
+	RegisterAuthRoutes(
+		di.MustResolve[*router.Router](c),
+		constant.V1,
+		di.MustResolve[*auth.Module](c),
+		di.MustResolve[*middleware.Auth](c),
+	)
+	return nil
+}
@@ -1,8 +1,13 @@
 package router
 
 import (
+	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/di"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/middleware"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/router"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/content"
 
@@ -16,15 +21,50 @@ func RegisterContentRoutes(router *router.Router, version string, module *conten
 			// public routes
 			r.Get(constant.RootPattern+"public", module.Handler.Public)
 			r.Group(func(r chi.Router) {
-				// protected routes
-				// r.Use(rbacMiddleWare.AuthRole)
-				// r.Use(authMiddleWare.AuthUser)
-				r.Post(constant.RootPattern, module.Handler.Create)
+				// readable by anyone, the service filters
+				// the contents by the principal if any
+				r.Use(authMiddleWare.OptionalUser)
 				r.Get(constant.RootPattern, module.Handler.ReadMany)
 				r.Get(constant.RootPattern+"{id}", module.Handler.ReadOne)
-				r.Patch(constant.RootPattern+"{id}", module.Handler.Update)
-				r.Delete(constant.RootPattern+"{id}", module.Handler.Delete)
+			})
+			r.Group(func(r chi.Router) {
+				// protected routes
+				r.With(rbacMiddleWare.Require("contents:create")).Post(constant.RootPattern, module.Handler.Create)
+				r.With(rbacMiddleWare.Require("contents:update")).Patch(constant.RootPattern+"{id}", module.Handler.Update)
+				r.With(rbacMiddleWare.Require("contents:restore")).Post(constant.RootPattern+"{id}/restore", module.Handler.Restore)
+				r.With(
+					rbacMiddleWare.Require("contents:delete"),
+					rbacMiddleWare.RequireIf(middleware.QueryFlag(constant.KeyHard), "contents:delete:hard"),
+				).Delete(constant.RootPattern+"{id}", module.Handler.Delete)
 			})
 		},
 	)
 }
+
+type ContentModule struct{}
+
+func init() {
+	register(ContentModule{})
+}
+
+func (ContentModule) Provide(c *di.Container) {
+	di.Provide(c, di.Singleton, func(c *di.Container) (*content.Module, error) {
+		return content.NewModule(
+			di.MustResolve[*sqlx.DB](c),
+			di.MustResolve[*postgres.TxManager](c),
+			di.MustResolve[*pagination.Codec](c),
+			di.MustResolve[*validator.Validate](c),
+		), nil
+	})
+}
+
+func (ContentModule) Register(c *di.Container) error {
+	RegisterContentRoutes(
+		di.MustResolve[*router.Router](c),
+		constant.V1,
+		di.MustResolve[*content.Module](c),
+		di.MustResolve[*middleware.Auth](c),
+		di.MustResolve[*middleware.RBAC](c),
+	)
+	return nil
+}
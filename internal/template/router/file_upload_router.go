@@ -1,8 +1,11 @@
 package router
 
 import (
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/di"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/router"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/s3ext"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/fileupload"
 
 	"github.com/go-chi/chi"
@@ -21,3 +24,27 @@ func RegisterFileUploadRoutes(router *router.Router, version string, module *fil
 		},
 	)
 }
+
+type FileUploadModule struct{}
+
+func init() {
+	register(FileUploadModule{})
+}
+
+func (FileUploadModule) Provide(c *di.Container) {
+	di.Provide(c, di.Singleton, func(c *di.Container) (*fileupload.Module, error) {
+		return fileupload.NewModule(
+			di.MustResolve[*s3ext.Clients](c),
+			di.MustResolve[*config.Config](c).S3,
+		), nil
+	})
+}
+
+func (FileUploadModule) Register(c *di.Container) error {
+	RegisterFileUploadRoutes(
+		di.MustResolve[*router.Router](c),
+		constant.V1,
+		di.MustResolve[*fileupload.Module](c),
+	)
+	return nil
+}
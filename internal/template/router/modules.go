@@ -0,0 +1,25 @@
+package router
+
+import "github.com/tanveerprottoy/stdlib-go-template/internal/pkg/di"
+
+// the modules provide their constructors to the container & register
+// their routes on the *router.Router of it, they live here rather than
+// in the module packages as the middleware package imports auth, each
+// one registers itself in the init of its router file so adding a
+// module is adding its file
+
+// modules are appended by the inits only, they're read-only after
+var modules []di.Module
+
+// register adds the module to the ones of the app, it's called
+// by the init of the router file of the module
+func register(m di.Module) {
+	modules = append(modules, m)
+}
+
+// Modules returns the modules of the app, their order is the one of
+// their files, they mustn't depend on it as they are all provided
+// before any registers
+func Modules() []di.Module {
+	return append([]di.Module(nil), modules...)
+}
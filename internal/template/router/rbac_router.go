@@ -0,0 +1,91 @@
+package router
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/config"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/di"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/middleware"
+	rbacpkg "github.com/tanveerprottoy/stdlib-go-template/internal/pkg/rbac"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/router"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/rbac"
+	"github.com/tanveerprottoy/stdlib-go-template/pkg/file"
+
+	"github.com/go-chi/chi"
+)
+
+func RegisterRBACRoutes(router *router.Router, version string, module *rbac.Module, rbacMiddleWare *middleware.RBAC) {
+	router.Mux.Route(
+		constant.ApiPattern+version+constant.RBACPattern,
+		func(r chi.Router) {
+			r.Group(func(r chi.Router) {
+				// protected routes
+				r.Use(rbacMiddleWare.Require("rbac:read"))
+				r.Get(constant.RootPattern+"roles", module.Handler.ReadRoles)
+				r.Get(constant.RootPattern+"roles/{name}", module.Handler.ReadRole)
+				r.Get(constant.RootPattern+"permissions", module.Handler.ReadPermissions)
+				r.Get(constant.RootPattern+"users/{id}/roles", module.Handler.ReadUserRoles)
+				r.Get(constant.RootPattern+"export", module.Handler.Export)
+			})
+			r.Group(func(r chi.Router) {
+				r.Use(rbacMiddleWare.Require(rbac.WritePermission))
+				r.Post(constant.RootPattern+"roles", module.Handler.CreateRole)
+				r.Put(constant.RootPattern+"roles/{name}", module.Handler.UpdateRole)
+				r.Delete(constant.RootPattern+"roles/{name}", module.Handler.DeleteRole)
+				r.Post(constant.RootPattern+"permissions", module.Handler.CreatePermission)
+				r.Delete(constant.RootPattern+"permissions/{name}", module.Handler.DeletePermission)
+				r.Put(constant.RootPattern+"users/{id}/roles", module.Handler.SetUserRoles)
+				r.Post(constant.RootPattern+"import", module.Handler.Import)
+			})
+		},
+	)
+}
+
+// RBACModule provides the policy along, loaded from the rbac tables,
+// they are seeded from the rbac config, config/rbac.json by default, when empty
+type RBACModule struct{}
+
+func init() {
+	register(RBACModule{})
+}
+
+func (RBACModule) Provide(c *di.Container) {
+	di.Provide(c, di.Singleton, func(c *di.Container) (*rbac.Module, error) {
+		return rbac.NewModule(
+			di.MustResolve[*sqlx.DB](c),
+			di.MustResolve[*postgres.TxManager](c),
+			di.MustResolve[*validator.Validate](c),
+		), nil
+	})
+	di.Provide(c, di.Singleton, func(c *di.Container) (*rbacpkg.Policy, error) {
+		path := di.MustResolve[*config.Config](c).RBAC.Config
+		if path == "" {
+			pwd, _ := file.GetPWD()
+			path = filepath.Join(pwd, "config", "rbac.json")
+		}
+		cfg, err := rbacpkg.ReadConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		s := di.MustResolve[*rbac.Module](c).Service
+		ctx := context.Background()
+		if err := s.Seed(cfg, ctx); err != nil {
+			return nil, err
+		}
+		return s.Load(ctx)
+	})
+}
+
+func (RBACModule) Register(c *di.Container) error {
+	m := di.MustResolve[*rbac.Module](c)
+	rm := di.MustResolve[*middleware.RBAC](c)
+	m.Service.OnChange(rm.SetPolicy)
+	m.Service.SetCheck(rm.CheckPolicy)
+	RegisterRBACRoutes(di.MustResolve[*router.Router](c), constant.V1, m, rm)
+	return nil
+}
@@ -1,28 +1,64 @@
 package router
 
 import (
+	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/constant"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/data/postgres"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/di"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/middleware"
+	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/pagination"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/pkg/router"
 	"github.com/tanveerprottoy/stdlib-go-template/internal/template/module/user"
 
 	"github.com/go-chi/chi"
 )
 
-func RegisterUserRoutes(router *router.Router, version string, module *user.Module, authMiddleWare *middleware.Auth) {
+func RegisterUserRoutes(router *router.Router, version string, module *user.Module, rbacMiddleWare *middleware.RBAC) {
 	router.Mux.Route(
 		constant.ApiPattern+version+constant.UsersPattern,
 		func(r chi.Router) {
 			// public routes
 			r.Get(constant.RootPattern+"public", module.Handler.Public)
 			r.Group(func(r chi.Router) {
-				// r.Use(authMiddleWare.AuthUser)
-				r.Get(constant.RootPattern, module.Handler.ReadMany)
-				r.Get(constant.RootPattern+"{id}", module.Handler.ReadOne)
-				r.Post(constant.RootPattern, module.Handler.Create)
-				r.Patch(constant.RootPattern+"{id}", module.Handler.Update)
-				r.Delete(constant.RootPattern+"{id}", module.Handler.Delete)
+				// protected routes
+				r.With(rbacMiddleWare.Require("users:read")).Get(constant.RootPattern, module.Handler.ReadMany)
+				r.With(rbacMiddleWare.Require("users:read")).Get(constant.RootPattern+"{id}", module.Handler.ReadOne)
+				r.With(rbacMiddleWare.Require("users:create")).Post(constant.RootPattern, module.Handler.Create)
+				r.With(rbacMiddleWare.Require("users:update")).Patch(constant.RootPattern+"{id}", module.Handler.Update)
+				r.With(rbacMiddleWare.Require("users:restore")).Post(constant.RootPattern+"{id}/restore", module.Handler.Restore)
+				r.With(
+					rbacMiddleWare.Require("users:delete"),
+					rbacMiddleWare.RequireIf(middleware.QueryFlag(constant.KeyHard), "users:delete:hard"),
+				).Delete(constant.RootPattern+"{id}", module.Handler.Delete)
 			})
 		},
 	)
 }
+
+type UserModule struct{}
+
+func init() {
+	register(UserModule{})
+}
+
+func (UserModule) Provide(c *di.Container) {
+	di.Provide(c, di.Singleton, func(c *di.Container) (*user.Module, error) {
+		return user.NewModule(
+			di.MustResolve[*sqlx.DB](c),
+			di.MustResolve[*postgres.TxManager](c),
+			di.MustResolve[*pagination.Codec](c),
+			di.MustResolve[*validator.Validate](c),
+		), nil
+	})
+}
+
+func (UserModule) Register(c *di.Container) error {
+	RegisterUserRoutes(
+		di.MustResolve[*router.Router](c),
+		constant.V1,
+		di.MustResolve[*user.Module](c),
+		di.MustResolve[*middleware.RBAC](c),
+	)
+	return nil
+}
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS contents (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR NOT NULL,
+    is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at BIGINT,
+    updated_at BIGINT,
+    user_id uuid REFERENCES users(id)
+);
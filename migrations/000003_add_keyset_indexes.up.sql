@@ -0,0 +1,3 @@
+-- keyset pagination seeks on (created_at, id)
+CREATE INDEX IF NOT EXISTS users_created_at_id_idx ON users (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS contents_created_at_id_idx ON contents (created_at DESC, id DESC);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS contents_deleted_at_idx;
+DROP INDEX IF EXISTS users_deleted_at_idx;
+
+ALTER TABLE contents ADD COLUMN is_deleted BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE contents SET is_deleted = TRUE WHERE deleted_at IS NOT NULL;
+ALTER TABLE contents DROP COLUMN deleted_by, DROP COLUMN deleted_at;
+
+ALTER TABLE users ADD COLUMN is_deleted BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE users SET is_deleted = TRUE WHERE deleted_at IS NOT NULL;
+ALTER TABLE users DROP COLUMN deleted_by, DROP COLUMN deleted_at;
@@ -0,0 +1,16 @@
+-- replace the is_deleted flag with deleted_at/deleted_by
+ALTER TABLE users
+    ADD COLUMN deleted_at BIGINT,
+    ADD COLUMN deleted_by uuid REFERENCES users(id) ON DELETE SET NULL;
+UPDATE users SET deleted_at = COALESCE(updated_at, (EXTRACT(EPOCH FROM now()) * 1000)::BIGINT) WHERE is_deleted;
+ALTER TABLE users DROP COLUMN is_deleted;
+
+ALTER TABLE contents
+    ADD COLUMN deleted_at BIGINT,
+    ADD COLUMN deleted_by uuid REFERENCES users(id) ON DELETE SET NULL;
+UPDATE contents SET deleted_at = COALESCE(updated_at, (EXTRACT(EPOCH FROM now()) * 1000)::BIGINT) WHERE is_deleted;
+ALTER TABLE contents DROP COLUMN is_deleted;
+
+-- the purge job scans the deleted rows only
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS contents_deleted_at_idx ON contents (deleted_at) WHERE deleted_at IS NOT NULL;
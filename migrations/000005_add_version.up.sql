@@ -0,0 +1,3 @@
+-- optimistic concurrency control, incremented on every update
+ALTER TABLE users ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE contents ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS users_phone_key;
+DROP INDEX IF EXISTS users_email_key;
+ALTER TABLE users
+    DROP COLUMN status,
+    DROP COLUMN email_verified,
+    DROP COLUMN password_hash,
+    DROP COLUMN age,
+    DROP COLUMN phone,
+    DROP COLUMN email;
@@ -0,0 +1,12 @@
+-- credentials & account state, nullable as the existing users have none
+ALTER TABLE users
+    ADD COLUMN email VARCHAR,
+    ADD COLUMN phone VARCHAR,
+    ADD COLUMN age SMALLINT,
+    ADD COLUMN password_hash VARCHAR,
+    ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN status VARCHAR NOT NULL DEFAULT 'active';
+
+-- emails are stored lower cased, a deleted user frees its email & phone
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS users_phone_key ON users (phone) WHERE deleted_at IS NULL;
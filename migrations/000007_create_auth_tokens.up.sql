@@ -0,0 +1,25 @@
+-- refresh tokens are stored hashed, every rotation stays in the family
+-- of the login so a reused token can revoke the whole family
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id uuid NOT NULL,
+    token_hash VARCHAR NOT NULL UNIQUE,
+    -- the access token issued with it, denylisted on revoke
+    access_jti VARCHAR NOT NULL,
+    access_expires_at BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    used_at BIGINT,
+    revoked_at BIGINT
+);
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_expires_at_idx ON refresh_tokens (expires_at);
+
+-- the jti of the revoked access tokens, kept until they expire
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR PRIMARY KEY,
+    expires_at BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS contents_user_id_idx;
+ALTER TABLE contents
+    DROP CONSTRAINT IF EXISTS contents_user_id_fkey,
+    ADD CONSTRAINT contents_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+ALTER TABLE contents DROP COLUMN published;
@@ -0,0 +1,10 @@
+-- published contents are readable by anyone, the rest by the owner & admins
+ALTER TABLE contents ADD COLUMN published BOOLEAN NOT NULL DEFAULT FALSE;
+-- the existing contents were readable by anyone
+UPDATE contents SET published = TRUE;
+
+-- the contents of a deleted user are left without owner
+ALTER TABLE contents
+    DROP CONSTRAINT IF EXISTS contents_user_id_fkey,
+    ADD CONSTRAINT contents_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS contents_user_id_idx ON contents (user_id);
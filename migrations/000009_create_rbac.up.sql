@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR PRIMARY KEY,
+    -- the roles whose permissions are inherited
+    inherits VARCHAR[] NOT NULL DEFAULT '{}',
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR PRIMARY KEY,
+    description VARCHAR NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+-- the roles of a user on top of users.role
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role)
+);
+
+-- the apps reload their cached policy on notification
+CREATE OR REPLACE FUNCTION rbac_notify() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('rbac_changed', TG_TABLE_NAME);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER roles_notify AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON roles
+    FOR EACH STATEMENT EXECUTE FUNCTION rbac_notify();
+CREATE TRIGGER permissions_notify AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON permissions
+    FOR EACH STATEMENT EXECUTE FUNCTION rbac_notify();
+CREATE TRIGGER role_permissions_notify AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON role_permissions
+    FOR EACH STATEMENT EXECUTE FUNCTION rbac_notify();
+CREATE TRIGGER user_roles_notify AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON user_roles
+    FOR EACH STATEMENT EXECUTE FUNCTION rbac_notify();
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR NOT NULL,
+    -- the public part of the key identifying it
+    prefix VARCHAR NOT NULL UNIQUE,
+    -- sha256 of the whole key, the key itself is never stored
+    key_hash VARCHAR NOT NULL,
+    -- permissions the key is limited to
+    scopes VARCHAR[] NOT NULL DEFAULT '{}',
+    -- the key acts on behalf of the user creating it
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at BIGINT NOT NULL,
+    expires_at BIGINT,
+    last_used_at BIGINT,
+    revoked_at BIGINT
+);
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE contents ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE users ALTER COLUMN created_at DROP NOT NULL;
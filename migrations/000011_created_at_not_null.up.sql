@@ -0,0 +1,7 @@
+-- keyset pagination seeks on (created_at, id), a null created_at sorts
+-- first in DESC order & is skipped by the seek so it's backfilled
+UPDATE users SET created_at = COALESCE(updated_at, (EXTRACT(EPOCH FROM now()) * 1000)::BIGINT) WHERE created_at IS NULL;
+ALTER TABLE users ALTER COLUMN created_at SET NOT NULL;
+
+UPDATE contents SET created_at = COALESCE(updated_at, (EXTRACT(EPOCH FROM now()) * 1000)::BIGINT) WHERE created_at IS NULL;
+ALTER TABLE contents ALTER COLUMN created_at SET NOT NULL;
@@ -0,0 +1,5 @@
+INSERT INTO role_permissions (role, permission)
+SELECT 'user', 'users:read'
+WHERE EXISTS (SELECT 1 FROM roles WHERE name = 'user')
+    AND EXISTS (SELECT 1 FROM permissions WHERE name = 'users:read')
+ON CONFLICT DO NOTHING;
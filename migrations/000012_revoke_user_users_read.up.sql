@@ -0,0 +1,3 @@
+-- the users listed each other's personal data, the seed only adds
+-- the new permissions so the grant is revoked from the seeded tables
+DELETE FROM role_permissions WHERE role = 'user' AND permission = 'users:read';
@@ -0,0 +1,8 @@
+// package migrations embeds the versioned sql migration files
+// files are named {version}_{name}.up.sql and {version}_{name}.down.sql
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -1,31 +1,5 @@
 -- DROP CREATE DB
+-- tables are created by the versioned migrations
+-- in the migrations dir, see `migrate up`
 DROP DATABASE IF EXISTS basic_db;
 CREATE DATABASE basic_db;
-
--- DROP CREATE TABLE
-DROP TABLE IF EXISTS users;
-CREATE TABLE users (
-    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
-    `name` varchar NOT NULL,
-    `role` varchar NOT NULL,
-    is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
-    created_at bigint,
-    updated_at bigint
-);
-
-/*
-email VARCHAR NOT NULL,
-age INT,
-phone VARCHAR NOT NULL,
-*/
-
--- DROP CREATE TABLE
-DROP TABLE IF EXISTS contents;
-CREATE TABLE contents (
-    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
-    `name` varchar NOT NULL,
-    is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
-    created_at bigint,
-    updated_at bigint,
-    user_id uuid NOT NULL REFERENCES users(id)
-);